@@ -0,0 +1,439 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: api/ml/v1/detector.proto
+
+package mlv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PredictRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ImageId string                 `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	// изображение передаётся ссылкой на бакет или байтами JPEG в теле запроса
+	//
+	// Types that are valid to be assigned to Image:
+	//
+	//	*PredictRequest_ImageUrl
+	//	*PredictRequest_ImageData
+	Image isPredictRequest_Image `protobuf_oneof:"image"`
+	// порог уверенности, ниже которого детекции не возвращаются
+	Threshold     float32 `protobuf:"fixed32,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictRequest) Reset() {
+	*x = PredictRequest{}
+	mi := &file_api_ml_v1_detector_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictRequest) ProtoMessage() {}
+
+func (x *PredictRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ml_v1_detector_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictRequest.ProtoReflect.Descriptor instead.
+func (*PredictRequest) Descriptor() ([]byte, []int) {
+	return file_api_ml_v1_detector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PredictRequest) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *PredictRequest) GetImage() isPredictRequest_Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetImageUrl() string {
+	if x != nil {
+		if x, ok := x.Image.(*PredictRequest_ImageUrl); ok {
+			return x.ImageUrl
+		}
+	}
+	return ""
+}
+
+func (x *PredictRequest) GetImageData() []byte {
+	if x != nil {
+		if x, ok := x.Image.(*PredictRequest_ImageData); ok {
+			return x.ImageData
+		}
+	}
+	return nil
+}
+
+func (x *PredictRequest) GetThreshold() float32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type isPredictRequest_Image interface {
+	isPredictRequest_Image()
+}
+
+type PredictRequest_ImageUrl struct {
+	ImageUrl string `protobuf:"bytes,2,opt,name=image_url,json=imageUrl,proto3,oneof"`
+}
+
+type PredictRequest_ImageData struct {
+	ImageData []byte `protobuf:"bytes,3,opt,name=image_data,json=imageData,proto3,oneof"`
+}
+
+func (*PredictRequest_ImageUrl) isPredictRequest_Image() {}
+
+func (*PredictRequest_ImageData) isPredictRequest_Image() {}
+
+type Detection struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// координаты рамки: x1, y1, x2, y2
+	Bbox []float32 `protobuf:"fixed32,1,rep,packed,name=bbox,proto3" json:"bbox,omitempty"`
+	// индекс класса модели
+	Class      int64   `protobuf:"varint,2,opt,name=class,proto3" json:"class,omitempty"`
+	Confidence float32 `protobuf:"fixed32,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	// эмбеддинг инструмента, packed float32
+	Embedding     []float32 `protobuf:"fixed32,4,rep,packed,name=embedding,proto3" json:"embedding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Detection) Reset() {
+	*x = Detection{}
+	mi := &file_api_ml_v1_detector_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Detection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Detection) ProtoMessage() {}
+
+func (x *Detection) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ml_v1_detector_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Detection.ProtoReflect.Descriptor instead.
+func (*Detection) Descriptor() ([]byte, []int) {
+	return file_api_ml_v1_detector_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Detection) GetBbox() []float32 {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *Detection) GetClass() int64 {
+	if x != nil {
+		return x.Class
+	}
+	return 0
+}
+
+func (x *Detection) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Detection) GetEmbedding() []float32 {
+	if x != nil {
+		return x.Embedding
+	}
+	return nil
+}
+
+type PredictResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ImageId    string                 `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	Detections []*Detection           `protobuf:"bytes,2,rep,name=detections,proto3" json:"detections,omitempty"`
+	// изображение с нанесёнными рамками, JPEG
+	DebugImage []byte `protobuf:"bytes,3,opt,name=debug_image,json=debugImage,proto3" json:"debug_image,omitempty"`
+	// идентификатор модели (имя и версия), выполнившей распознавание
+	ModelVersion  string `protobuf:"bytes,4,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PredictResponse) Reset() {
+	*x = PredictResponse{}
+	mi := &file_api_ml_v1_detector_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PredictResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PredictResponse) ProtoMessage() {}
+
+func (x *PredictResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ml_v1_detector_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PredictResponse.ProtoReflect.Descriptor instead.
+func (*PredictResponse) Descriptor() ([]byte, []int) {
+	return file_api_ml_v1_detector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PredictResponse) GetImageId() string {
+	if x != nil {
+		return x.ImageId
+	}
+	return ""
+}
+
+func (x *PredictResponse) GetDetections() []*Detection {
+	if x != nil {
+		return x.Detections
+	}
+	return nil
+}
+
+func (x *PredictResponse) GetDebugImage() []byte {
+	if x != nil {
+		return x.DebugImage
+	}
+	return nil
+}
+
+func (x *PredictResponse) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+type GetModelVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetModelVersionRequest) Reset() {
+	*x = GetModelVersionRequest{}
+	mi := &file_api_ml_v1_detector_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetModelVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModelVersionRequest) ProtoMessage() {}
+
+func (x *GetModelVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ml_v1_detector_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModelVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetModelVersionRequest) Descriptor() ([]byte, []int) {
+	return file_api_ml_v1_detector_proto_rawDescGZIP(), []int{3}
+}
+
+type GetModelVersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelVersion  string                 `protobuf:"bytes,1,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetModelVersionResponse) Reset() {
+	*x = GetModelVersionResponse{}
+	mi := &file_api_ml_v1_detector_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetModelVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModelVersionResponse) ProtoMessage() {}
+
+func (x *GetModelVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_ml_v1_detector_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModelVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetModelVersionResponse) Descriptor() ([]byte, []int) {
+	return file_api_ml_v1_detector_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetModelVersionResponse) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+var File_api_ml_v1_detector_proto protoreflect.FileDescriptor
+
+const file_api_ml_v1_detector_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/ml/v1/detector.proto\x12\x05ml.v1\"\x92\x01\n" +
+	"\x0ePredictRequest\x12\x19\n" +
+	"\bimage_id\x18\x01 \x01(\tR\aimageId\x12\x1d\n" +
+	"\timage_url\x18\x02 \x01(\tH\x00R\bimageUrl\x12\x1f\n" +
+	"\n" +
+	"image_data\x18\x03 \x01(\fH\x00R\timageData\x12\x1c\n" +
+	"\tthreshold\x18\x04 \x01(\x02R\tthresholdB\a\n" +
+	"\x05image\"s\n" +
+	"\tDetection\x12\x12\n" +
+	"\x04bbox\x18\x01 \x03(\x02R\x04bbox\x12\x14\n" +
+	"\x05class\x18\x02 \x01(\x03R\x05class\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x02R\n" +
+	"confidence\x12\x1c\n" +
+	"\tembedding\x18\x04 \x03(\x02R\tembedding\"\xa4\x01\n" +
+	"\x0fPredictResponse\x12\x19\n" +
+	"\bimage_id\x18\x01 \x01(\tR\aimageId\x120\n" +
+	"\n" +
+	"detections\x18\x02 \x03(\v2\x10.ml.v1.DetectionR\n" +
+	"detections\x12\x1f\n" +
+	"\vdebug_image\x18\x03 \x01(\fR\n" +
+	"debugImage\x12#\n" +
+	"\rmodel_version\x18\x04 \x01(\tR\fmodelVersion\"\x18\n" +
+	"\x16GetModelVersionRequest\">\n" +
+	"\x17GetModelVersionResponse\x12#\n" +
+	"\rmodel_version\x18\x01 \x01(\tR\fmodelVersion2\x96\x01\n" +
+	"\bDetector\x128\n" +
+	"\aPredict\x12\x15.ml.v1.PredictRequest\x1a\x16.ml.v1.PredictResponse\x12P\n" +
+	"\x0fGetModelVersion\x12\x1d.ml.v1.GetModelVersionRequest\x1a\x1e.ml.v1.GetModelVersionResponseB&Z$airport-tools-backend/api/ml/v1;mlv1b\x06proto3"
+
+var (
+	file_api_ml_v1_detector_proto_rawDescOnce sync.Once
+	file_api_ml_v1_detector_proto_rawDescData []byte
+)
+
+func file_api_ml_v1_detector_proto_rawDescGZIP() []byte {
+	file_api_ml_v1_detector_proto_rawDescOnce.Do(func() {
+		file_api_ml_v1_detector_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_ml_v1_detector_proto_rawDesc), len(file_api_ml_v1_detector_proto_rawDesc)))
+	})
+	return file_api_ml_v1_detector_proto_rawDescData
+}
+
+var file_api_ml_v1_detector_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_ml_v1_detector_proto_goTypes = []any{
+	(*PredictRequest)(nil),          // 0: ml.v1.PredictRequest
+	(*Detection)(nil),               // 1: ml.v1.Detection
+	(*PredictResponse)(nil),         // 2: ml.v1.PredictResponse
+	(*GetModelVersionRequest)(nil),  // 3: ml.v1.GetModelVersionRequest
+	(*GetModelVersionResponse)(nil), // 4: ml.v1.GetModelVersionResponse
+}
+var file_api_ml_v1_detector_proto_depIdxs = []int32{
+	1, // 0: ml.v1.PredictResponse.detections:type_name -> ml.v1.Detection
+	0, // 1: ml.v1.Detector.Predict:input_type -> ml.v1.PredictRequest
+	3, // 2: ml.v1.Detector.GetModelVersion:input_type -> ml.v1.GetModelVersionRequest
+	2, // 3: ml.v1.Detector.Predict:output_type -> ml.v1.PredictResponse
+	4, // 4: ml.v1.Detector.GetModelVersion:output_type -> ml.v1.GetModelVersionResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_ml_v1_detector_proto_init() }
+func file_api_ml_v1_detector_proto_init() {
+	if File_api_ml_v1_detector_proto != nil {
+		return
+	}
+	file_api_ml_v1_detector_proto_msgTypes[0].OneofWrappers = []any{
+		(*PredictRequest_ImageUrl)(nil),
+		(*PredictRequest_ImageData)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_ml_v1_detector_proto_rawDesc), len(file_api_ml_v1_detector_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_ml_v1_detector_proto_goTypes,
+		DependencyIndexes: file_api_ml_v1_detector_proto_depIdxs,
+		MessageInfos:      file_api_ml_v1_detector_proto_msgTypes,
+	}.Build()
+	File_api_ml_v1_detector_proto = out.File
+	file_api_ml_v1_detector_proto_goTypes = nil
+	file_api_ml_v1_detector_proto_depIdxs = nil
+}
@@ -9,6 +9,11 @@ import "airport-tools-backend/internal/app"
 
 // @host		localhost:8080
 // @BasePath	/api/v1
+
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Access токен в формате "Bearer <token>"
 func main() {
 	app.Run()
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	mlv1 "airport-tools-backend/api/ml/v1"
+	"airport-tools-backend/internal/mlstub"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultHttpAddr = ":8000"
+	defaultGrpcAddr = ":50051"
+	defaultClasses  = "0,1,2"
+	defaultVersion  = "mlstub-1"
+	maxMessageSize  = 64 << 20
+)
+
+// Заглушка ML-сервиса: одновременно слушает HTTP (MLSTUB_HTTP_ADDR) и gRPC (MLSTUB_GRPC_ADDR)
+// и на любое изображение возвращает по одной детекции на каждый класс из MLSTUB_CLASSES от имени модели MLSTUB_MODEL_VERSION
+func main() {
+	classes, err := parseClasses(getEnv("MLSTUB_CLASSES", defaultClasses))
+	if err != nil {
+		log.Fatal(err)
+	}
+	stub := mlstub.NewStub(classes, getEnv("MLSTUB_MODEL_VERSION", defaultVersion))
+
+	httpServer := &http.Server{
+		Addr:              getEnv("MLSTUB_HTTP_ADDR", defaultHttpAddr),
+		Handler:           stub.HTTPHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize), grpc.MaxSendMsgSize(maxMessageSize))
+	mlv1.RegisterDetectorServer(grpcServer, stub)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(mlv1.Detector_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	grpcListener, err := net.Listen("tcp", getEnv("MLSTUB_GRPC_ADDR", defaultGrpcAddr))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		log.Printf("ml stub http listening on %s", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("http server failed: %v", err)
+		}
+	}()
+
+	go func() {
+		log.Printf("ml stub grpc listening on %s", grpcListener.Addr())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatalf("grpc server failed: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	grpcServer.GracefulStop()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func parseClasses(raw string) ([]int64, error) {
+	parts := strings.Split(raw, ",")
+	classes := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		class, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if class < 0 {
+			return nil, fmt.Errorf("class index must be non-negative: %d", class)
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, nil
+}
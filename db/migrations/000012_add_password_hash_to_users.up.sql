@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash VARCHAR(256) NOT NULL DEFAULT '';
+
+-- PIN 1234 для демонстрационных пользователей из 000010_add_data_to_tables
+UPDATE users SET password_hash = '$2a$10$vbTamxyPTucJ9MHRY1kReusgepbiVXIbEFuy.ZdY3JbdBQR0s16Qe'
+WHERE employee_id IN ('AT-12321', 'AT-12345', 'AT-999999') AND password_hash = '';
\ No newline at end of file
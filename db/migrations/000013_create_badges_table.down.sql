@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS badge_id;
+
+DROP TABLE IF EXISTS badges;
\ No newline at end of file
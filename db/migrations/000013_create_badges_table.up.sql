@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS badges (
+    id BIGSERIAL PRIMARY KEY,
+    uid VARCHAR(64) UNIQUE NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    activated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS badges_user_id_idx ON badges(user_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS badge_id BIGINT REFERENCES badges(id) ON DELETE RESTRICT;
\ No newline at end of file
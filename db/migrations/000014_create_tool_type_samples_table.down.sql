@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tool_type_samples;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS tool_type_samples (
+    id BIGSERIAL PRIMARY KEY,
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE CASCADE,
+    image_url TEXT NOT NULL,
+    confidence REAL NOT NULL,
+    embedding VECTOR(1280) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS tool_type_samples_tool_type_id_idx ON tool_type_samples(tool_type_id);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE cv_scan_details DROP COLUMN IF EXISTS cosine_similarity;
+ALTER TABLE cv_scan_details DROP COLUMN IF EXISTS reference_id;
+
+DROP TABLE IF EXISTS tool_type_references;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tool_type_references (
+    id BIGSERIAL PRIMARY KEY,
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE CASCADE,
+    label VARCHAR(128) NOT NULL DEFAULT '',
+    embedding VECTOR(1280) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS tool_type_references_tool_type_id_idx ON tool_type_references(tool_type_id);
+
+ALTER TABLE cv_scan_details ADD COLUMN IF NOT EXISTS reference_id BIGINT REFERENCES tool_type_references(id) ON DELETE SET NULL;
+ALTER TABLE cv_scan_details ADD COLUMN IF NOT EXISTS cosine_similarity REAL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE tool_sets DROP CONSTRAINT IF EXISTS tool_sets_family_id_version_key;
+ALTER TABLE tool_sets ADD CONSTRAINT tool_sets_name_key UNIQUE (name);
+
+ALTER TABLE tool_sets DROP COLUMN IF EXISTS created_at;
+ALTER TABLE tool_sets DROP COLUMN IF EXISTS retired_at;
+ALTER TABLE tool_sets DROP COLUMN IF EXISTS version;
+ALTER TABLE tool_sets DROP COLUMN IF EXISTS family_id;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE tool_sets ADD COLUMN IF NOT EXISTS family_id BIGINT;
+ALTER TABLE tool_sets ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE tool_sets ADD COLUMN IF NOT EXISTS retired_at TIMESTAMP;
+ALTER TABLE tool_sets ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+UPDATE tool_sets SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE tool_sets ALTER COLUMN family_id SET NOT NULL;
+
+-- название общее для всех версий набора, уникальность названия между наборами проверяется приложением
+ALTER TABLE tool_sets DROP CONSTRAINT IF EXISTS tool_sets_name_key;
+ALTER TABLE tool_sets ADD CONSTRAINT tool_sets_family_id_version_key UNIQUE (family_id, version);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE tool_set_items DROP CONSTRAINT IF EXISTS tool_set_items_quantity_check;
+ALTER TABLE tool_set_items DROP COLUMN IF EXISTS quantity;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE tool_set_items ADD COLUMN IF NOT EXISTS quantity INT NOT NULL DEFAULT 1;
+ALTER TABLE tool_set_items ADD CONSTRAINT tool_set_items_quantity_check CHECK (quantity > 0);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transaction_instances;
+DROP TABLE IF EXISTS tool_instances;
\ No newline at end of file
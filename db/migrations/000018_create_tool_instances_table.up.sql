@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS tool_instances (
+    id BIGSERIAL PRIMARY KEY,
+    serial_number VARCHAR(64) UNIQUE NOT NULL,
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE RESTRICT,
+    home_tool_set_id BIGINT REFERENCES tool_sets(id) ON DELETE RESTRICT,
+    status VARCHAR(16) NOT NULL DEFAULT 'IN_SERVICE' CHECK (status IN ('IN_SERVICE', 'QUARANTINED', 'LOST', 'SCRAPPED')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS tool_instances_home_tool_set_id_idx ON tool_instances(home_tool_set_id);
+
+CREATE TABLE IF NOT EXISTS transaction_instances (
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tool_instance_id BIGINT NOT NULL REFERENCES tool_instances(id) ON DELETE RESTRICT,
+    PRIMARY KEY (transaction_id, tool_instance_id)
+);
+
+CREATE INDEX IF NOT EXISTS transaction_instances_tool_instance_id_idx ON transaction_instances(tool_instance_id);
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS calibration_events;
+
+DROP INDEX IF EXISTS tool_instances_calibration_due_at_idx;
+
+ALTER TABLE tool_instances DROP COLUMN IF EXISTS calibration_due_at;
+ALTER TABLE tool_instances DROP COLUMN IF EXISTS last_calibrated_at;
+
+ALTER TABLE tool_types DROP COLUMN IF EXISTS calibration_interval_days;
\ No newline at end of file
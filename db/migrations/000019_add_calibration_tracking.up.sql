@@ -0,0 +1,19 @@
+ALTER TABLE tool_types ADD COLUMN IF NOT EXISTS calibration_interval_days INTEGER CHECK (calibration_interval_days > 0);
+
+ALTER TABLE tool_instances ADD COLUMN IF NOT EXISTS last_calibrated_at TIMESTAMP;
+ALTER TABLE tool_instances ADD COLUMN IF NOT EXISTS calibration_due_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tool_instances_calibration_due_at_idx ON tool_instances(calibration_due_at);
+
+CREATE TABLE IF NOT EXISTS calibration_events (
+    id BIGSERIAL PRIMARY KEY,
+    tool_instance_id BIGINT NOT NULL REFERENCES tool_instances(id) ON DELETE CASCADE,
+    calibrated_at TIMESTAMP NOT NULL,
+    next_due_at TIMESTAMP NOT NULL,
+    performed_by BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (next_due_at > calibrated_at)
+);
+
+CREATE INDEX IF NOT EXISTS calibration_events_tool_instance_id_idx ON calibration_events(tool_instance_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS transactions_user_id_active_key;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- у пользователя не может быть больше одной незакрытой транзакции. Задвоенные до появления ограничения
+-- транзакции автоматически не исправляются: по каждой из них инструмент мог быть выдан на руки.
+-- Миграция останавливается и перечисляет пользователей, чьи транзакции нужно разобрать вручную
+DO $$
+DECLARE
+    conflicting TEXT;
+BEGIN
+    SELECT string_agg(user_id::TEXT, ', ' ORDER BY user_id) INTO conflicting
+    FROM (
+        SELECT user_id
+        FROM transactions
+        WHERE status IN ('OPEN', 'QA VERIFICATION')
+        GROUP BY user_id
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF conflicting IS NOT NULL THEN
+        RAISE EXCEPTION 'users with more than one OPEN/QA VERIFICATION transaction: %', conflicting
+            USING HINT = 'resolve the duplicate transactions of these users manually, then rerun the migration';
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_user_id_active_key ON transactions(user_id) WHERE status IN ('OPEN', 'QA VERIFICATION');
\ No newline at end of file
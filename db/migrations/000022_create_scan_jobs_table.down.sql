@@ -0,0 +1 @@
+DROP TABLE IF EXISTS scan_jobs;
\ No newline at end of file
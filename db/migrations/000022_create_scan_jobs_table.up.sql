@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS scan_jobs (
+    id UUID PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tool_set_id BIGINT NOT NULL DEFAULT 0,
+    badge_id BIGINT REFERENCES badges(id) ON DELETE SET NULL,
+    image_data TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RUNNING', 'DONE', 'FAILED')),
+    result JSONB,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS scan_jobs_status_created_at_idx ON scan_jobs(status, created_at);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS cv_scans_transaction_id_scan_type_idx;
+DROP INDEX IF EXISTS cv_scans_model_version_idx;
+
+ALTER TABLE cv_scans DROP COLUMN IF EXISTS model_version;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE cv_scans ADD COLUMN IF NOT EXISTS model_version VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS cv_scans_model_version_idx ON cv_scans(model_version);
+CREATE INDEX IF NOT EXISTS cv_scans_transaction_id_scan_type_idx ON cv_scans(transaction_id, scan_type, created_at DESC);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DELETE FROM cv_scan_details WHERE detected_tool_type_id IS NULL;
+ALTER TABLE cv_scan_details ALTER COLUMN detected_tool_type_id SET NOT NULL;
+ALTER TABLE cv_scan_details DROP COLUMN IF EXISTS class_index;
+
+DROP TABLE IF EXISTS ml_class_mappings;
\ No newline at end of file
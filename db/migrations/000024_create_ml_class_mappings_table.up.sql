@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS ml_class_mappings (
+    id BIGSERIAL PRIMARY KEY,
+    model_version VARCHAR(255) NOT NULL,
+    class_index INTEGER NOT NULL CHECK (class_index >= 0),
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (model_version, class_index)
+);
+
+CREATE INDEX IF NOT EXISTS ml_class_mappings_tool_type_id_idx ON ml_class_mappings(tool_type_id);
+
+-- прежнее неявное правило "класс модели = id типа инструмента - 1" становится сопоставлением для всех версий
+INSERT INTO ml_class_mappings (model_version, class_index, tool_type_id)
+SELECT '*', id - 1, id FROM tool_types WHERE id >= 1
+ON CONFLICT (model_version, class_index) DO NOTHING;
+
+ALTER TABLE cv_scan_details ALTER COLUMN detected_tool_type_id DROP NOT NULL;
+ALTER TABLE cv_scan_details ADD COLUMN IF NOT EXISTS class_index INTEGER;
+UPDATE cv_scan_details SET class_index = detected_tool_type_id - 1 WHERE class_index IS NULL;
+ALTER TABLE cv_scan_details ALTER COLUMN class_index SET NOT NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shadow_scan_details;
+DROP TABLE IF EXISTS shadow_scans;
\ No newline at end of file
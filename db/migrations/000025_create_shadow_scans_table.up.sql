@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS shadow_scans (
+    id BIGSERIAL PRIMARY KEY,
+    cv_scan_id BIGINT NOT NULL UNIQUE REFERENCES cv_scans(id) ON DELETE CASCADE,
+    model_version VARCHAR(255),
+    debug_image_url TEXT NOT NULL DEFAULT '',
+    status VARCHAR(32),
+    primary_status VARCHAR(32) NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS shadow_scans_created_at_idx ON shadow_scans(created_at);
+CREATE INDEX IF NOT EXISTS shadow_scans_model_version_idx ON shadow_scans(model_version);
+
+CREATE TABLE IF NOT EXISTS shadow_scan_details (
+    id BIGSERIAL PRIMARY KEY,
+    shadow_scan_id BIGINT NOT NULL REFERENCES shadow_scans(id) ON DELETE CASCADE,
+    class_index INTEGER NOT NULL,
+    tool_type_id BIGINT REFERENCES tool_types(id) ON DELETE SET NULL,
+    confidence REAL NOT NULL,
+    bbox DOUBLE PRECISION[] NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS shadow_scan_details_shadow_scan_id_idx ON shadow_scan_details(shadow_scan_id);
\ No newline at end of file
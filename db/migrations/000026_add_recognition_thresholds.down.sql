@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS tool_set_thresholds;
+
+ALTER TABLE tool_types DROP COLUMN IF EXISTS cosine_sim_threshold;
+ALTER TABLE tool_types DROP COLUMN IF EXISTS confidence_threshold;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE tool_types ADD COLUMN IF NOT EXISTS confidence_threshold REAL CHECK (confidence_threshold BETWEEN 0 AND 1);
+ALTER TABLE tool_types ADD COLUMN IF NOT EXISTS cosine_sim_threshold REAL CHECK (cosine_sim_threshold BETWEEN -1 AND 1);
+
+-- пороги задаются для семейства версий набора, чтобы редактирование состава набора их не сбрасывало
+CREATE TABLE IF NOT EXISTS tool_set_thresholds (
+    family_id BIGINT NOT NULL,
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE CASCADE,
+    confidence_threshold REAL CHECK (confidence_threshold BETWEEN 0 AND 1),
+    cosine_sim_threshold REAL CHECK (cosine_sim_threshold BETWEEN -1 AND 1),
+    PRIMARY KEY (family_id, tool_type_id)
+);
\ No newline at end of file
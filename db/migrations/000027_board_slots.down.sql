@@ -0,0 +1 @@
+DROP TABLE IF EXISTS board_slots;
\ No newline at end of file
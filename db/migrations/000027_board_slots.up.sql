@@ -0,0 +1,10 @@
+-- раскладка ложемента задаётся для семейства версий набора; polygon — вершины x1, y1, x2, y2, ... в пикселях
+CREATE TABLE IF NOT EXISTS board_slots (
+    id BIGSERIAL PRIMARY KEY,
+    family_id BIGINT NOT NULL,
+    tool_type_id BIGINT NOT NULL REFERENCES tool_types(id) ON DELETE CASCADE,
+    polygon DOUBLE PRECISION[] NOT NULL CHECK (cardinality(polygon) >= 6 AND cardinality(polygon) % 2 = 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS board_slots_family_id_idx ON board_slots(family_id);
\ No newline at end of file
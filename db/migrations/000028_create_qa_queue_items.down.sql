@@ -0,0 +1 @@
+DROP TABLE IF EXISTS qa_queue_items;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- очередь QA-проверки: строка существует, пока транзакция находится в статусе QA VERIFICATION
+CREATE TABLE IF NOT EXISTS qa_queue_items (
+    transaction_id BIGINT PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+    enqueued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    assignee_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP,
+    claimed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    claim_expires_at TIMESTAMP,
+    escalated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS qa_queue_items_enqueued_at_idx ON qa_queue_items(enqueued_at);
+CREATE INDEX IF NOT EXISTS qa_queue_items_assignee_id_idx ON qa_queue_items(assignee_id);
+
+INSERT INTO qa_queue_items (transaction_id, enqueued_at)
+SELECT id, updated_at FROM transactions WHERE status = 'QA VERIFICATION'
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE scan_jobs DROP COLUMN IF EXISTS kiosk_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS kiosk_id;
+
+-- users.role_id ссылается на роль с ON DELETE RESTRICT: роль удаляется, только если учётных записей киосков нет,
+-- иначе она остаётся вместе с ними
+DELETE FROM roles r WHERE r.name = 'Kiosk' AND NOT EXISTS (SELECT 1 FROM users u WHERE u.role_id = r.id);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- киоск выдачи авторизуется учётной записью с ролью Kiosk; киоск, через который выполнена проверка, сохраняется
+INSERT INTO roles(name) VALUES ('Kiosk') ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS kiosk_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE scan_jobs ADD COLUMN IF NOT EXISTS kiosk_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
\ No newline at end of file
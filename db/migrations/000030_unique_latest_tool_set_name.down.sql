@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tool_sets_latest_name_key;
+DROP INDEX IF EXISTS tool_sets_latest_family_id_key;
+
+ALTER TABLE tool_sets DROP COLUMN IF EXISTS is_latest;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- is_latest отмечает последнюю версию семейства: название уникально (без учёта регистра) среди последних версий наборов
+ALTER TABLE tool_sets ADD COLUMN IF NOT EXISTS is_latest BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE tool_sets SET is_latest = TRUE
+WHERE id IN (SELECT DISTINCT ON (family_id) id FROM tool_sets ORDER BY family_id, version DESC);
+
+-- названия, задвоившиеся до появления ограничения, получают суффикс с номером семейства, иначе индекс не построится
+UPDATE tool_sets t SET name = left(t.name, 240) || ' (' || t.family_id || ')'
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY lower(name) ORDER BY family_id) AS rn
+    FROM tool_sets
+    WHERE is_latest
+) d
+WHERE t.id = d.id AND d.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tool_sets_latest_family_id_key ON tool_sets(family_id) WHERE is_latest;
+CREATE UNIQUE INDEX IF NOT EXISTS tool_sets_latest_name_key ON tool_sets(lower(name)) WHERE is_latest;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE scan_jobs DROP COLUMN IF EXISTS requested_by;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- requested_by пользователь, поставивший задание в очередь: только он видит состояние и результат проверки.
+-- Для проверки по пропуску это киоск, иначе сам инженер
+ALTER TABLE scan_jobs ADD COLUMN IF NOT EXISTS requested_by BIGINT REFERENCES users(id) ON DELETE CASCADE;
+
+UPDATE scan_jobs SET requested_by = COALESCE(kiosk_id, user_id) WHERE requested_by IS NULL;
+
+ALTER TABLE scan_jobs ALTER COLUMN requested_by SET NOT NULL;
\ No newline at end of file
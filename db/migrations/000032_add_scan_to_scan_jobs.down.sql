@@ -0,0 +1,2 @@
+ALTER TABLE scan_jobs DROP COLUMN IF EXISTS scan_id;
+ALTER TABLE scan_jobs DROP COLUMN IF EXISTS transaction_id;
\ No newline at end of file
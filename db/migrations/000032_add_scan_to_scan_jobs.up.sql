@@ -0,0 +1,4 @@
+-- транзакция и скан, сохранённые проверкой задания; записываются в той же транзакции БД, что и сама проверка,
+-- поэтому задание, возвращённое в очередь после сбоя, не выполняет проверку повторно
+ALTER TABLE scan_jobs ADD COLUMN IF NOT EXISTS transaction_id BIGINT REFERENCES transactions(id) ON DELETE SET NULL;
+ALTER TABLE scan_jobs ADD COLUMN IF NOT EXISTS scan_id BIGINT REFERENCES cv_scans(id) ON DELETE SET NULL;
\ No newline at end of file
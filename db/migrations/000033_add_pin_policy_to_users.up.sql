@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN must_change_pin BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN login_locked_until TIMESTAMP,
+    ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- демонстрационный PIN 1234 из 000012 должен быть заменён при первом входе.
+-- Пользователям без PIN его задаёт QA через PUT /api/v1/qa/users/{user_id}/pin
+UPDATE users SET must_change_pin = TRUE;
\ No newline at end of file
@@ -18,7 +18,7 @@ const docTemplate = `{
     "paths": {
         "/api/v1/auth/login": {
             "post": {
-                "description": "Вход в систему по табельному номеру сотрудника.\u003cbr\u003e После успешного входа пользователь перенаправляется:\u003cbr\u003e • инженеру — на экран загрузки фотографии инструментов;\u003cbr\u003e • QA — на экран проверки незавершённых транзакций.",
+                "description": "Вход в систему по табельному номеру и PIN-коду сотрудника.\u003cbr\u003e В ответ выдаются access и refresh токены.\u003cbr\u003e PIN, выданный или сброшенный QA, нужно заменить при первом входе: без new_pin вход отклоняется с 403, с new_pin PIN заменяется и токены выдаются.\u003cbr\u003e После LOGIN_MAX_ATTEMPTS неверных PIN-кодов подряд вход сотрудника блокируется на LOGIN_LOCKOUT (429). Access токен передаётся в заголовке ` + "`" + `Authorization: Bearer \u003ctoken\u003e` + "`" + `.\u003cbr\u003e После успешного входа пользователь перенаправляется:\u003cbr\u003e • инженеру — на экран загрузки фотографии инструментов;\u003cbr\u003e • QA — на экран проверки незавершённых транзакций.",
                 "consumes": [
                     "application/json"
                 ],
@@ -53,8 +53,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "404": {
-                        "description": "Пользователь не найден",
+                    "401": {
+                        "description": "Неверный табельный номер или PIN-код",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Необходимо сменить PIN-код",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Вход временно заблокирован",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -68,9 +80,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/register": {
+        "/api/v1/auth/refresh": {
             "post": {
-                "description": "Регистрация сотрудника в системе.\u003cbr\u003e Необходимые данные: табельный номер, ФИО и роль (например, \"Инженер\" или \"QA\").\u003cbr\u003e",
+                "description": "Выдаёт новую пару access/refresh токенов по действующему refresh токену.\u003cbr\u003e Токены, выпущенные до смены или сброса PIN, недействительны.",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,23 +92,23 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Регистрация сотрудника в системе",
+                "summary": "Обновление токенов",
                 "parameters": [
                     {
-                        "description": "Данные для регистрации",
+                        "description": "Refresh токен",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.RegisterReq"
+                            "$ref": "#/definitions/v1.RefreshReq"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Регистрация успешна",
+                    "200": {
+                        "description": "Новая пара токенов",
                         "schema": {
-                            "$ref": "#/definitions/v1.RegisterRes"
+                            "$ref": "#/definitions/v1.LoginRes"
                         }
                     },
                     "400": {
@@ -105,8 +117,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "409": {
-                        "description": "Пользователь с таким табельным номером уже существует",
+                    "401": {
+                        "description": "Токен недействителен или истёк",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -120,79 +132,68 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/qa/statistics/errors": {
-            "get": {
-                "description": "Возвращает статистику ошибок системы и QA. Поддерживает:\u003cbr/\u003e- ` + "`" + `error_type=MODEL_ERR` + "`" + ` — список транзакций, где ошиблась ML-модель;\u003cbr/\u003e- ` + "`" + `error_type=HUMAN_ERR` + "`" + ` — статистика ошибок QA-инженеров;\u003cbr/\u003e- Без параметров — общее сравнение ML vs Human ошибок.",
+        "/api/v1/auth/register": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Регистрация сотрудника в системе. Доступна только QA.\u003cbr\u003e Необходимые данные: табельный номер, ФИО, роль (например, \"Инженер\" или \"QA\") и PIN-код/пароль (не короче 4 символов).\u003cbr\u003e",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "statistics"
+                    "auth"
                 ],
-                "summary": "Получить статистику ошибок",
+                "summary": "Регистрация сотрудника в системе",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Тип ошибки: MODEL_ERR или HUMAN_ERR",
-                        "name": "error_type",
-                        "in": "query"
+                        "description": "Данные для регистрации",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RegisterReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Успешный ответ",
+                    "201": {
+                        "description": "Регистрация успешна",
                         "schema": {
-                            "$ref": "#/definitions/v1.StatisticsRes"
+                            "$ref": "#/definitions/v1.RegisterRes"
                         }
                     },
                     "400": {
-                        "description": "Неверные параметры",
+                        "description": "Неверное тело запроса",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
+                    "401": {
+                        "description": "Требуется авторизация",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/qa/statistics/qa": {
-            "get": {
-                "description": "Возвращает список QA-сотрудников или статистику конкретного QA-инженера.\u003cbr/\u003eПоддерживает:\u003cbr/\u003e- ` + "`" + `employee_id` + "`" + ` — статистика проверок конкретного QA-инженера;\u003cbr/\u003e- Без параметров — список всех QA-сотрудников, выполняющих проверки.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "statistics"
-                ],
-                "summary": "Получить статистику QA",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Табельный номер QA-инженера",
-                        "name": "employee_id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Успешный ответ",
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "$ref": "#/definitions/v1.StatisticsRes"
+                            "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "400": {
-                        "description": "Неверные параметры",
+                    "409": {
+                        "description": "Пользователь с таким табельным номером уже существует",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Ошибка сервера",
+                        "description": "Внутренняя ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -200,85 +201,77 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/qa/statistics/transactions": {
+        "/api/v1/health/live": {
             "get": {
-                "description": "Возвращает агрегированную статистику по всем транзакциям:\u003cbr/\u003e- общее количество;\u003cbr/\u003e- количество QA-транзакций;\u003cbr/\u003e- количество открытых/закрытых транзакций;\u003cbr/\u003e- количество неудачных транзакций.",
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка, что сервис запущен",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/health/ready": {
+            "get": {
+                "description": "Возвращает состояние ML-сервиса по данным фоновой проверки доступности и состояние circuit breaker'а (CLOSED, OPEN, HALF_OPEN).\u003cbr\u003e Если ML-сервис недоступен или цепь разомкнута, отвечает 503.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "statistics"
+                    "health"
                 ],
-                "summary": "Получить общую статистику транзакций",
+                "summary": "Готовность сервиса выполнять проверки",
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
-                        "schema": {
-                            "$ref": "#/definitions/v1.StatisticsRes"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверные параметры",
+                        "description": "Сервис готов",
                         "schema": {
-                            "$ref": "#/definitions/v1.HTTPError"
+                            "$ref": "#/definitions/v1.ReadinessRes"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
+                    "503": {
+                        "description": "ML-сервис недоступен",
                         "schema": {
-                            "$ref": "#/definitions/v1.HTTPError"
+                            "$ref": "#/definitions/v1.ReadinessRes"
                         }
                     }
                 }
             }
         },
-        "/api/v1/qa/statistics/users": {
+        "/api/v1/qa/badges/": {
             "get": {
-                "description": "Возвращает статистику по всем инженерам или конкретному сотруднику. Поддерживает:\u003cbr/\u003e- ` + "`" + `employee_id` + "`" + ` — список транзакций конкретного пользователя (можно фильтровать по дате, лимиту транзакций, добавить среднее время работы);\u003cbr/\u003e- ` + "`" + `avg_work_duration=true` + "`" + ` — среднее время работы каждого инженера;\u003cbr/\u003e- ` + "`" + `start_date/end_date` + "`" + ` — начало и конец периода транзакций;\u003cbr/\u003e- ` + "`" + `limit` + "`" + ` — кол-во транзакций на вывод;\u003cbr/\u003e- Без параметров — список всех транзакций всех инженеров.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все пропуска сотрудника, включая отозванные.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "statistics"
+                    "QA"
                 ],
-                "summary": "Получить статистику пользователей (инженеров)",
+                "summary": "Пропуска сотрудника",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Табельный номер инженера",
+                        "description": "Табельный номер сотрудника",
                         "name": "employee_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Начало периода (формат DD-MM-YYYY)",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Конец периода (формат DD-MM-YYYY)",
-                        "name": "end_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Максимальное количество записей для вывода",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "true — получить среднее время работы каждого инженера",
-                        "name": "avg_work_duration",
-                        "in": "query"
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
+                        "description": "Список пропусков",
                         "schema": {
-                            "$ref": "#/definitions/v1.StatisticsRes"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.BadgeDTO"
+                            }
                         }
                     },
                     "400": {
@@ -287,37 +280,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
+                    "401": {
+                        "description": "Требуется авторизация",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/qa/tools/ml-errors": {
-            "get": {
-                "description": "Возвращает список наборов инструментов, где для каждого инструмента указано, сколько раз на нём была зарегистрирована ошибка MODEL_ERR",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "QA"
-                ],
-                "summary": "Возвращает наборы инструментов с ML-ошибками",
-                "responses": {
-                    "200": {
-                        "description": "Наборы и инструментами с MODEL_ERR ошибками",
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/v1.ToolSetWithErrors"
-                            }
+                            "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
-                    "400": {
-                        "description": "Неверное тело запроса",
+                    "404": {
+                        "description": "Пользователь не найден",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -329,11 +305,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/qa/tools/new_set": {
+            },
             "post": {
-                "description": "Принимает имя нового набора и список инструментов (их айди)",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Привязывает пропуск (UID RFID-метки) к сотруднику. Если activated_at не указан, пропуск активируется сразу.",
                 "consumes": [
                     "application/json"
                 ],
@@ -341,25 +320,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tools"
+                    "QA"
                 ],
-                "summary": "Создание нового набора инструментов",
+                "summary": "Зарегистрировать пропуск",
                 "parameters": [
                     {
-                        "description": "Запрос создание нового набора",
+                        "description": "Данные пропуска",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.AddToolSetReq"
+                            "$ref": "#/definitions/v1.CreateBadgeReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Новый набор",
+                    "201": {
+                        "description": "Пропуск зарегистрирован",
                         "schema": {
-                            "$ref": "#/definitions/v1.AddToolSetRes"
+                            "$ref": "#/definitions/v1.BadgeDTO"
                         }
                     },
                     "400": {
@@ -368,6 +347,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Пропуск с таким UID уже зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -377,36 +380,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/qa/transactions/": {
-            "get": {
-                "description": "Возвращает список транзакций QA.\u003cbr\u003e Можно фильтровать по статусу с помощью query-параметра ` + "`" + `status` + "`" + `.\u003cbr\u003e Допустимые значения: \u003cbr\u003e - ` + "`" + `qa` + "`" + ` или ` + "`" + `qa verification` + "`" + ` вернёт только транзакции, требующие проверки QA;\u003cbr\u003e - ` + "`" + `closed` + "`" + ` вернет закрытые транзакции;\u003cbr\u003e - ` + "`" + `open` + "`" + ` вернет открытые транзакции;\u003cbr\u003e - ` + "`" + `failed` + "`" + ` вернет транзакции с неудачной выдачей инструментов.\u003cbr\u003e Каждая транзакция содержит минимальные данные: ID, инженера, номер набора инструментов, дату создания транзакции, текущий статус.",
-                "consumes": [
-                    "application/json"
+        "/api/v1/qa/badges/:badge_id/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Отзывает утерянный пропуск. Пользователь и его транзакции не затрагиваются, отозванный пропуск больше не принимается киоском.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "QA"
                 ],
-                "summary": "Список транзакций",
+                "summary": "Отозвать пропуск",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Фильтр по статусу транзакции",
-                        "name": "status",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Идентификатор пропуска",
+                        "name": "badge_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список транзакций",
+                        "description": "Пропуск отозван",
                         "schema": {
-                            "$ref": "#/definitions/v1.ListTransactionsRes"
+                            "$ref": "#/definitions/v1.BadgeDTO"
                         }
                     },
                     "400": {
-                        "description": "Неверное тело запроса",
+                        "description": "Неверные параметры или пропуск уже отозван",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Пропуск не найден",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -420,52 +444,126 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/qa/transactions/:transaction_id": {
+        "/api/v1/qa/ml-class-mappings/": {
             "get": {
-                "description": "Получить информацию о проблемной транзакции.\u003cbr\u003eОткрывается экран сверки:\u003cbr\u003e\u003cbr\u003e • Фотография инструментов (полноразмерное изображение)\u003cbr\u003e • access_tools — инструменты, прошедшие автоматическую проверку\u003cbr\u003e • Список проблемных инструментов с пояснениями, сгруппированных по категориям:\u003cbr\u003e \u0026nbsp;\u0026nbsp;2) manual_check_tools — инструменты, требующие ручной проверки\u003cbr\u003e \u0026nbsp;\u0026nbsp;3) unknown_tools — инструменты, не входящие в ожидаемый набор\u003cbr\u003e \u0026nbsp;\u0026nbsp;4) missing_tools — инструменты, отсутствующие на фото, но ожидаемые\u003cbr\u003e\u003cbr\u003e",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает сопоставления индексов классов ML-модели с типами инструментов. Сопоставления с версией \"*\" действуют для всех версий модели, у которых нет собственного сопоставления этого класса.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "QA"
                 ],
-                "summary": "Получение информации о транзакции",
+                "summary": "Сопоставления классов модели",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Идентификатор транзакции",
-                        "name": "transaction_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Только сопоставления этой версии модели",
+                        "name": "model_version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сопоставления классов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.MLClassMappingDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверная версия модели",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
                     },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
                     {
-                        "description": "Данные о транзакции",
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сопоставляет индекс класса версии модели с типом инструмента. Версия \"*\" задаёт сопоставление для всех версий. Детекции несопоставленных классов возвращаются в unknown_tools.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Добавить сопоставление класса модели",
+                "parameters": [
+                    {
+                        "description": "Версия модели, индекс класса и тип инструмента",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.GetQAVerificationRes"
+                            "$ref": "#/definitions/v1.CreateMLClassMappingReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Информация о транзакции",
+                    "201": {
+                        "description": "Сопоставление создано",
                         "schema": {
-                            "$ref": "#/definitions/v1.VerificationRes"
+                            "$ref": "#/definitions/v1.MLClassMappingDTO"
                         }
                     },
                     "400": {
-                        "description": "Неверное тело запроса",
+                        "description": "Неверное тело запроса, версия модели или индекс класса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
                     "404": {
-                        "description": "Транзакция не найдена",
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Класс этой версии модели уже сопоставлен",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -479,9 +577,70 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/qa/transactions/:transaction_id/verification": {
-            "post": {
-                "description": "Позволяет QA-сотруднику завершить проверку транзакции, указав причину проблемы (ошибка человека / модели), добавить комментарий и пометить инструменты, на которых модель ошиблась (через ToolIds в теле запроса).",
+        "/api/v1/qa/ml-class-mappings/:mapping_id": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет сопоставление. Детекции этого класса будут возвращаться в unknown_tools, если для класса нет сопоставления с версией \"*\".",
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Удалить сопоставление класса модели",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор сопоставления",
+                        "name": "mapping_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сопоставление удалено"
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Сопоставление не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сопоставляет класс модели с другим типом инструмента. Уже сохранённые сканы не пересчитываются.",
                 "consumes": [
                     "application/json"
                 ],
@@ -491,30 +650,30 @@ const docTemplate = `{
                 "tags": [
                     "QA"
                 ],
-                "summary": "QA-проверка и завершение транзакции",
+                "summary": "Переназначить класс модели",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Идентификатор транзакции",
-                        "name": "transaction_id",
+                        "type": "integer",
+                        "description": "Идентификатор сопоставления",
+                        "name": "mapping_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные завершения QA-проверки",
+                        "description": "Новый тип инструмента",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.VerificationReq"
+                            "$ref": "#/definitions/v1.UpdateMLClassMappingReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешное закрытие транзакции",
+                        "description": "Сопоставление обновлено",
                         "schema": {
-                            "$ref": "#/definitions/v1.VerificationRes"
+                            "$ref": "#/definitions/v1.MLClassMappingDTO"
                         }
                     },
                     "400": {
@@ -523,8 +682,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
                     "404": {
-                        "description": "Транзакция не найдена",
+                        "description": "Сопоставление или тип инструмента не найдены",
                         "schema": {
                             "$ref": "#/definitions/v1.HTTPError"
                         }
@@ -538,9 +709,74 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/check": {
-            "post": {
-                "description": "Принимает табельный номер инженера и фотографию инструментов в формате base64.\u003cbr\u003e Сервис анализирует изображение, сопоставляет инструменты с ожидаемым набором и возвращает: \u003cbr\u003e\u003cbr\u003e• URL обработанного изображения \u003cbr\u003e• четыре массива: \u003cbr\u003e1) access_tools — инструменты, прошедшие автоматическую проверку\u003cbr\u003e1) manual_check_tools — инструменты, требующие ручной проверки \u003cbr\u003e2) unknown_tools — инструменты, отсутствующие в ожидаемом наборе \u003cbr\u003e3) missing_tools — инструменты, отсутствующие на фотографии, но ожидаемые\u003cbr\u003e• transaction_type - тип транзакции(Checkin - Сдача/Checkout - Выдача)\u003cbr\u003e• status - статус транзакции(OPEN - открыта, CLOSED - закрыта, QA VERIFICATION - QA проверка)\u003cbr\u003e\u003cbr\u003e Если 4 или более инструментов не попали в access_tools или за 3 попытки сканирования транзакция не закрылась, устанавливается флаг \"QA ПРОВЕРКА\" (QA VERIFICATION). \u003cbr\u003e\u003cbr\u003eЭндпоинт используется как для выдачи инструментов инженеру, так и для их последующей сдачи.",
+        "/api/v1/qa/queue/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает транзакции в статусе QA VERIFICATION от самых давних к новым. Для каждой указаны время ожидания, SLA, признак просрочки и момент эскалации, назначенный аудитор и аудитор, взявший транзакцию в работу.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Очередь QA-проверки",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "true — только транзакции, назначенные текущему аудитору",
+                        "name": "mine",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Очередь QA",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.QAQueueItemDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/queue/:transaction_id/assignee": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Закрепляет транзакцию за аудитором: взять её в работу и отправить решение сможет только он. Блокировка другого аудитора снимается. Нельзя назначить аудитора, который сам является инженером по транзакции.",
                 "consumes": [
                     "application/json"
                 ],
@@ -548,25 +784,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "QA"
                 ],
-                "summary": "Операция выдачи/сдачи инструментов",
+                "summary": "Назначить транзакцию аудитору",
                 "parameters": [
                     {
-                        "description": "Запрос на выдачу или сдачу инструментов",
+                        "type": "integer",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Табельный номер аудитора",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.CheckReq"
+                            "$ref": "#/definitions/v1.AssignQAQueueItemReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешная проверка",
+                        "description": "Элемент очереди",
                         "schema": {
-                            "$ref": "#/definitions/v1.CheckRes"
+                            "$ref": "#/definitions/v1.QAQueueItemDTO"
                         }
                     },
                     "400": {
@@ -575,6 +818,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не аудитор качества или является инженером по транзакции",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакции нет в очереди QA или пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -582,29 +843,59 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/users/roles": {
-            "get": {
-                "description": "Возвращает список всех возможных ролей пользователей в системе.",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает транзакцию в общую очередь: её снова может взять любой аудитор.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "QA"
+                ],
+                "summary": "Снять назначение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Получить список ролей",
                 "responses": {
                     "200": {
-                        "description": "Список ролей",
+                        "description": "Элемент очереди",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/v1.GetRolesRes"
-                            }
+                            "$ref": "#/definitions/v1.QAQueueItemDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакции нет в очереди QA",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
                         }
                     },
                     "500": {
@@ -615,7 +906,3453 @@ const docTemplate = `{
                     }
                 }
             }
-        }
+        },
+        "/api/v1/qa/queue/:transaction_id/claim": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Блокирует транзакцию за текущим аудитором на время, заданное QA_CLAIM_TTL. Повторный вызов продлевает блокировку. Пока блокировка действует, другой аудитор не может взять транзакцию или отправить по ней решение.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Взять транзакцию в работу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Элемент очереди",
+                        "schema": {
+                            "$ref": "#/definitions/v1.QAQueueItemDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакции нет в очереди QA",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Транзакция в работе у другого аудитора или назначена другому аудитору",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает блокировку, взятую текущим аудитором, не дожидаясь её истечения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Вернуть транзакцию в очередь",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Элемент очереди",
+                        "schema": {
+                            "$ref": "#/definitions/v1.QAQueueItemDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакции нет в очереди QA",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Транзакция не в работе у текущего аудитора",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/errors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает статистику ошибок системы и QA. Поддерживает:\u003cbr/\u003e- ` + "`" + `error_type=MODEL_ERR` + "`" + ` — список транзакций, где ошиблась ML-модель, с версией модели последнего скана сдачи;\u003cbr/\u003e- ` + "`" + `error_type=HUMAN_ERR` + "`" + ` — статистика ошибок QA-инженеров;\u003cbr/\u003e- Без параметров — общее сравнение ML vs Human ошибок с разбивкой ошибок модели по версиям.\u003cbr/\u003e\u003cbr/\u003e` + "`" + `model_version` + "`" + ` оставляет только транзакции, последний скан сдачи которых выполнен этой версией модели (не влияет на HUMAN_ERR).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Получить статистику ошибок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тип ошибки: MODEL_ERR или HUMAN_ERR",
+                        "name": "error_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия модели",
+                        "name": "model_version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.StatisticsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/model-versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Для каждой версии модели, которой выполнялись сканы: число сканов и транзакций, период использования и число ошибок MODEL_ERR, отнесённых к версии по последнему скану сдачи. Сканы, сделанные до учёта версий, собраны в группу с model_version = null.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Статистика по версиям модели",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ModelVersionStatsDTO"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/qa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список QA-сотрудников или статистику конкретного QA-инженера.\u003cbr/\u003eПоддерживает:\u003cbr/\u003e- ` + "`" + `employee_id` + "`" + ` — статистика проверок конкретного QA-инженера;\u003cbr/\u003e- Без параметров — список всех QA-сотрудников, выполняющих проверки.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Получить статистику QA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Табельный номер QA-инженера",
+                        "name": "employee_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.StatisticsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/shadow": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сравнивает распознавания основной и теневой моделей на одних и тех же изображениях: долю сканов с совпавшими детекциями, долю сканов, по которым теневая модель дала бы тот же статус транзакции, матрицу статусов и расхождения по типам инструментов. Сканы, на которых теневая модель вернула ошибку, учитываются только в failed_count.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Сравнение с теневой моделью",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Версия теневой модели",
+                        "name": "model_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (не включая), RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ShadowComparisonDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный период",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/thresholds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Повторяет автоматическую проверку сохранённых сканов сдачи с каждой парой порогов (уверенность, косинусная близость) из сетки. Детекции последних сканов транзакций с QA-решением размечаются по вердикту аудитора: детекция верна, если её тип не отмечен как ошибка модели. Для каждой пары возвращаются precision и recall автоматического прохождения и число сдач, которые ушли бы на QA. При заданном tool_type_id пороги перебираются только для этого типа, остальные типы проверяются с действующими порогами.\u003cbr\u003e В отчёт берутся не больше 2000 последних сканов периода; если сканов больше, truncated = true и период стоит сузить.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Калибровка порогов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Версия модели, выполнившей распознавание",
+                        "name": "model_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (не включая), RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Тип инструмента",
+                        "name": "tool_type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальный порог уверенности, по умолчанию 0.5",
+                        "name": "confidence_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальный порог уверенности, по умолчанию 0.95",
+                        "name": "confidence_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Шаг порога уверенности, по умолчанию 0.05",
+                        "name": "confidence_step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальный порог косинусной близости, по умолчанию 0.5",
+                        "name": "cosine_sim_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальный порог косинусной близости, по умолчанию 0.95",
+                        "name": "cosine_sim_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Шаг порога косинусной близости, по умолчанию 0.05",
+                        "name": "cosine_sim_step",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ThresholdSweepDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный период или сетка порогов",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает агрегированную статистику по всем транзакциям:\u003cbr/\u003e- общее количество;\u003cbr/\u003e- количество QA-транзакций;\u003cbr/\u003e- количество открытых/закрытых транзакций;\u003cbr/\u003e- количество неудачных транзакций.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Получить общую статистику транзакций",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.StatisticsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/statistics/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает статистику по всем инженерам или конкретному сотруднику. Поддерживает:\u003cbr/\u003e- ` + "`" + `employee_id` + "`" + ` — список транзакций конкретного пользователя (можно фильтровать по дате, лимиту транзакций, добавить среднее время работы);\u003cbr/\u003e- ` + "`" + `avg_work_duration=true` + "`" + ` — среднее время работы каждого инженера;\u003cbr/\u003e- ` + "`" + `start_date/end_date` + "`" + ` — начало и конец периода транзакций;\u003cbr/\u003e- ` + "`" + `limit` + "`" + ` — кол-во транзакций на вывод;\u003cbr/\u003e- Без параметров — список всех транзакций всех инженеров.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "statistics"
+                ],
+                "summary": "Получить статистику пользователей (инженеров)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Табельный номер инженера",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (формат DD-MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (формат DD-MM-YYYY)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество записей для вывода",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true — получить среднее время работы каждого инженера",
+                        "name": "avg_work_duration",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "$ref": "#/definitions/v1.StatisticsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-instances/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает экземпляры инструментов. Поддерживает фильтрацию по типу, набору (любая версия) и статусу.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Реестр экземпляров инструментов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Тип инструмента",
+                        "name": "tool_type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Набор, к которому приписан экземпляр",
+                        "name": "tool_set_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус: IN_SERVICE, QUARANTINED, LOST, SCRAPPED",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список экземпляров",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolInstanceDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Регистрирует физический экземпляр инструмента по серийному номеру. Если указан набор, экземпляр приписывается к нему (ко всем его версиям) и выдаётся вместе с набором. Экземпляров типа в наборе не может быть больше, чем требует набор: при выдаче выдаются все числящиеся экземпляры.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Зарегистрировать экземпляр инструмента",
+                "parameters": [
+                    {
+                        "description": "Данные экземпляра",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateToolInstanceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Экземпляр зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolInstanceDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента или набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Серийный номер уже зарегистрирован или в наборе нет места для экземпляра",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-instances/:tool_instance_id": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Карточка экземпляра инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор экземпляра",
+                        "name": "tool_instance_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Экземпляр",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolInstanceDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Экземпляр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-instances/:tool_instance_id/calibrations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Журнал поверок экземпляра",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор экземпляра",
+                        "name": "tool_instance_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поверки, начиная с последней",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.CalibrationEventDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Экземпляр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сохраняет запись о поверке и переносит срок следующей поверки экземпляра. Поверитель — текущий пользователь.\u003cbr\u003e Если calibrated_at не передан, используется текущее время. Если next_due_at не передан, срок вычисляется по периодичности типа инструмента.\u003cbr\u003e Поверка задним числом, более ранняя, чем последняя зарегистрированная, сохраняется только в журнале: даты поверки экземпляра не меняются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Зарегистрировать поверку экземпляра",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор экземпляра",
+                        "name": "tool_instance_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные поверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecordCalibrationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Поверка зарегистрирована",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CalibrationEventDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или даты поверки",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Экземпляр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Экземпляр списан",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-instances/:tool_instance_id/status": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит экземпляр в статус IN_SERVICE, QUARANTINED, LOST или SCRAPPED. Выдаются только экземпляры в статусе IN_SERVICE; списанный экземпляр вернуть в оборот нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Изменить статус экземпляра",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор экземпляра",
+                        "name": "tool_instance_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый статус",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ChangeToolInstanceStatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус изменён",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolInstanceDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или статус",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Экземпляр не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Экземпляр списан или в наборе нет места для найденного экземпляра",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-instances/calibration-due": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает экземпляры в обороте (IN_SERVICE, QUARANTINED), поверка которых просрочена или наступает в ближайшие days дней (по умолчанию 30), а также экземпляры, ни разу не прошедшие обязательную поверку.\u003cbr\u003e days_left отрицателен для просроченных экземпляров и отсутствует для не поверявшихся.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Отчёт о предстоящих поверках",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Горизонт отчёта в днях (по умолчанию 30)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Экземпляры, требующие поверки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.CalibrationDueDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает последнюю версию каждого набора. Выведенные из оборота наборы возвращаются только при include_retired=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Список наборов инструментов",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "true — включить выведенные из оборота наборы",
+                        "name": "include_retired",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список наборов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolSetDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает версию набора вместе с составом инструментов. Выведенные из оборота версии также доступны для аудита.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Версия набора инструментов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Версия набора",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolSetDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт новую версию набора с указанным названием и составом. Открытые транзакции продолжают ссылаться на версию, по которой была выдача; новые выдачи идут по последней версии.\u003cbr\u003e Набор, последняя версия которого выведена из оборота, не редактируется. Если набор одновременно отредактирован другим пользователем, возвращается 409 и редактирование нужно повторить.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Редактировать набор инструментов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор любой версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название и состав набора (повтор айди задаёт количество)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.EditToolSetReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Создана новая версия",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolSetDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или название уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "В наборе числится больше экземпляров, чем требует новая версия; набор выведен из оборота или одновременно изменён",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id/layout": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает гнёзда ложемента набора: многоугольники в пикселях изображения скана, по одному гнезду на экземпляр типа инструмента. Пустой список — расположение инструментов не проверяется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Получить раскладку ложемента набора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Раскладка ложемента",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.BoardSlotDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный идентификатор набора",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет раскладку ложемента набора. Раскладка действует для всех версий набора. При проверке каждый найденный инструмент должен лежать центром рамки в свободном гнезде своего типа, иначе он попадает в misplaced_tools и требует ручной проверки. Типы без гнёзд не проверяются; пустой список slots отключает проверку расположения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Задать раскладку ложемента набора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Гнёзда ложемента",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetBoardLayoutReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Раскладка сохранена",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.BoardSlotDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса, гнездо меньше чем из 3 точек или тип не входит в набор",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id/retire": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Помечает версию набора как выведенную из оборота. По последней выведенной версии нельзя выдавать инструменты, но она остаётся доступной для чтения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Вывести версию набора из оборота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Версия выведена из оборота",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolSetDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры или версия уже выведена",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id/thresholds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает для каждого типа инструмента версии набора применяемые пороги автоматической проверки, а также пороги, заданные для набора и для типа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Пороги проверки набора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пороги проверки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolSetThresholdDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id/thresholds/:tool_type_id": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переопределяет пороги автоматической проверки типа инструмента для набора. Пороги действуют для всех версий набора, поэтому редактирование состава их не сбрасывает. Значение null наследует порог типа; если оба порога null, переопределение удаляется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Задать пороги типа инструмента в наборе",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пороги проверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetThresholdsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пороги сохранены",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolSetThresholdDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса, порог вне диапазона или тип не входит в набор",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-sets/:tool_set_id/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все версии набора, к которому относится указанная версия, от новой к старой.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "История версий набора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор любой версии набора",
+                        "name": "tool_set_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Версии набора",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolSetDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Набор не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все типы инструментов, которые может распознать система.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Справочник типов инструментов",
+                "responses": {
+                    "200": {
+                        "description": "Список типов инструментов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolTypeDTO"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт тип инструмента с эталонным эмбеддингом. Эмбеддинг должен содержать ровно 1280 значений.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Добавить тип инструмента",
+                "parameters": [
+                    {
+                        "description": "Данные типа инструмента",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateToolTypeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Тип инструмента создан",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или размер эмбеддинга",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Тип инструмента с таким партномером уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет тип инструмента. Типы, которые входят в наборы или встречались в сканах, удалить нельзя.",
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Удалить тип инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Тип инструмента удалён"
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Тип инструмента используется",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Изменяет название типа инструмента. Партномер и эмбеддинг не меняются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Переименовать тип инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RenameToolTypeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Тип инструмента переименован",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или название не изменилось",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/calibration-interval": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Устанавливает интервал поверки в днях для типа инструмента (динамометрические ключи, манометры и т.п.). Значение null отключает контроль поверки.\u003cbr\u003e Экземпляры типа с заданной периодичностью, ни разу не прошедшие поверку, считаются просроченными и не выдаются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Задать периодичность поверки типа инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Периодичность поверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetCalibrationIntervalReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Периодичность сохранена",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или значение не изменилось",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/embedding": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет эталонный эмбеддинг типа инструмента, с которым сравниваются распознанные инструменты. Эмбеддинг должен содержать ровно 1280 значений.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Заменить эталонный эмбеддинг",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый эмбеддинг",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateToolTypeEmbeddingReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эмбеддинг обновлён",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или размер эмбеддинга",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/embedding/recompute": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Пересчитывает эталонный эмбеддинг типа инструмента по уже сохранённым образцам без повторного распознавания фотографий.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Пересчитать эталонный эмбеддинг",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эталонный эмбеддинг пересчитан",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeSamplesRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры или у типа нет образцов",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/references": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает дополнительные эталонные эмбеддинги типа инструмента (разные ракурсы и состояния). При проверке используется максимальное сходство по основному и всем дополнительным эталонам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Дополнительные эталоны инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список эталонов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolTypeReferenceDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет типу инструмента дополнительный эталонный эмбеддинг. Эмбеддинг должен содержать ровно 1280 значений.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Добавить эталон инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Эталон",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AddToolTypeReferenceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Эталон добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeReferenceDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или размер эмбеддинга",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/references/:reference_id": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет дополнительный эталон. В ранее сохранённых сканах ссылка на удалённый эталон обнуляется.",
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Удалить эталон инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор эталона",
+                        "name": "reference_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Эталон удалён"
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Эталон не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/samples": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает сохранённые образцы, по которым рассчитан эталонный эмбеддинг типа инструмента.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Эталонные фотографии инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список образцов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolTypeSampleDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает фотографии инструмента в формате base64. Каждая фотография распознаётся ML-сервисом, из детекций ожидаемого типа берётся самая уверенная, её эмбеддинг сохраняется как образец.\u003cbr\u003e После загрузки эталонный эмбеддинг типа пересчитывается как нормализованное среднее по всем образцам.\u003cbr\u003e rejected_images — индексы фотографий, на которых инструмент ожидаемого типа не найден.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Загрузить эталонные фотографии инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Фотографии инструмента",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AddToolTypeSamplesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Эталонный эмбеддинг пересчитан",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeSamplesRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или инструмент не найден ни на одной фотографии",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tool-types/:tool_type_id/thresholds": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Устанавливает пороги уверенности модели и косинусной близости к эталону, с которыми детекции типа проходят автоматическую проверку. Значение null возвращает глобальный порог (CONFIDENCE, COSINE_SIM). Пороги, заданные для набора, важнее порогов типа.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Задать пороги проверки типа инструмента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор типа инструмента",
+                        "name": "tool_type_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пороги проверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetThresholdsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пороги сохранены",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ToolTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или порог вне допустимого диапазона",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Тип инструмента не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tools/ml-errors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список наборов инструментов, где для каждого инструмента указано, сколько раз на нём была зарегистрирована ошибка MODEL_ERR, всего и по версиям модели (by_model_version). Ошибка относится к версии модели последнего скана сдачи по транзакции.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Возвращает наборы инструментов с ML-ошибками",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Учитывать только ошибки этой версии модели",
+                        "name": "model_version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Наборы и инструментами с MODEL_ERR ошибками",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolSetWithErrors"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tools/ml-errors/instances": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Детализация отчёта /qa/tools/ml-errors до конкретного экземпляра: для каждого экземпляра указано, сколько раз QA фиксировал ошибку MODEL_ERR на его типе в транзакциях, по которым этот экземпляр был выдан, всего и по версиям модели (by_model_version).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Экземпляры инструментов с ML-ошибками",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Учитывать только ошибки этой версии модели",
+                        "name": "model_version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Экземпляры с MODEL_ERR ошибками",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ToolInstanceWithErrorCount"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/tools/new_set": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает имя нового набора и список инструментов (их айди). Повтор айди задаёт количество экземпляров: [3, 3, 3, 3] — четыре инструмента одного типа.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tools"
+                ],
+                "summary": "Создание нового набора инструментов",
+                "parameters": [
+                    {
+                        "description": "Запрос создание нового набора",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AddToolSetReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новый набор",
+                        "schema": {
+                            "$ref": "#/definitions/v1.AddToolSetRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/transactions/": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список транзакций QA.\u003cbr\u003e Можно фильтровать по статусу с помощью query-параметра ` + "`" + `status` + "`" + `.\u003cbr\u003e Допустимые значения: \u003cbr\u003e - ` + "`" + `qa` + "`" + ` или ` + "`" + `qa verification` + "`" + ` вернёт только транзакции, требующие проверки QA;\u003cbr\u003e - ` + "`" + `closed` + "`" + ` вернет закрытые транзакции;\u003cbr\u003e - ` + "`" + `open` + "`" + ` вернет открытые транзакции;\u003cbr\u003e - ` + "`" + `failed` + "`" + ` вернет транзакции с неудачной выдачей инструментов.\u003cbr\u003e Каждая транзакция содержит минимальные данные: ID, инженера, номер набора инструментов, дату создания транзакции, текущий статус.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Список транзакций",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр по статусу транзакции",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список транзакций",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListTransactionsRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/transactions/:transaction_id": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получить информацию о проблемной транзакции.\u003cbr\u003eОткрывается экран сверки:\u003cbr\u003e\u003cbr\u003e • Фотография инструментов (полноразмерное изображение)\u003cbr\u003e • access_tools — инструменты, прошедшие автоматическую проверку\u003cbr\u003e • Список проблемных инструментов с пояснениями, сгруппированных по категориям:\u003cbr\u003e \u0026nbsp;\u0026nbsp;2) manual_check_tools — инструменты, требующие ручной проверки\u003cbr\u003e \u0026nbsp;\u0026nbsp;3) unknown_tools — инструменты, не входящие в ожидаемый набор\u003cbr\u003e \u0026nbsp;\u0026nbsp;4) missing_tools — инструменты, отсутствующие на фото, но ожидаемые\u003cbr\u003e\u003cbr\u003e",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "Получение информации о транзакции",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные о транзакции",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetQAVerificationRes"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация о транзакции",
+                        "schema": {
+                            "$ref": "#/definitions/v1.VerificationRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакция не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/transactions/:transaction_id/verification": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет QA-сотруднику завершить проверку транзакции, указав причину проблемы (ошибка человека / модели), добавить комментарий и пометить инструменты, на которых модель ошиблась (через ToolIds в теле запроса).\u003cbr\u003e Проверяющий определяется по access токену. Сотрудник не может проверять транзакцию, в которой он сам является инженером.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QA"
+                ],
+                "summary": "QA-проверка и завершение транзакции",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор транзакции",
+                        "name": "transaction_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные завершения QA-проверки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.VerificationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное закрытие транзакции",
+                        "schema": {
+                            "$ref": "#/definitions/v1.VerificationRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав или попытка проверить собственную транзакцию",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакция не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "По транзакции уже принято решение, она в работе у другого аудитора или назначена другому, либо запрос с этим Idempotency-Key ещё выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/qa/users/:user_id/pin": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задаёт сотруднику новый PIN-код. Доступно только QA.\u003cbr\u003e Выданные ранее токены сотрудника отзываются, блокировка входа снимается. При следующем входе сотрудник должен заменить PIN на свой (new_pin в /auth/login).",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Выдать или сбросить PIN-код сотрудника",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор сотрудника",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый PIN-код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetUserPinReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "PIN-код задан"
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/check": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Принимает фотографию инструментов в формате base64. Инженер определяется по access токену.\u003cbr\u003e Сервис анализирует изображение, сопоставляет инструменты с ожидаемым набором и возвращает: \u003cbr\u003e\u003cbr\u003e• URL обработанного изображения \u003cbr\u003e• четыре массива: \u003cbr\u003e1) access_tools — инструменты, прошедшие автоматическую проверку\u003cbr\u003e1) manual_check_tools — инструменты, требующие ручной проверки \u003cbr\u003e2) unknown_tools — инструменты, отсутствующие в ожидаемом наборе \u003cbr\u003e3) missing_tools — инструменты, отсутствующие на фотографии, но ожидаемые (по записи на каждый недостающий экземпляр)\u003cbr\u003e4) misplaced_tools — инструменты не в своём гнезде ложемента, если для набора задана раскладка; требуют ручной проверки\u003cbr\u003e5) possibly_substituted_tools — при сдаче: инструменты, непохожие на выданные по этой транзакции; такая сдача уходит на QA\u003cbr\u003e• tool_counts — ожидаемое и распознанное количество по каждому типу с излишком (surplus) и недостачей (shortfall); экземпляры сверх ожидаемого попадают в unknown_tools\u003cbr\u003e• transaction_type - тип транзакции(Checkin - Сдача/Checkout - Выдача)\u003cbr\u003e• status - статус транзакции(OPEN - открыта, CLOSED - закрыта, QA VERIFICATION - QA проверка)\u003cbr\u003e\u003cbr\u003e Если 4 или более инструментов не попали в access_tools или за 3 попытки сканирования транзакция не закрылась, устанавливается флаг \"QA ПРОВЕРКА\" (QA VERIFICATION). \u003cbr\u003e\u003cbr\u003eЭндпоинт используется как для выдачи инструментов инженеру, так и для их последующей сдачи.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Операция выдачи/сдачи инструментов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Запрос на выдачу или сдачу инструментов",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckReq"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Асинхронный режим: вернуть 202 с идентификатором задания, результат получать через /users/check/jobs/{job_id}",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная проверка",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckRes"
+                        }
+                    },
+                    "202": {
+                        "description": "Проверка поставлена в очередь (async=true)",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ScanJobDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Набор нельзя выдать (версия выведена из оборота, не хватает исправных экземпляров, истёк срок поверки) или по сотруднику уже выполняется параллельная проверка",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/check/badge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Вариант эндпоинта /users/check для киоска: инженер идентифицируется по UID пропуска (RFID), а не по введённому табельному номеру.\u003cbr\u003e Запрос выполняется с access токеном учётной записи киоска (роль Kiosk). Пропуск должен быть активирован и не отозван. Использованный пропуск и киоск сохраняются в транзакции.\u003cbr\u003e Формат ответа совпадает с /users/check.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Операция выдачи/сдачи инструментов по пропуску",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Запрос на выдачу или сдачу инструментов по пропуску",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckByBadgeReq"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Асинхронный режим: вернуть 202 с идентификатором задания, результат получать через /users/check/jobs/{job_id}",
+                        "name": "async",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная проверка",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckRes"
+                        }
+                    },
+                    "202": {
+                        "description": "Проверка поставлена в очередь (async=true)",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ScanJobDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация киоска",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрос выполнен не киоском, пропуск отозван или ещё не активирован",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Пропуск не найден",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Набор нельзя выдать (версия выведена из оборота, не хватает исправных экземпляров, истёк срок поверки) или по сотруднику уже выполняется параллельная проверка",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key уже использован с другим телом запроса",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/check/jobs/:job_id": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает состояние задания, созданного запросом /users/check?async=true (или /users/check/badge?async=true).\u003cbr\u003e status: PENDING — в очереди, RUNNING — обрабатывается, DONE — готово (result содержит ответ в формате /users/check), FAILED — ошибка (error содержит причину).\u003cbr\u003e Задание доступно только пользователю, который его поставил: инженеру или киоску.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Состояние асинхронной проверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор задания",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние задания",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ScanJobDTO"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Задание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/check/jobs/:job_id/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events: событие status с объектом ScanJobDTO отправляется при каждом изменении состояния задания. После DONE или FAILED поток закрывается. Задание доступно только пользователю, который его поставил.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Поток состояния асинхронной проверки (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор задания",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "События status",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ScanJobDTO"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Задание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/roles": {
+            "get": {
+                "description": "Возвращает список всех возможных ролей пользователей в системе.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить список ролей",
+                "responses": {
+                    "200": {
+                        "description": "Список ролей",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.GetRolesRes"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/v1.HTTPError"
+                        }
+                    }
+                }
+            }
+        }
     },
     "definitions": {
         "domain.Reason": {
@@ -624,301 +4361,1239 @@ const docTemplate = `{
                 "MODEL_ERR",
                 "HUMAN_ERR"
             ],
-            "x-enum-varnames": [
-                "ModelError",
-                "HumanError"
-            ]
+            "x-enum-varnames": [
+                "ModelError",
+                "HumanError"
+            ]
+        },
+        "domain.Status": {
+            "type": "string",
+            "enum": [
+                "OPEN",
+                "CLOSED",
+                "QA VERIFICATION",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "OPEN",
+                "CLOSED",
+                "QA",
+                "FAILED"
+            ]
+        },
+        "v1.AddToolSetReq": {
+            "type": "object",
+            "required": [
+                "tool_set_name",
+                "tools_ids"
+            ],
+            "properties": {
+                "tool_set_name": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "tools_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "v1.AddToolSetRes": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolTypeDTO"
+                    }
+                }
+            }
+        },
+        "v1.AddToolTypeReferenceReq": {
+            "type": "object",
+            "required": [
+                "embedding"
+            ],
+            "properties": {
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 128
+                }
+            }
+        },
+        "v1.AddToolTypeSamplesReq": {
+            "type": "object",
+            "required": [
+                "images"
+            ],
+            "properties": {
+                "images": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.AssignQAQueueItemReq": {
+            "type": "object",
+            "required": [
+                "employee_id"
+            ],
+            "properties": {
+                "employee_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.BadgeDTO": {
+            "type": "object",
+            "properties": {
+                "activated_at": {
+                    "type": "string"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/v1.UserDto"
+                }
+            }
+        },
+        "v1.BoardSlotDTO": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "polygon": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.PointDTO"
+                    }
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.BoardSlotReq": {
+            "type": "object",
+            "required": [
+                "polygon",
+                "tool_type_id"
+            ],
+            "properties": {
+                "polygon": {
+                    "type": "array",
+                    "minItems": 3,
+                    "items": {
+                        "$ref": "#/definitions/v1.PointDTO"
+                    }
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CalibrationDueDTO": {
+            "type": "object",
+            "properties": {
+                "days_left": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "$ref": "#/definitions/v1.ToolInstanceDTO"
+                },
+                "overdue": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.CalibrationEventDTO": {
+            "type": "object",
+            "properties": {
+                "calibrated_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "$ref": "#/definitions/v1.ToolInstanceDTO"
+                },
+                "next_due_at": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "performed_by": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ChangeToolInstanceStatusReq": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CheckByBadgeReq": {
+            "type": "object",
+            "required": [
+                "badge_uid",
+                "data"
+            ],
+            "properties": {
+                "badge_uid": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "string"
+                },
+                "tool_set_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CheckReq": {
+            "type": "object",
+            "required": [
+                "data"
+            ],
+            "properties": {
+                "data": {
+                    "type": "string"
+                },
+                "tool_set_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CheckRes": {
+            "type": "object",
+            "properties": {
+                "access_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                },
+                "debug_image_url": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolInstanceDTO"
+                    }
+                },
+                "problematic_tools": {
+                    "$ref": "#/definitions/v1.ProblematicTools"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tool_counts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolCountDTO"
+                    }
+                },
+                "transaction_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CreateBadgeReq": {
+            "type": "object",
+            "required": [
+                "employee_id",
+                "uid"
+            ],
+            "properties": {
+                "activated_at": {
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CreateMLClassMappingReq": {
+            "type": "object",
+            "required": [
+                "class_index",
+                "model_version",
+                "tool_type_id"
+            ],
+            "properties": {
+                "class_index": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "model_version": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "*"
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CreateToolInstanceReq": {
+            "type": "object",
+            "required": [
+                "serial_number",
+                "tool_type_id"
+            ],
+            "properties": {
+                "home_tool_set_id": {
+                    "type": "integer"
+                },
+                "serial_number": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CreateToolTypeReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "part_number",
+                "reference_embedding"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "part_number": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "reference_embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "v1.EditToolSetReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "tools_ids"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "tools_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "v1.GetQAVerificationRes": {
+            "type": "object",
+            "properties": {
+                "access_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolInstanceDTO"
+                    }
+                },
+                "problematic_tools": {
+                    "$ref": "#/definitions/v1.ProblematicTools"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tool_set_id": {
+                    "type": "integer"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/v1.UserDto"
+                }
+            }
+        },
+        "v1.GetRolesRes": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.HTTPError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListTransactionsRes": {
+            "type": "object",
+            "properties": {
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.TransactionDTO"
+                    }
+                }
+            }
+        },
+        "v1.LoginReq": {
+            "type": "object",
+            "required": [
+                "employee_id",
+                "password"
+            ],
+            "properties": {
+                "employee_id": {
+                    "type": "string"
+                },
+                "new_pin": {
+                    "description": "NewPin новый PIN; обязателен, если вход отклонён с требованием сменить PIN",
+                    "type": "string",
+                    "minLength": 4
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginRes": {
+            "type": "object",
+            "properties": {
+                "access_expires_at": {
+                    "type": "string"
+                },
+                "access_token": {
+                    "type": "string"
+                },
+                "refresh_expires_at": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.MLClassMappingDTO": {
+            "type": "object",
+            "properties": {
+                "class_index": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "model_version": {
+                    "type": "string"
+                },
+                "tool_type": {
+                    "$ref": "#/definitions/v1.ToolTypeDTO"
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.MLHealthDTO": {
+            "type": "object",
+            "properties": {
+                "circuit_state": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "last_checked_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "model_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ModelVersionErrorCount": {
+            "type": "object",
+            "properties": {
+                "ml_error_count": {
+                    "type": "integer"
+                },
+                "model_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ModelVersionStatsDTO": {
+            "type": "object",
+            "properties": {
+                "first_seen_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "ml_error_count": {
+                    "type": "integer"
+                },
+                "model_version": {
+                    "type": "string"
+                },
+                "scans_count": {
+                    "type": "integer"
+                },
+                "transactions_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.PointDTO": {
+            "type": "object",
+            "properties": {
+                "x": {
+                    "type": "number",
+                    "example": 120
+                },
+                "y": {
+                    "type": "number",
+                    "example": 340
+                }
+            }
+        },
+        "v1.ProblematicTools": {
+            "type": "object",
+            "properties": {
+                "manual_check_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                },
+                "misplaced_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                },
+                "missing_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolTypeDTO"
+                    }
+                },
+                "possibly_substituted_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                },
+                "unknown_tools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                    }
+                }
+            }
+        },
+        "v1.QAQueueItemDTO": {
+            "type": "object",
+            "properties": {
+                "assigned_at": {
+                    "type": "string"
+                },
+                "assignee": {
+                    "$ref": "#/definitions/v1.UserDto"
+                },
+                "claim_expires_at": {
+                    "type": "string"
+                },
+                "claimed_by": {
+                    "$ref": "#/definitions/v1.UserDto"
+                },
+                "enqueued_at": {
+                    "type": "string"
+                },
+                "escalated_at": {
+                    "type": "string"
+                },
+                "overdue": {
+                    "type": "boolean"
+                },
+                "sla_seconds": {
+                    "type": "integer"
+                },
+                "time_in_queue_seconds": {
+                    "type": "integer"
+                },
+                "tool_set_id": {
+                    "type": "integer"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/v1.UserDto"
+                }
+            }
+        },
+        "v1.ReadinessRes": {
+            "type": "object",
+            "properties": {
+                "ml": {
+                    "$ref": "#/definitions/v1.MLHealthDTO"
+                },
+                "ready": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.RecognizedToolDTO": {
+            "type": "object",
+            "properties": {
+                "bbox": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "checkout_similarity": {
+                    "description": "косинусная близость к парному инструменту на скане выдачи",
+                    "type": "number"
+                },
+                "class_index": {
+                    "type": "integer"
+                },
+                "confidence": {
+                    "type": "number"
+                },
+                "confidence_threshold": {
+                    "description": "пороги, с которыми сравнивалась детекция: объясняют, почему инструмент ушёл на ручную проверку",
+                    "type": "number"
+                },
+                "cosine_sim_threshold": {
+                    "type": "number"
+                },
+                "cosine_similarity": {
+                    "type": "number"
+                },
+                "reference_id": {
+                    "type": "integer"
+                },
+                "slot_id": {
+                    "description": "гнездо ложемента, в котором лежит инструмент; у misplaced_tools — чужое гнездо",
+                    "type": "integer"
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.RecordCalibrationReq": {
+            "type": "object",
+            "properties": {
+                "calibrated_at": {
+                    "type": "string"
+                },
+                "next_due_at": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "v1.RefreshReq": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
         },
-        "domain.Status": {
-            "type": "string",
-            "enum": [
-                "OPEN",
-                "CLOSED",
-                "QA VERIFICATION",
-                "FAILED"
+        "v1.RegisterReq": {
+            "type": "object",
+            "required": [
+                "employee_id",
+                "full_name",
+                "password",
+                "role"
             ],
-            "x-enum-varnames": [
-                "OPEN",
-                "CLOSED",
-                "QA",
-                "FAILED"
-            ]
+            "properties": {
+                "employee_id": {
+                    "type": "string"
+                },
+                "full_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 4
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
         },
-        "v1.AddToolSetReq": {
+        "v1.RegisterRes": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.RenameToolTypeReq": {
             "type": "object",
             "required": [
-                "tool_set_name",
-                "tools_ids"
+                "name"
             ],
             "properties": {
-                "tool_set_name": {
+                "name": {
                     "type": "string",
-                    "minLength": 3
+                    "maxLength": 256
+                }
+            }
+        },
+        "v1.ScanJobDTO": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
                 },
-                "tools_ids": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/v1.CheckRes"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SetBoardLayoutReq": {
+            "type": "object",
+            "properties": {
+                "slots": {
                     "type": "array",
-                    "minItems": 1,
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/v1.BoardSlotReq"
+                    }
+                }
+            }
+        },
+        "v1.SetCalibrationIntervalReq": {
+            "type": "object",
+            "properties": {
+                "interval_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SetThresholdsReq": {
+            "type": "object",
+            "properties": {
+                "confidence_threshold": {
+                    "type": "number",
+                    "example": 0.6
+                },
+                "cosine_sim_threshold": {
+                    "type": "number",
+                    "example": 0.8
+                }
+            }
+        },
+        "v1.SetUserPinReq": {
+            "type": "object",
+            "required": [
+                "pin"
+            ],
+            "properties": {
+                "pin": {
+                    "type": "string",
+                    "minLength": 4
+                }
+            }
+        },
+        "v1.ShadowComparisonDTO": {
+            "type": "object",
+            "properties": {
+                "agreement_rate": {
+                    "type": "number"
+                },
+                "failed_count": {
+                    "type": "integer"
+                },
+                "scans_count": {
+                    "type": "integer"
+                },
+                "status_agreement_rate": {
+                    "type": "number"
+                },
+                "statuses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ShadowStatusCountDTO"
+                    }
+                },
+                "tool_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ShadowToolTypeDiffDTO"
                     }
                 }
             }
         },
-        "v1.AddToolSetRes": {
+        "v1.ShadowStatusCountDTO": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "primary_status": {
+                    "$ref": "#/definitions/domain.Status"
+                },
+                "shadow_status": {
+                    "$ref": "#/definitions/domain.Status"
+                }
+            }
+        },
+        "v1.ShadowToolTypeDiffDTO": {
+            "type": "object",
+            "properties": {
+                "primary_count": {
+                    "type": "integer"
+                },
+                "scans_with_diff": {
+                    "type": "integer"
+                },
+                "shadow_count": {
+                    "type": "integer"
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                },
+                "tool_type_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.StatisticsRes": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ThresholdPairStatsDTO": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "number"
+                },
+                "cosine_sim": {
+                    "type": "number"
+                },
+                "escalations": {
+                    "type": "integer"
+                },
+                "false_negatives": {
+                    "type": "integer"
+                },
+                "false_positives": {
+                    "type": "integer"
+                },
+                "precision": {
+                    "type": "number"
+                },
+                "recall": {
+                    "type": "number"
+                },
+                "true_negatives": {
+                    "type": "integer"
+                },
+                "true_positives": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ThresholdSweepDTO": {
             "type": "object",
             "properties": {
-                "id": {
+                "current_escalations": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "labeled_detections": {
+                    "type": "integer"
                 },
-                "tools": {
+                "pairs": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.ToolTypeDTO"
+                        "$ref": "#/definitions/v1.ThresholdPairStatsDTO"
                     }
+                },
+                "resolved_scans_count": {
+                    "type": "integer"
+                },
+                "scans_count": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "type": "boolean"
                 }
             }
         },
-        "v1.CheckReq": {
+        "v1.ToolCountDTO": {
             "type": "object",
-            "required": [
-                "data",
-                "employee_id"
-            ],
             "properties": {
-                "data": {
-                    "type": "string"
+                "confidence_threshold": {
+                    "type": "number"
                 },
-                "employee_id": {
-                    "type": "string"
+                "cosine_sim_threshold": {
+                    "type": "number"
                 },
-                "tool_set_id": {
+                "detected": {
+                    "type": "integer"
+                },
+                "expected": {
+                    "type": "integer"
+                },
+                "shortfall": {
+                    "type": "integer"
+                },
+                "surplus": {
                     "type": "integer"
+                },
+                "tool_type": {
+                    "$ref": "#/definitions/v1.ToolTypeDTO"
                 }
             }
         },
-        "v1.CheckRes": {
+        "v1.ToolInstanceDTO": {
             "type": "object",
             "properties": {
-                "access_tools": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v1.RecognizedToolDTO"
-                    }
-                },
-                "debug_image_url": {
+                "calibration_due_at": {
                     "type": "string"
                 },
-                "image_url": {
+                "home_tool_set_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_calibrated_at": {
                     "type": "string"
                 },
-                "problematic_tools": {
-                    "$ref": "#/definitions/v1.ProblematicTools"
+                "serial_number": {
+                    "type": "string"
                 },
                 "status": {
                     "type": "string"
                 },
-                "transaction_type": {
+                "tool_type": {
+                    "$ref": "#/definitions/v1.ToolTypeDTO"
+                },
+                "tool_type_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "v1.GetQAVerificationRes": {
+        "v1.ToolInstanceWithErrorCount": {
             "type": "object",
             "properties": {
-                "access_tools": {
+                "by_model_version": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                        "$ref": "#/definitions/v1.ModelVersionErrorCount"
                     }
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "image_url": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "problematic_tools": {
-                    "$ref": "#/definitions/v1.ProblematicTools"
+                "ml_error_count": {
+                    "type": "integer"
                 },
-                "status": {
+                "serial_number": {
                     "type": "string"
                 },
-                "tool_set_id": {
-                    "type": "integer"
-                },
-                "transaction_id": {
+                "tool_type_id": {
                     "type": "integer"
                 },
-                "user": {
-                    "$ref": "#/definitions/v1.UserDto"
+                "tool_type_name": {
+                    "type": "string"
                 }
             }
         },
-        "v1.GetRolesRes": {
+        "v1.ToolSetDTO": {
             "type": "object",
             "properties": {
-                "roles": {
+                "created_at": {
+                    "type": "string"
+                },
+                "family_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/v1.ToolSetItemDTO"
                     }
-                }
-            }
-        },
-        "v1.HTTPError": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
                 },
-                "message": {
+                "name": {
                     "type": "string"
-                }
-            }
-        },
-        "v1.ListTransactionsRes": {
-            "type": "object",
-            "properties": {
-                "transactions": {
+                },
+                "retired_at": {
+                    "type": "string"
+                },
+                "tools": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.TransactionDTO"
+                        "$ref": "#/definitions/v1.ToolTypeDTO"
                     }
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "v1.LoginReq": {
+        "v1.ToolSetItemDTO": {
             "type": "object",
-            "required": [
-                "employee_id"
-            ],
             "properties": {
-                "employee_id": {
-                    "type": "string"
+                "quantity": {
+                    "type": "integer"
+                },
+                "tool_type_id": {
+                    "type": "integer"
                 }
             }
         },
-        "v1.LoginRes": {
+        "v1.ToolSetThresholdDTO": {
             "type": "object",
             "properties": {
-                "role": {
-                    "type": "string"
+                "confidence_threshold": {
+                    "type": "number"
+                },
+                "cosine_sim_threshold": {
+                    "type": "number"
+                },
+                "set_confidence_threshold": {
+                    "type": "number"
+                },
+                "set_cosine_sim_threshold": {
+                    "type": "number"
+                },
+                "tool_type": {
+                    "$ref": "#/definitions/v1.ToolTypeDTO"
                 }
             }
         },
-        "v1.ProblematicTools": {
+        "v1.ToolSetWithErrors": {
             "type": "object",
             "properties": {
-                "manual_check_tools": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v1.RecognizedToolDTO"
-                    }
+                "id": {
+                    "type": "integer"
                 },
-                "missing_tools": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v1.ToolTypeDTO"
-                    }
+                "name": {
+                    "type": "string"
                 },
-                "unknown_tools": {
+                "tools": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.RecognizedToolDTO"
+                        "$ref": "#/definitions/v1.ToolWithErrorCount"
                     }
                 }
             }
         },
-        "v1.RecognizedToolDTO": {
+        "v1.ToolTypeDTO": {
             "type": "object",
             "properties": {
-                "bbox": {
-                    "type": "array",
-                    "items": {
-                        "type": "number"
-                    }
+                "calibration_interval_days": {
+                    "type": "integer"
                 },
-                "confidence": {
+                "confidence_threshold": {
                     "type": "number"
                 },
-                "tool_type_id": {
+                "cosine_sim_threshold": {
+                    "type": "number"
+                },
+                "id": {
                     "type": "integer"
-                }
-            }
-        },
-        "v1.RegisterReq": {
-            "type": "object",
-            "required": [
-                "employee_id",
-                "full_name",
-                "role"
-            ],
-            "properties": {
-                "employee_id": {
-                    "type": "string"
                 },
-                "full_name": {
+                "name": {
                     "type": "string"
                 },
-                "role": {
+                "part_number": {
                     "type": "string"
                 }
             }
         },
-        "v1.RegisterRes": {
+        "v1.ToolTypeReferenceDTO": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
-                }
-            }
-        },
-        "v1.StatisticsRes": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "type": {
+                },
+                "label": {
                     "type": "string"
                 }
             }
         },
-        "v1.ToolSetWithErrors": {
+        "v1.ToolTypeSampleDTO": {
             "type": "object",
             "properties": {
+                "confidence": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "image_url": {
                     "type": "string"
-                },
-                "tools": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v1.ToolWithErrorCount"
-                    }
                 }
             }
         },
-        "v1.ToolTypeDTO": {
+        "v1.ToolTypeSamplesRes": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "integer"
+                "rejected_images": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "name": {
-                    "type": "string"
+                "samples": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ToolTypeSampleDTO"
+                    }
                 },
-                "part_number": {
-                    "type": "string"
+                "tool_type": {
+                    "$ref": "#/definitions/v1.ToolTypeDTO"
                 }
             }
         },
         "v1.ToolWithErrorCount": {
             "type": "object",
             "properties": {
+                "by_model_version": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ModelVersionErrorCount"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -950,6 +5625,31 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.UpdateMLClassMappingReq": {
+            "type": "object",
+            "required": [
+                "tool_type_id"
+            ],
+            "properties": {
+                "tool_type_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.UpdateToolTypeEmbeddingReq": {
+            "type": "object",
+            "required": [
+                "reference_embedding"
+            ],
+            "properties": {
+                "reference_embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
         "v1.UserDto": {
             "type": "object",
             "properties": {
@@ -964,22 +5664,17 @@ const docTemplate = `{
         "v1.VerificationReq": {
             "type": "object",
             "required": [
-                "qa_employee_id",
                 "reason"
             ],
             "properties": {
                 "notes": {
                     "type": "string"
                 },
-                "qa_employee_id": {
-                    "type": "string"
-                },
                 "reason": {
                     "$ref": "#/definitions/domain.Reason"
                 },
                 "tool_ids": {
                     "type": "array",
-                    "minItems": 1,
                     "items": {
                         "type": "integer"
                     }
@@ -1003,6 +5698,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Access токен в формате \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
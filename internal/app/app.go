@@ -8,6 +8,7 @@ import (
 	"airport-tools-backend/internal/repository/yandex_s3"
 	"airport-tools-backend/internal/server"
 	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/internal/worker"
 	"airport-tools-backend/pkg/logger"
 	"context"
 	"log"
@@ -46,6 +47,17 @@ func Run() {
 	toolSetRepo := postgres.NewToolSetRepository(pg.Db)
 	toolTypeRepo := postgres.NewToolTypeRepository(pg.Db)
 	transactionRepo := postgres.NewTransactionRepository(pg.Db)
+	badgeRepo := postgres.NewBadgeRepository(pg.Db)
+	sampleRepo := postgres.NewToolTypeSampleRepository(pg.Db)
+	referenceRepo := postgres.NewToolTypeReferenceRepository(pg.Db)
+	instanceRepo := postgres.NewToolInstanceRepository(pg.Db)
+	calibrationRepo := postgres.NewCalibrationEventRepository(pg.Db)
+	uow := postgres.NewUnitOfWork(pg.Db)
+	idempotencyRepo := postgres.NewIdempotencyKeyRepository(pg.Db)
+	scanJobRepo := postgres.NewScanJobRepository(pg.Db)
+	classMappingRepo := postgres.NewMLClassMappingRepository(pg.Db)
+	shadowScanRepo := postgres.NewShadowScanRepository(pg.Db)
+	qaQueueRepo := postgres.NewQAQueueRepository(pg.Db)
 
 	bucketName := os.Getenv("BUCKET_NAME")
 	s3, err := yandex_s3.InitS3(bucketName)
@@ -53,9 +65,25 @@ func Run() {
 		log.Fatal(err)
 	}
 
-	mlUrl := os.Getenv("ML_SERVICE_URL")
 	imageStorage := infrastructure.NewImageStorage(s3)
-	ml := infrastructure.NewMlGateway(http.DefaultClient, mlUrl, imageStorage)
+	ml, err := infrastructure.NewMLGatewayFromConfig(config.LoadMLConfig(), imageStorage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ml.Close()
+
+	// теневая модель необязательна: без неё распознавания на ней не выполняются
+	shadowConfig := config.LoadMLShadowConfig()
+	var shadowGateway usecase.MLGateway
+	var shadowMl infrastructure.ResilientMLGateway
+	if shadowConfig.Enabled {
+		shadowMl, err = infrastructure.NewMLGatewayFromConfig(shadowConfig.ML, imageStorage)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer shadowMl.Close()
+		shadowGateway = shadowMl
+	}
 
 	strConfidence := os.Getenv("CONFIDENCE")
 	confidence, err := strconv.ParseFloat(strConfidence, 32)
@@ -71,7 +99,17 @@ func Run() {
 	trRepo := postgres.NewTransactionResolutionsRepo(pg.Db)
 	loger := logger.NewSlogLogger()
 	roleRepo := postgres.NewRoleRepo(pg.Db)
-	service := usecase.NewService(userRepo, cvScanRepo, cvScanDetailRepo, toolTypeRepo, transactionRepo, ml, imageStorage, toolSetRepo, float32(confidence), float32(cosineSim), trRepo, loger, roleRepo)
+
+	authConfig := config.LoadAuthConfig()
+	if authConfig.Secret == "" {
+		log.Fatal("JWT_SECRET is not set")
+	}
+	tokenManager := infrastructure.NewJwtTokenManager(authConfig.Secret, authConfig.AccessTokenTTL, authConfig.RefreshTokenTTL)
+	passwordHasher := infrastructure.NewBcryptHasher(0)
+
+	qaQueueConfig := config.LoadQAQueueConfig()
+	loginConfig := config.LoadLoginConfig()
+	service := usecase.NewService(userRepo, cvScanRepo, cvScanDetailRepo, toolTypeRepo, transactionRepo, ml, imageStorage, toolSetRepo, float32(confidence), float32(cosineSim), config.LoadDuplicateIoU(), config.LoadSubstitutionSim(), trRepo, loger, roleRepo, tokenManager, passwordHasher, badgeRepo, sampleRepo, referenceRepo, instanceRepo, calibrationRepo, uow, idempotencyRepo, scanJobRepo, classMappingRepo, shadowGateway, shadowScanRepo, shadowConfig.QueueSize, qaQueueRepo, qaQueueConfig.ClaimTTL, qaQueueConfig.SLA, loginConfig.MaxAttempts, loginConfig.Lockout)
 
 	handler := v1.NewHandler(service)
 
@@ -85,6 +123,22 @@ func Run() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	go ml.RunHealthProbe(ctx)
+	if shadowMl != nil {
+		go shadowMl.RunHealthProbe(ctx)
+		go service.RunShadowScans(ctx, shadowConfig.Workers)
+	}
+
+	go worker.NewQAEscalator(service, qaQueueConfig).Run(ctx)
+	go worker.NewIdempotencyKeyPurger(service, config.LoadIdempotencyConfig()).Run(ctx)
+
+	scanWorkers := worker.NewScanWorkerPool(service, config.LoadScanWorkerConfig())
+	workersDone := make(chan struct{})
+	go func() {
+		scanWorkers.Run(ctx)
+		close(workersDone)
+	}()
+
 	go func() {
 		log.Printf("starting server on port %s", serverConfig.Port)
 		if err := server.Run(); err != nil && err != http.ErrServerClosed {
@@ -102,5 +156,11 @@ func Run() {
 		log.Fatalf("server forced to shutdown: %v", err)
 	}
 
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		log.Println("scan workers did not finish in time, unfinished jobs will be requeued")
+	}
+
 	log.Println("server stopped gracefully")
 }
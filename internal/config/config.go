@@ -2,11 +2,58 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	defaultPort = "8080"
+	defaultPort             = "8080"
+	defaultAccessTokenTTL   = 15 * time.Minute
+	defaultRefreshTokenTTL  = 7 * 24 * time.Hour
+	defaultLoginMaxAttempts = 5
+	defaultLoginLockout     = 15 * time.Minute
+
+	defaultScanWorkers         = 2
+	defaultScanJobPollInterval = time.Second
+	defaultScanJobTimeout      = 5 * time.Minute
+
+	defaultMLTimeout          = 30 * time.Second
+	defaultMLMaxRetries       = 2
+	defaultMLRetryBaseDelay   = 200 * time.Millisecond
+	defaultMLBreakerThreshold = 5
+	defaultMLBreakerCooldown  = 30 * time.Second
+	defaultMLHealthInterval   = 15 * time.Second
+
+	defaultMLShadowWorkers   = 2
+	defaultMLShadowQueueSize = 100
+
+	defaultDuplicateIoU    = 0.5
+	defaultSubstitutionSim = 0.8
+
+	defaultQAClaimTTL           = 15 * time.Minute
+	defaultQASLA                = 4 * time.Hour
+	defaultQAEscalationInterval = time.Minute
+
+	defaultIdempotencyPurgeInterval = time.Hour
+)
+
+// MLProtocol протокол взаимодействия с ML-сервисом
+type MLProtocol string
+
+const (
+	MLProtocolHttp MLProtocol = "http"
+	MLProtocolGrpc MLProtocol = "grpc"
+)
+
+// MLTransport способ передачи изображения в ML-сервис
+type MLTransport string
+
+const (
+	// MLTransportUrl ML-сервис сам скачивает изображение по ссылке на бакет
+	MLTransportUrl MLTransport = "url"
+	// MLTransportUpload изображение передаётся в теле запроса (multipart/form-data), бакет может быть закрытым
+	MLTransportUpload MLTransport = "upload"
 )
 
 type HttpServer struct {
@@ -15,6 +62,68 @@ type HttpServer struct {
 	WriteTimeout time.Duration
 }
 
+type Auth struct {
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// Login ограничение подбора PIN-кода: после MaxAttempts неверных попыток подряд вход сотрудника блокируется на Lockout
+type Login struct {
+	MaxAttempts int
+	Lockout     time.Duration
+}
+
+// ScanWorker параметры пула воркеров асинхронной проверки
+type ScanWorker struct {
+	Workers      int
+	PollInterval time.Duration
+	// JobTimeout ограничение времени одной попытки; задание, не завершённое за 2*JobTimeout, возвращается в очередь
+	JobTimeout time.Duration
+}
+
+// QAQueue параметры очереди QA-проверки
+type QAQueue struct {
+	// ClaimTTL срок, на который аудитор берёт транзакцию в работу; по истечении её может взять другой аудитор
+	ClaimTTL time.Duration
+	// SLA допустимое время ожидания транзакции в очереди, после которого она эскалируется
+	SLA                time.Duration
+	EscalationInterval time.Duration
+}
+
+// Idempotency параметры хранения ключей идемпотентности: истёкшие ключи удаляются каждые PurgeInterval
+type Idempotency struct {
+	PurgeInterval time.Duration
+}
+
+// MLShadow параметры теневой модели: каждое распознавание в фоне дублируется на неё для сравнения с основной.
+// Если очередь заполнена, распознавание на теневой модели пропускается
+type MLShadow struct {
+	Enabled   bool
+	ML        ML
+	Workers   int
+	QueueSize int
+}
+
+// ML параметры подключения к ML-сервису
+type ML struct {
+	Protocol MLProtocol
+	Url      string
+	// GrpcAddr адрес gRPC-сервера ML-сервиса (host:port) для протокола grpc
+	GrpcAddr  string
+	Transport MLTransport
+	// HealthUrl адрес проверки доступности; по умолчанию Url + "/health"
+	HealthUrl string
+	// Timeout ограничение одной попытки вызова
+	Timeout        time.Duration
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	// BreakerThreshold число неудачных вызовов подряд, после которого вызовы отклоняются без обращения к сервису
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	HealthInterval   time.Duration
+}
+
 // LoadHttpServerConfig загружает конфигурацию HTTP-сервера из переменных окружения
 func LoadHttpServerConfig() HttpServer {
 	port := os.Getenv("HTTP_PORT")
@@ -31,3 +140,221 @@ func LoadHttpServerConfig() HttpServer {
 		WriteTimeout: writeTimeout,
 	}
 }
+
+// LoadAuthConfig загружает параметры подписи и срока жизни токенов из переменных окружения
+func LoadAuthConfig() Auth {
+	accessTTL, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL"))
+	if err != nil || accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+
+	refreshTTL, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL"))
+	if err != nil || refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+
+	return Auth{
+		Secret:          os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:  accessTTL,
+		RefreshTokenTTL: refreshTTL,
+	}
+}
+
+// LoadLoginConfig загружает ограничение попыток входа из переменных окружения
+func LoadLoginConfig() Login {
+	maxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = defaultLoginMaxAttempts
+	}
+
+	lockout, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT"))
+	if err != nil || lockout <= 0 {
+		lockout = defaultLoginLockout
+	}
+
+	return Login{
+		MaxAttempts: maxAttempts,
+		Lockout:     lockout,
+	}
+}
+
+// LoadScanWorkerConfig загружает параметры пула воркеров асинхронной проверки из переменных окружения
+func LoadScanWorkerConfig() ScanWorker {
+	workers, err := strconv.Atoi(os.Getenv("SCAN_WORKERS"))
+	if err != nil || workers <= 0 {
+		workers = defaultScanWorkers
+	}
+
+	pollInterval, err := time.ParseDuration(os.Getenv("SCAN_JOB_POLL_INTERVAL"))
+	if err != nil || pollInterval <= 0 {
+		pollInterval = defaultScanJobPollInterval
+	}
+
+	jobTimeout, err := time.ParseDuration(os.Getenv("SCAN_JOB_TIMEOUT"))
+	if err != nil || jobTimeout <= 0 {
+		jobTimeout = defaultScanJobTimeout
+	}
+
+	return ScanWorker{
+		Workers:      workers,
+		PollInterval: pollInterval,
+		JobTimeout:   jobTimeout,
+	}
+}
+
+// LoadMLConfig загружает параметры ML-сервиса из переменных окружения
+func LoadMLConfig() ML {
+	mlUrl := os.Getenv("ML_SERVICE_URL")
+
+	protocol := MLProtocol(strings.ToLower(os.Getenv("ML_PROTOCOL")))
+	if protocol != MLProtocolGrpc {
+		protocol = MLProtocolHttp
+	}
+
+	transport := MLTransport(strings.ToLower(os.Getenv("ML_TRANSPORT_MODE")))
+	if transport != MLTransportUpload {
+		transport = MLTransportUrl
+	}
+
+	healthUrl := os.Getenv("ML_HEALTH_URL")
+	if healthUrl == "" {
+		healthUrl = strings.TrimSuffix(mlUrl, "/") + "/health"
+	}
+
+	timeout, err := time.ParseDuration(os.Getenv("ML_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = defaultMLTimeout
+	}
+
+	maxRetries, err := strconv.Atoi(os.Getenv("ML_MAX_RETRIES"))
+	if err != nil || maxRetries < 0 {
+		maxRetries = defaultMLMaxRetries
+	}
+
+	retryBaseDelay, err := time.ParseDuration(os.Getenv("ML_RETRY_BASE_DELAY"))
+	if err != nil || retryBaseDelay <= 0 {
+		retryBaseDelay = defaultMLRetryBaseDelay
+	}
+
+	breakerThreshold, err := strconv.Atoi(os.Getenv("ML_BREAKER_THRESHOLD"))
+	if err != nil || breakerThreshold <= 0 {
+		breakerThreshold = defaultMLBreakerThreshold
+	}
+
+	breakerCooldown, err := time.ParseDuration(os.Getenv("ML_BREAKER_COOLDOWN"))
+	if err != nil || breakerCooldown <= 0 {
+		breakerCooldown = defaultMLBreakerCooldown
+	}
+
+	healthInterval, err := time.ParseDuration(os.Getenv("ML_HEALTH_INTERVAL"))
+	if err != nil || healthInterval <= 0 {
+		healthInterval = defaultMLHealthInterval
+	}
+
+	return ML{
+		Protocol:         protocol,
+		Url:              mlUrl,
+		GrpcAddr:         os.Getenv("ML_GRPC_ADDR"),
+		Transport:        transport,
+		HealthUrl:        healthUrl,
+		Timeout:          timeout,
+		MaxRetries:       maxRetries,
+		RetryBaseDelay:   retryBaseDelay,
+		BreakerThreshold: breakerThreshold,
+		BreakerCooldown:  breakerCooldown,
+		HealthInterval:   healthInterval,
+	}
+}
+
+// LoadMLShadowConfig загружает параметры теневой модели. Адрес задаётся ML_SHADOW_URL или ML_SHADOW_GRPC_ADDR
+// (протокол — ML_SHADOW_PROTOCOL, по умолчанию как у основной модели), остальные параметры берутся у основной модели
+func LoadMLShadowConfig() MLShadow {
+	cfg := LoadMLConfig()
+
+	if protocol := MLProtocol(strings.ToLower(os.Getenv("ML_SHADOW_PROTOCOL"))); protocol == MLProtocolHttp || protocol == MLProtocolGrpc {
+		cfg.Protocol = protocol
+	}
+	cfg.Url = os.Getenv("ML_SHADOW_URL")
+	cfg.GrpcAddr = os.Getenv("ML_SHADOW_GRPC_ADDR")
+	cfg.HealthUrl = strings.TrimSuffix(cfg.Url, "/") + "/health"
+
+	enabled := cfg.Url != ""
+	if cfg.Protocol == MLProtocolGrpc {
+		enabled = cfg.GrpcAddr != ""
+	}
+
+	workers, err := strconv.Atoi(os.Getenv("ML_SHADOW_WORKERS"))
+	if err != nil || workers <= 0 {
+		workers = defaultMLShadowWorkers
+	}
+
+	queueSize, err := strconv.Atoi(os.Getenv("ML_SHADOW_QUEUE_SIZE"))
+	if err != nil || queueSize <= 0 {
+		queueSize = defaultMLShadowQueueSize
+	}
+
+	return MLShadow{
+		Enabled:   enabled,
+		ML:        cfg,
+		Workers:   workers,
+		QueueSize: queueSize,
+	}
+}
+
+// LoadDuplicateIoU загружает DUPLICATE_IOU — IoU рамок, начиная с которого детекции одного класса
+// считаются одним инструментом. 0 отключает подавление повторов
+func LoadDuplicateIoU() float32 {
+	iou, err := strconv.ParseFloat(os.Getenv("DUPLICATE_IOU"), 32)
+	if err != nil || iou < 0 || iou > 1 {
+		return defaultDuplicateIoU
+	}
+
+	return float32(iou)
+}
+
+// LoadSubstitutionSim загружает SUBSTITUTION_SIM — косинусную близость сданного инструмента к выданному,
+// ниже которой инструмент считается возможно подменённым. -1 отключает проверку
+func LoadSubstitutionSim() float32 {
+	sim, err := strconv.ParseFloat(os.Getenv("SUBSTITUTION_SIM"), 32)
+	if err != nil || sim < -1 || sim > 1 {
+		return defaultSubstitutionSim
+	}
+
+	return float32(sim)
+}
+
+// LoadQAQueueConfig загружает параметры очереди QA-проверки из переменных окружения
+func LoadQAQueueConfig() QAQueue {
+	claimTTL, err := time.ParseDuration(os.Getenv("QA_CLAIM_TTL"))
+	if err != nil || claimTTL <= 0 {
+		claimTTL = defaultQAClaimTTL
+	}
+
+	sla, err := time.ParseDuration(os.Getenv("QA_SLA"))
+	if err != nil || sla <= 0 {
+		sla = defaultQASLA
+	}
+
+	escalationInterval, err := time.ParseDuration(os.Getenv("QA_ESCALATION_INTERVAL"))
+	if err != nil || escalationInterval <= 0 {
+		escalationInterval = defaultQAEscalationInterval
+	}
+
+	return QAQueue{
+		ClaimTTL:           claimTTL,
+		SLA:                sla,
+		EscalationInterval: escalationInterval,
+	}
+}
+
+// LoadIdempotencyConfig загружает параметры хранения ключей идемпотентности из переменных окружения
+func LoadIdempotencyConfig() Idempotency {
+	purgeInterval, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_PURGE_INTERVAL"))
+	if err != nil || purgeInterval <= 0 {
+		purgeInterval = defaultIdempotencyPurgeInterval
+	}
+
+	return Idempotency{
+		PurgeInterval: purgeInterval,
+	}
+}
@@ -8,9 +8,76 @@ import (
 )
 
 type ToolWithErrorCount struct {
-	ID           int64  `json:"id"`
-	Name         string `json:"name"`
-	MLErrorCount int64  `json:"ml_error_count"`
+	ID             int64                    `json:"id"`
+	Name           string                   `json:"name"`
+	MLErrorCount   int64                    `json:"ml_error_count"`
+	ByModelVersion []ModelVersionErrorCount `json:"by_model_version"`
+}
+
+// ModelVersionErrorCount число ошибок модели по версии; model_version null — сканы без сохранённой версии
+type ModelVersionErrorCount struct {
+	ModelVersion *string `json:"model_version"`
+	MLErrorCount int64   `json:"ml_error_count"`
+}
+
+type ModelVersionStatsDTO struct {
+	ModelVersion      *string   `json:"model_version"`
+	ScansCount        int64     `json:"scans_count"`
+	TransactionsCount int64     `json:"transactions_count"`
+	MLErrorCount      int64     `json:"ml_error_count"`
+	FirstSeenAt       time.Time `json:"first_seen_at"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+}
+
+// ShadowComparisonDTO сравнение основной и теневой моделей. agreement_rate — доля сканов, в которых обе модели
+// нашли одинаковое число инструментов каждого типа, status_agreement_rate — доля сканов с тем же статусом транзакции
+type ShadowComparisonDTO struct {
+	ScansCount          int64                   `json:"scans_count"`
+	FailedCount         int64                   `json:"failed_count"`
+	AgreementRate       float64                 `json:"agreement_rate"`
+	StatusAgreementRate float64                 `json:"status_agreement_rate"`
+	Statuses            []ShadowStatusCountDTO  `json:"statuses"`
+	ToolTypes           []ShadowToolTypeDiffDTO `json:"tool_types"`
+}
+
+type ShadowStatusCountDTO struct {
+	PrimaryStatus domain.Status `json:"primary_status"`
+	ShadowStatus  domain.Status `json:"shadow_status"`
+	Count         int64         `json:"count"`
+}
+
+type ShadowToolTypeDiffDTO struct {
+	ToolTypeId    int64  `json:"tool_type_id"`
+	ToolTypeName  string `json:"tool_type_name"`
+	PrimaryCount  int64  `json:"primary_count"`
+	ShadowCount   int64  `json:"shadow_count"`
+	ScansWithDiff int64  `json:"scans_with_diff"`
+}
+
+// ThresholdSweepDTO отчёт калибровки порогов. labeled_detections — детекции из сканов с QA-решением,
+// current_escalations — сдачи, ушедшие бы на QA при действующих порогах, truncated — прогнаны только последние
+// сканы периода
+type ThresholdSweepDTO struct {
+	ScansCount         int                     `json:"scans_count"`
+	Truncated          bool                    `json:"truncated"`
+	ResolvedScansCount int                     `json:"resolved_scans_count"`
+	LabeledDetections  int                     `json:"labeled_detections"`
+	CurrentEscalations int                     `json:"current_escalations"`
+	Pairs              []ThresholdPairStatsDTO `json:"pairs"`
+}
+
+// ThresholdPairStatsDTO результат прогона с одной парой порогов: precision — доля верных детекций среди прошедших
+// автоматическую проверку, recall — доля прошедших среди верных, escalations — сдачи, которые ушли бы на QA
+type ThresholdPairStatsDTO struct {
+	Confidence     float32 `json:"confidence"`
+	CosineSim      float32 `json:"cosine_sim"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	TrueNegatives  int     `json:"true_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	Escalations    int     `json:"escalations"`
 }
 
 type ToolSetWithErrors struct {
@@ -46,12 +113,138 @@ func toArrDeliveryToolWithErrorCount(arr []repository.ToolWithErrorCount) []Tool
 
 func toDeliveryToolWithErrorCount(res repository.ToolWithErrorCount) ToolWithErrorCount {
 	return ToolWithErrorCount{
-		ID:           res.ID,
-		Name:         res.Name,
-		MLErrorCount: res.MLErrorCount,
+		ID:             res.ID,
+		Name:           res.Name,
+		MLErrorCount:   res.MLErrorCount,
+		ByModelVersion: toArrDeliveryModelVersionErrorCount(res.ByModelVersion),
 	}
 }
 
+func toArrDeliveryModelVersionErrorCount(arr []repository.ModelVersionErrorCount) []ModelVersionErrorCount {
+	res := make([]ModelVersionErrorCount, len(arr))
+	for i, count := range arr {
+		res[i] = ModelVersionErrorCount{
+			ModelVersion: count.ModelVersion,
+			MLErrorCount: count.MLErrorCount,
+		}
+	}
+
+	return res
+}
+
+func toDeliveryShadowComparisonDTO(comparison *usecase.ShadowComparisonDTO) *ShadowComparisonDTO {
+	res := &ShadowComparisonDTO{
+		ScansCount:          comparison.ScansCount,
+		FailedCount:         comparison.FailedCount,
+		AgreementRate:       comparison.AgreementRate,
+		StatusAgreementRate: comparison.StatusAgreementRate,
+		Statuses:            make([]ShadowStatusCountDTO, len(comparison.Statuses)),
+		ToolTypes:           make([]ShadowToolTypeDiffDTO, len(comparison.ToolTypes)),
+	}
+
+	for i, status := range comparison.Statuses {
+		res.Statuses[i] = ShadowStatusCountDTO{
+			PrimaryStatus: status.PrimaryStatus,
+			ShadowStatus:  status.ShadowStatus,
+			Count:         status.Count,
+		}
+	}
+
+	for i, toolType := range comparison.ToolTypes {
+		res.ToolTypes[i] = ShadowToolTypeDiffDTO{
+			ToolTypeId:    toolType.ToolTypeId,
+			ToolTypeName:  toolType.ToolTypeName,
+			PrimaryCount:  toolType.PrimaryCount,
+			ShadowCount:   toolType.ShadowCount,
+			ScansWithDiff: toolType.ScansWithDiff,
+		}
+	}
+
+	return res
+}
+
+func toUsecaseSetBoardLayoutReq(toolSetId int64, req *SetBoardLayoutReq) *usecase.SetBoardLayoutReq {
+	res := &usecase.SetBoardLayoutReq{
+		ToolSetId: toolSetId,
+		Slots:     make([]*usecase.BoardSlotReq, len(req.Slots)),
+	}
+
+	for i, slot := range req.Slots {
+		polygon := make([]domain.Point, len(slot.Polygon))
+		for j, point := range slot.Polygon {
+			polygon[j] = domain.Point{X: point.X, Y: point.Y}
+		}
+
+		res.Slots[i] = &usecase.BoardSlotReq{
+			ToolTypeId: slot.ToolTypeId,
+			Polygon:    polygon,
+		}
+	}
+
+	return res
+}
+
+func toArrDeliveryBoardSlotDTO(slots []*domain.BoardSlot) []BoardSlotDTO {
+	res := make([]BoardSlotDTO, len(slots))
+	for i, slot := range slots {
+		polygon := make([]PointDTO, len(slot.Polygon))
+		for j, point := range slot.Polygon {
+			polygon[j] = PointDTO{X: point.X, Y: point.Y}
+		}
+
+		res[i] = BoardSlotDTO{
+			Id:         slot.Id,
+			ToolTypeId: slot.ToolTypeId,
+			Polygon:    polygon,
+		}
+	}
+
+	return res
+}
+
+func toDeliveryThresholdSweepDTO(sweep *usecase.ThresholdSweepRes) *ThresholdSweepDTO {
+	res := &ThresholdSweepDTO{
+		ScansCount:         sweep.ScansCount,
+		Truncated:          sweep.Truncated,
+		ResolvedScansCount: sweep.ResolvedScansCount,
+		LabeledDetections:  sweep.LabeledDetections,
+		CurrentEscalations: sweep.CurrentEscalations,
+		Pairs:              make([]ThresholdPairStatsDTO, len(sweep.Pairs)),
+	}
+
+	for i, pair := range sweep.Pairs {
+		res.Pairs[i] = ThresholdPairStatsDTO{
+			Confidence:     pair.Thresholds.Confidence,
+			CosineSim:      pair.Thresholds.CosineSim,
+			TruePositives:  pair.TruePositives,
+			FalsePositives: pair.FalsePositives,
+			FalseNegatives: pair.FalseNegatives,
+			TrueNegatives:  pair.TrueNegatives,
+			Precision:      pair.Precision,
+			Recall:         pair.Recall,
+			Escalations:    pair.Escalations,
+		}
+	}
+
+	return res
+}
+
+func toArrDeliveryModelVersionStats(arr []*repository.ModelVersionStats) []ModelVersionStatsDTO {
+	res := make([]ModelVersionStatsDTO, len(arr))
+	for i, stats := range arr {
+		res[i] = ModelVersionStatsDTO{
+			ModelVersion:      stats.ModelVersion,
+			ScansCount:        stats.ScansCount,
+			TransactionsCount: stats.TransactionsCount,
+			MLErrorCount:      stats.MLErrorCount,
+			FirstSeenAt:       stats.FirstSeenAt,
+			LastSeenAt:        stats.LastSeenAt,
+		}
+	}
+
+	return res
+}
+
 type AddToolSetRes struct {
 	Id    int64          `json:"id"`
 	Name  string         `json:"name"`
@@ -63,6 +256,95 @@ type AddToolSetReq struct {
 	ToolsIds    []int64 `json:"tools_ids" binding:"required,min=1,dive,gt=0"`
 }
 
+type EditToolSetReq struct {
+	Name     string  `json:"name" binding:"required,min=3"`
+	ToolsIds []int64 `json:"tools_ids" binding:"required,min=1,dive,gt=0"`
+}
+
+type ToolSetDTO struct {
+	Id        int64             `json:"id"`
+	FamilyId  int64             `json:"family_id"`
+	Version   int               `json:"version"`
+	Name      string            `json:"name"`
+	RetiredAt *time.Time        `json:"retired_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	Tools     []*ToolTypeDTO    `json:"tools"`
+	Items     []*ToolSetItemDTO `json:"items"`
+}
+
+type ToolSetItemDTO struct {
+	ToolTypeId int64 `json:"tool_type_id"`
+	Quantity   int   `json:"quantity"`
+}
+
+type CreateToolInstanceReq struct {
+	SerialNumber  string `json:"serial_number" binding:"required,max=64"`
+	ToolTypeId    int64  `json:"tool_type_id" binding:"required,gt=0"`
+	HomeToolSetId *int64 `json:"home_tool_set_id" binding:"omitempty,gt=0"`
+}
+
+type ChangeToolInstanceStatusReq struct {
+	Status string `json:"status" binding:"required"`
+}
+
+type ToolInstanceDTO struct {
+	Id            int64        `json:"id"`
+	SerialNumber  string       `json:"serial_number"`
+	ToolTypeId    int64        `json:"tool_type_id"`
+	ToolType      *ToolTypeDTO `json:"tool_type,omitempty"`
+	HomeToolSetId *int64       `json:"home_tool_set_id"`
+	Status        string       `json:"status"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+
+	LastCalibratedAt *time.Time `json:"last_calibrated_at"`
+	CalibrationDueAt *time.Time `json:"calibration_due_at"`
+}
+
+type SetCalibrationIntervalReq struct {
+	IntervalDays *int `json:"interval_days" binding:"omitempty,gt=0"`
+}
+
+type RecordCalibrationReq struct {
+	CalibratedAt *time.Time `json:"calibrated_at"`
+	NextDueAt    *time.Time `json:"next_due_at"`
+	Notes        string     `json:"notes" binding:"max=1000"`
+}
+
+type CalibrationEventDTO struct {
+	Id           int64            `json:"id"`
+	Instance     *ToolInstanceDTO `json:"instance,omitempty"`
+	CalibratedAt time.Time        `json:"calibrated_at"`
+	NextDueAt    time.Time        `json:"next_due_at"`
+	PerformedBy  int64            `json:"performed_by"`
+	Notes        string           `json:"notes"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+type CalibrationDueDTO struct {
+	Instance *ToolInstanceDTO `json:"instance"`
+	Overdue  bool             `json:"overdue"`
+	DaysLeft *int             `json:"days_left,omitempty"`
+}
+
+type ToolInstanceWithErrorCount struct {
+	ID             int64                    `json:"id"`
+	SerialNumber   string                   `json:"serial_number"`
+	ToolTypeId     int64                    `json:"tool_type_id"`
+	ToolTypeName   string                   `json:"tool_type_name"`
+	MLErrorCount   int64                    `json:"ml_error_count"`
+	ByModelVersion []ModelVersionErrorCount `json:"by_model_version"`
+}
+
+type ToolCountDTO struct {
+	ToolType            *ToolTypeDTO `json:"tool_type"`
+	Expected            int          `json:"expected"`
+	Detected            int          `json:"detected"`
+	Surplus             int          `json:"surplus"`
+	Shortfall           int          `json:"shortfall"`
+	ConfidenceThreshold float32      `json:"confidence_threshold"`
+	CosineSimThreshold  float32      `json:"cosine_sim_threshold"`
+}
+
 type GetUsersListTransactionsRes struct {
 	Transactions []*TransactionDTO
 	Avg          float64
@@ -79,9 +361,10 @@ type LightTransaction struct {
 }
 
 type MlErrorTransaction struct {
-	TransactionID  int64  `json:"transaction_id"`
-	SourceImageUrl string `json:"source_image_url"`
-	DebugImageUrl  string `json:"debug_image_url"`
+	TransactionID  int64   `json:"transaction_id"`
+	SourceImageUrl string  `json:"source_image_url"`
+	DebugImageUrl  string  `json:"debug_image_url"`
+	ModelVersion   *string `json:"model_version"`
 }
 
 type GetAvgWorkDurationRes struct {
@@ -108,8 +391,9 @@ type GetTransactionStatisticsRes struct {
 }
 
 type ModelOrHumanStatsRes struct {
-	MlErrors    int `json:"ml_errors"`
-	HumanErrors int `json:"human_errors"`
+	MlErrors               int                      `json:"ml_errors"`
+	HumanErrors            int                      `json:"human_errors"`
+	MlErrorsByModelVersion []ModelVersionErrorCount `json:"ml_errors_by_model_version"`
 }
 
 type QaTransactionsRes struct {
@@ -134,10 +418,9 @@ type GetRolesRes struct {
 }
 
 type VerificationReq struct {
-	QAEmployeeId string        `json:"qa_employee_id" binding:"required"`
-	Reason       domain.Reason `json:"reason" binding:"required"`
-	Notes        string        `json:"notes"`
-	ToolIds      []int64       `json:"tool_ids" binding:"omitempty,dive,gt=0"`
+	Reason  domain.Reason `json:"reason" binding:"required"`
+	Notes   string        `json:"notes"`
+	ToolIds []int64       `json:"tool_ids" binding:"omitempty,dive,gt=0"`
 }
 
 type VerificationRes struct {
@@ -156,12 +439,18 @@ type GetQAVerificationRes struct {
 	ProblematicTools *ProblematicTools    `json:"problematic_tools"`
 	ImageUrl         string               `json:"image_url"`
 	Status           string               `json:"status"`
+	Instances        []*ToolInstanceDTO   `json:"instances"`
 }
 
+// ProblematicTools инструменты, требующие внимания; misplaced_tools — найденные инструменты не в своём гнезде
+// ложемента (заполняется, если для набора задана раскладка), possibly_substituted_tools — сданные инструменты,
+// непохожие на выданные по этой транзакции (они же остаются в своей категории)
 type ProblematicTools struct {
-	ManualCheckTools []*RecognizedToolDTO `json:"manual_check_tools"`
-	UnknownTools     []*RecognizedToolDTO `json:"unknown_tools"`
-	MissingTools     []*ToolTypeDTO       `json:"missing_tools"`
+	ManualCheckTools         []*RecognizedToolDTO `json:"manual_check_tools"`
+	UnknownTools             []*RecognizedToolDTO `json:"unknown_tools"`
+	MissingTools             []*ToolTypeDTO       `json:"missing_tools"`
+	MisplacedTools           []*RecognizedToolDTO `json:"misplaced_tools"`
+	PossiblySubstitutedTools []*RecognizedToolDTO `json:"possibly_substituted_tools"`
 }
 
 type ListTransactionsRes struct {
@@ -185,6 +474,7 @@ type RegisterReq struct {
 	EmployeeId string `json:"employee_id" binding:"required"`
 	FullName   string `json:"full_name" binding:"required"`
 	Role       string `json:"role" binding:"required"`
+	Password   string `json:"password" binding:"required,min=4"`
 }
 
 type RegisterRes struct {
@@ -193,16 +483,94 @@ type RegisterRes struct {
 
 type LoginReq struct {
 	EmployeeId string `json:"employee_id" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	// NewPin новый PIN; обязателен, если вход отклонён с требованием сменить PIN
+	NewPin string `json:"new_pin" binding:"omitempty,min=4"`
+}
+
+type SetUserPinReq struct {
+	Pin string `json:"pin" binding:"required,min=4"`
 }
 
 type LoginRes struct {
-	Role string `json:"role"`
+	Role             string    `json:"role"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// CheckReq запрос на выдачу/сдачу; инженер определяется по access токену
 type CheckReq struct {
-	EmployeeId string `json:"employee_id" binding:"required"`
-	Data       string `json:"data" binding:"required"`
-	ToolSetId  int64  `json:"tool_set_id"`
+	Data      string `json:"data" binding:"required"`
+	ToolSetId int64  `json:"tool_set_id"`
+}
+
+type CreateToolTypeReq struct {
+	PartNumber         string    `json:"part_number" binding:"required,max=32"`
+	Name               string    `json:"name" binding:"required,max=256"`
+	ReferenceEmbedding []float32 `json:"reference_embedding" binding:"required"`
+}
+
+type AddToolTypeSamplesReq struct {
+	Images []string `json:"images" binding:"required,min=1,dive,required"`
+}
+
+type ToolTypeSampleDTO struct {
+	Id         int64     `json:"id"`
+	ImageUrl   string    `json:"image_url"`
+	Confidence float32   `json:"confidence"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ToolTypeSamplesRes struct {
+	ToolType       *ToolTypeDTO         `json:"tool_type"`
+	Samples        []*ToolTypeSampleDTO `json:"samples"`
+	RejectedImages []int                `json:"rejected_images"`
+}
+
+type AddToolTypeReferenceReq struct {
+	Label     string    `json:"label" binding:"max=128"`
+	Embedding []float32 `json:"embedding" binding:"required"`
+}
+
+type ToolTypeReferenceDTO struct {
+	Id        int64     `json:"id"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RenameToolTypeReq struct {
+	Name string `json:"name" binding:"required,max=256"`
+}
+
+type UpdateToolTypeEmbeddingReq struct {
+	ReferenceEmbedding []float32 `json:"reference_embedding" binding:"required"`
+}
+
+type CheckByBadgeReq struct {
+	BadgeUid  string `json:"badge_uid" binding:"required"`
+	Data      string `json:"data" binding:"required"`
+	ToolSetId int64  `json:"tool_set_id"`
+}
+
+type CreateBadgeReq struct {
+	Uid         string     `json:"uid" binding:"required"`
+	EmployeeId  string     `json:"employee_id" binding:"required"`
+	ActivatedAt *time.Time `json:"activated_at"`
+}
+
+type BadgeDTO struct {
+	Id          int64      `json:"id"`
+	Uid         string     `json:"uid"`
+	User        UserDto    `json:"user"`
+	ActivatedAt time.Time  `json:"activated_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	Active      bool       `json:"active"`
 }
 
 type CheckRes struct {
@@ -210,20 +578,141 @@ type CheckRes struct {
 	DebugImageUrl    string               `json:"debug_image_url"`
 	AccessTools      []*RecognizedToolDTO `json:"access_tools"`
 	ProblematicTools *ProblematicTools    `json:"problematic_tools"`
+	ToolCounts       []*ToolCountDTO      `json:"tool_counts"`
+	Instances        []*ToolInstanceDTO   `json:"instances,omitempty"`
 	TransactionType  string               `json:"transaction_type"`
 	Status           string               `json:"status"`
 }
 
+type MLHealthDTO struct {
+	Healthy       bool       `json:"healthy"`
+	CircuitState  string     `json:"circuit_state"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	ModelVersion  *string    `json:"model_version,omitempty"`
+}
+
+type ReadinessRes struct {
+	Ready bool         `json:"ready"`
+	ML    *MLHealthDTO `json:"ml"`
+}
+
+// ScanJobDTO состояние асинхронной проверки; result заполняется, когда status = DONE.
+// transaction_id — транзакция, по которой сохранена проверка
+type ScanJobDTO struct {
+	JobId         string     `json:"job_id"`
+	Status        string     `json:"status"`
+	TransactionId *int64     `json:"transaction_id,omitempty"`
+	Result        *CheckRes  `json:"result,omitempty"`
+	Error         *string    `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// RecognizedToolDTO распознанный инструмент; tool_type_id отсутствует, если класс модели не сопоставлен с типом инструмента
 type RecognizedToolDTO struct {
-	ToolTypeId int64     `json:"tool_type_id"`
-	Confidence float32   `json:"confidence"`
-	Bbox       []float32 `json:"bbox"`
+	ToolTypeId       *int64    `json:"tool_type_id,omitempty"`
+	ClassIndex       int64     `json:"class_index"`
+	Confidence       float32   `json:"confidence"`
+	Bbox             []float32 `json:"bbox"`
+	ReferenceId      *int64    `json:"reference_id,omitempty"`
+	CosineSimilarity *float32  `json:"cosine_similarity,omitempty"`
+	// пороги, с которыми сравнивалась детекция: объясняют, почему инструмент ушёл на ручную проверку
+	ConfidenceThreshold *float32 `json:"confidence_threshold,omitempty"`
+	CosineSimThreshold  *float32 `json:"cosine_sim_threshold,omitempty"`
+	// гнездо ложемента, в котором лежит инструмент; у misplaced_tools — чужое гнездо
+	SlotId *int64 `json:"slot_id,omitempty"`
+	// косинусная близость к парному инструменту на скане выдачи
+	CheckoutSimilarity *float32 `json:"checkout_similarity,omitempty"`
 }
 
 type ToolTypeDTO struct {
-	Id         int64  `json:"id"`
-	PartNumber string `json:"part_number"`
-	Name       string `json:"name"`
+	Id                      int64    `json:"id"`
+	PartNumber              string   `json:"part_number"`
+	Name                    string   `json:"name"`
+	CalibrationIntervalDays *int     `json:"calibration_interval_days,omitempty"`
+	ConfidenceThreshold     *float32 `json:"confidence_threshold,omitempty"`
+	CosineSimThreshold      *float32 `json:"cosine_sim_threshold,omitempty"`
+}
+
+// SetThresholdsReq пороги автоматической проверки; null — порог наследуется
+type SetThresholdsReq struct {
+	ConfidenceThreshold *float32 `json:"confidence_threshold" example:"0.6"`
+	CosineSimThreshold  *float32 `json:"cosine_sim_threshold" example:"0.8"`
+}
+
+// ToolSetThresholdDTO пороги типа инструмента в наборе: confidence_threshold и cosine_sim_threshold применяются
+// при проверке, set_* заданы для набора, пороги типа — в tool_type
+type ToolSetThresholdDTO struct {
+	ToolType               *ToolTypeDTO `json:"tool_type"`
+	ConfidenceThreshold    float32      `json:"confidence_threshold"`
+	CosineSimThreshold     float32      `json:"cosine_sim_threshold"`
+	SetConfidenceThreshold *float32     `json:"set_confidence_threshold,omitempty"`
+	SetCosineSimThreshold  *float32     `json:"set_cosine_sim_threshold,omitempty"`
+}
+
+// PointDTO точка на изображении скана в пикселях
+type PointDTO struct {
+	X float32 `json:"x" example:"120"`
+	Y float32 `json:"y" example:"340"`
+}
+
+// BoardSlotDTO гнездо ложемента под один экземпляр типа инструмента
+type BoardSlotDTO struct {
+	Id         int64      `json:"id"`
+	ToolTypeId int64      `json:"tool_type_id"`
+	Polygon    []PointDTO `json:"polygon"`
+}
+
+type BoardSlotReq struct {
+	ToolTypeId int64      `json:"tool_type_id" binding:"required"`
+	Polygon    []PointDTO `json:"polygon" binding:"required,min=3"`
+}
+
+// SetBoardLayoutReq раскладка ложемента; пустой список slots отключает проверку расположения
+type SetBoardLayoutReq struct {
+	Slots []BoardSlotReq `json:"slots" binding:"dive"`
+}
+
+// QAQueueItemDTO элемент очереди QA-проверки. overdue — время в очереди превысило sla_seconds;
+// claimed_by заполнен, пока аудитор держит транзакцию в работе
+type QAQueueItemDTO struct {
+	TransactionId      int64      `json:"transaction_id"`
+	ToolSetId          int64      `json:"tool_set_id"`
+	User               UserDto    `json:"user"`
+	EnqueuedAt         time.Time  `json:"enqueued_at"`
+	TimeInQueueSeconds int64      `json:"time_in_queue_seconds"`
+	SlaSeconds         int64      `json:"sla_seconds"`
+	Overdue            bool       `json:"overdue"`
+	EscalatedAt        *time.Time `json:"escalated_at,omitempty"`
+	Assignee           *UserDto   `json:"assignee,omitempty"`
+	AssignedAt         *time.Time `json:"assigned_at,omitempty"`
+	ClaimedBy          *UserDto   `json:"claimed_by,omitempty"`
+	ClaimExpiresAt     *time.Time `json:"claim_expires_at,omitempty"`
+}
+
+// AssignQAQueueItemReq назначение транзакции аудитору по табельному номеру
+type AssignQAQueueItemReq struct {
+	EmployeeId string `json:"employee_id" binding:"required"`
+}
+
+type CreateMLClassMappingReq struct {
+	ModelVersion string `json:"model_version" binding:"required,max=255" example:"*"`
+	ClassIndex   *int64 `json:"class_index" binding:"required,min=0"`
+	ToolTypeId   int64  `json:"tool_type_id" binding:"required"`
+}
+
+type UpdateMLClassMappingReq struct {
+	ToolTypeId int64 `json:"tool_type_id" binding:"required"`
+}
+
+type MLClassMappingDTO struct {
+	Id           int64        `json:"id"`
+	ModelVersion string       `json:"model_version"`
+	ClassIndex   int64        `json:"class_index"`
+	ToolTypeId   int64        `json:"tool_type_id"`
+	ToolType     *ToolTypeDTO `json:"tool_type,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
 
 func NewListTransactionsRes(transactions []TransactionDTO) *ListTransactionsRes {
@@ -268,11 +757,24 @@ func toDeliveryUserDto(user usecase.UserDto) UserDto {
 }
 
 func toDeliveryRecognizedToolDTO(tool *domain.RecognizedTool) *RecognizedToolDTO {
-	return &RecognizedToolDTO{
-		ToolTypeId: tool.ToolTypeId,
-		Confidence: tool.Confidence,
-		Bbox:       tool.Bbox,
+	res := &RecognizedToolDTO{
+		ToolTypeId:       tool.ToolTypeId,
+		ClassIndex:       tool.ClassIndex,
+		Confidence:       tool.Confidence,
+		Bbox:             tool.Bbox,
+		ReferenceId:      tool.ReferenceId,
+		CosineSimilarity: tool.CosineSimilarity,
+		SlotId:           tool.SlotId,
+
+		CheckoutSimilarity: tool.CheckoutSimilarity,
 	}
+
+	if tool.Thresholds != nil {
+		res.ConfidenceThreshold = &tool.Thresholds.Confidence
+		res.CosineSimThreshold = &tool.Thresholds.CosineSim
+	}
+
+	return res
 }
 
 func toDeliveryToolTypeDTO(dto *usecase.ToolTypeDTO) *ToolTypeDTO {
@@ -280,6 +782,10 @@ func toDeliveryToolTypeDTO(dto *usecase.ToolTypeDTO) *ToolTypeDTO {
 		Id:         dto.Id,
 		PartNumber: dto.PartNumber,
 		Name:       dto.Name,
+
+		CalibrationIntervalDays: dto.CalibrationIntervalDays,
+		ConfidenceThreshold:     dto.Thresholds.Confidence,
+		CosineSimThreshold:      dto.Thresholds.CosineSim,
 	}
 }
 
@@ -301,34 +807,284 @@ func toArrDeliveryRecognizedToolDTO(tools []*domain.RecognizedTool) []*Recognize
 	return result
 }
 
+func toDeliveryScanJobDTO(job *usecase.ScanJobDTO) *ScanJobDTO {
+	res := &ScanJobDTO{
+		JobId:         job.Id,
+		Status:        job.Status,
+		TransactionId: job.TransactionId,
+		Error:         job.Error,
+		CreatedAt:     job.CreatedAt,
+		FinishedAt:    job.FinishedAt,
+	}
+
+	if job.Result != nil {
+		res.Result = ToDeliveryCheckRes(job.Result)
+	}
+
+	return res
+}
+
 func ToDeliveryCheckRes(res *usecase.CheckRes) *CheckRes {
 	return &CheckRes{
 		ImageUrl:         res.ImageUrl,
 		DebugImageUrl:    res.DebugImageUrl,
 		AccessTools:      toArrDeliveryRecognizedToolDTO(res.AccessTools),
 		ProblematicTools: toDeliveryProblematicTools(res.ProblematicTools),
+		ToolCounts:       toArrDeliveryToolCountDTO(res.ToolCounts),
+		Instances:        toArrDeliveryToolInstanceDTO(res.Instances),
 		TransactionType:  res.TransactionType,
 		Status:           res.Status,
 	}
 }
 
-func ToUseCaseCheckReq(req *CheckReq) *usecase.CheckReq {
-	return &usecase.CheckReq{
-		EmployeeId: req.EmployeeId,
-		Data:       req.Data,
-		ToolSetId:  req.ToolSetId,
+func ToUseCaseCheckReq(req *CheckReq, employeeId string) *usecase.CheckReq {
+	return usecase.NewCheckReq(employeeId, req.Data, req.ToolSetId, nil, nil)
+}
+
+func toUseCaseCheckByBadgeReq(req *CheckByBadgeReq, kioskId int64) *usecase.CheckByBadgeReq {
+	return &usecase.CheckByBadgeReq{
+		BadgeUid:  req.BadgeUid,
+		Data:      req.Data,
+		ToolSetId: req.ToolSetId,
+		KioskId:   kioskId,
+	}
+}
+
+func toUseCaseCreateToolTypeReq(req CreateToolTypeReq) *usecase.CreateToolTypeReq {
+	return usecase.NewCreateToolTypeReq(req.PartNumber, req.Name, req.ReferenceEmbedding)
+}
+
+func toDeliveryToolTypeSampleDTO(sample *usecase.ToolTypeSampleDTO) *ToolTypeSampleDTO {
+	return &ToolTypeSampleDTO{
+		Id:         sample.Id,
+		ImageUrl:   sample.ImageUrl,
+		Confidence: sample.Confidence,
+		CreatedAt:  sample.CreatedAt,
+	}
+}
+
+func toArrDeliveryToolTypeSampleDTO(samples []*usecase.ToolTypeSampleDTO) []*ToolTypeSampleDTO {
+	res := make([]*ToolTypeSampleDTO, len(samples))
+	for i, sample := range samples {
+		res[i] = toDeliveryToolTypeSampleDTO(sample)
+	}
+
+	return res
+}
+
+func toDeliveryToolTypeSamplesRes(res *usecase.ToolTypeSamplesRes) *ToolTypeSamplesRes {
+	return &ToolTypeSamplesRes{
+		ToolType:       toDeliveryToolTypeDTO(res.ToolType),
+		Samples:        toArrDeliveryToolTypeSampleDTO(res.Samples),
+		RejectedImages: res.RejectedImages,
+	}
+}
+
+func toDeliveryToolTypeReferenceDTO(reference *usecase.ToolTypeReferenceDTO) *ToolTypeReferenceDTO {
+	return &ToolTypeReferenceDTO{
+		Id:        reference.Id,
+		Label:     reference.Label,
+		CreatedAt: reference.CreatedAt,
+	}
+}
+
+func toArrDeliveryToolTypeReferenceDTO(references []*usecase.ToolTypeReferenceDTO) []*ToolTypeReferenceDTO {
+	res := make([]*ToolTypeReferenceDTO, len(references))
+	for i, reference := range references {
+		res[i] = toDeliveryToolTypeReferenceDTO(reference)
+	}
+
+	return res
+}
+
+func toDeliveryToolSetDTO(set *usecase.ToolSetDTO) *ToolSetDTO {
+	return &ToolSetDTO{
+		Id:        set.Id,
+		FamilyId:  set.FamilyId,
+		Version:   set.Version,
+		Name:      set.Name,
+		RetiredAt: set.RetiredAt,
+		CreatedAt: set.CreatedAt,
+		Tools:     toArrDeliveryToolTypeDTO(set.Tools),
+		Items:     toArrDeliveryToolSetItemDTO(set.Items),
+	}
+}
+
+func toArrDeliveryToolSetItemDTO(items []*usecase.ToolSetItemDTO) []*ToolSetItemDTO {
+	res := make([]*ToolSetItemDTO, len(items))
+	for i, item := range items {
+		res[i] = &ToolSetItemDTO{
+			ToolTypeId: item.ToolTypeId,
+			Quantity:   item.Quantity,
+		}
+	}
+
+	return res
+}
+
+func toDeliveryToolInstanceDTO(instance *usecase.ToolInstanceDTO) *ToolInstanceDTO {
+	res := &ToolInstanceDTO{
+		Id:            instance.Id,
+		SerialNumber:  instance.SerialNumber,
+		ToolTypeId:    instance.ToolTypeId,
+		HomeToolSetId: instance.HomeToolSetId,
+		Status:        instance.Status,
+		UpdatedAt:     instance.UpdatedAt,
+
+		LastCalibratedAt: instance.LastCalibratedAt,
+		CalibrationDueAt: instance.CalibrationDueAt,
+	}
+
+	if instance.ToolType != nil {
+		res.ToolType = toDeliveryToolTypeDTO(instance.ToolType)
+	}
+
+	return res
+}
+
+func toArrDeliveryToolInstanceDTO(instances []*usecase.ToolInstanceDTO) []*ToolInstanceDTO {
+	res := make([]*ToolInstanceDTO, len(instances))
+	for i, instance := range instances {
+		res[i] = toDeliveryToolInstanceDTO(instance)
+	}
+
+	return res
+}
+
+func toDeliveryCalibrationEventDTO(event *usecase.CalibrationEventDTO) *CalibrationEventDTO {
+	res := &CalibrationEventDTO{
+		Id:           event.Id,
+		CalibratedAt: event.CalibratedAt,
+		NextDueAt:    event.NextDueAt,
+		PerformedBy:  event.PerformedBy,
+		Notes:        event.Notes,
+		CreatedAt:    event.CreatedAt,
+	}
+
+	if event.Instance != nil {
+		res.Instance = toDeliveryToolInstanceDTO(event.Instance)
+	}
+
+	return res
+}
+
+func toArrDeliveryCalibrationEventDTO(events []*usecase.CalibrationEventDTO) []*CalibrationEventDTO {
+	res := make([]*CalibrationEventDTO, len(events))
+	for i, event := range events {
+		res[i] = toDeliveryCalibrationEventDTO(event)
+	}
+
+	return res
+}
+
+func toArrDeliveryCalibrationDueDTO(items []*usecase.CalibrationDueDTO) []*CalibrationDueDTO {
+	res := make([]*CalibrationDueDTO, len(items))
+	for i, item := range items {
+		res[i] = &CalibrationDueDTO{
+			Instance: toDeliveryToolInstanceDTO(item.Instance),
+			Overdue:  item.Overdue,
+			DaysLeft: item.DaysLeft,
+		}
+	}
+
+	return res
+}
+
+func toArrDeliveryToolInstanceWithErrorCount(arr []*repository.ToolInstanceWithErrorCount) []ToolInstanceWithErrorCount {
+	res := make([]ToolInstanceWithErrorCount, len(arr))
+	for i, r := range arr {
+		res[i] = ToolInstanceWithErrorCount{
+			ID:             r.ID,
+			SerialNumber:   r.SerialNumber,
+			ToolTypeId:     r.ToolTypeId,
+			ToolTypeName:   r.ToolTypeName,
+			MLErrorCount:   r.MLErrorCount,
+			ByModelVersion: toArrDeliveryModelVersionErrorCount(r.ByModelVersion),
+		}
+	}
+
+	return res
+}
+
+func toArrDeliveryToolCountDTO(counts []*usecase.ToolCountDTO) []*ToolCountDTO {
+	res := make([]*ToolCountDTO, len(counts))
+	for i, count := range counts {
+		res[i] = &ToolCountDTO{
+			ToolType:  toDeliveryToolTypeDTO(count.ToolType),
+			Expected:  count.Expected,
+			Detected:  count.Detected,
+			Surplus:   count.Surplus,
+			Shortfall: count.Shortfall,
+
+			ConfidenceThreshold: count.Thresholds.Confidence,
+			CosineSimThreshold:  count.Thresholds.CosineSim,
+		}
+	}
+
+	return res
+}
+
+func toArrDeliveryToolSetDTO(sets []*usecase.ToolSetDTO) []*ToolSetDTO {
+	res := make([]*ToolSetDTO, len(sets))
+	for i, set := range sets {
+		res[i] = toDeliveryToolSetDTO(set)
+	}
+
+	return res
+}
+
+func toUseCaseCreateBadgeReq(req CreateBadgeReq) *usecase.CreateBadgeReq {
+	return usecase.NewCreateBadgeReq(req.Uid, req.EmployeeId, req.ActivatedAt)
+}
+
+func toDeliveryBadgeDTO(badge *usecase.BadgeDTO) *BadgeDTO {
+	return &BadgeDTO{
+		Id:          badge.Id,
+		Uid:         badge.Uid,
+		User:        toDeliveryUserDto(badge.User),
+		ActivatedAt: badge.ActivatedAt,
+		RevokedAt:   badge.RevokedAt,
+		Active:      badge.Active,
+	}
+}
+
+func toArrDeliveryBadgeDTO(badges []*usecase.BadgeDTO) []*BadgeDTO {
+	res := make([]*BadgeDTO, len(badges))
+	for i, badge := range badges {
+		res[i] = toDeliveryBadgeDTO(badge)
 	}
+
+	return res
 }
 
 func toUseCaseLoginReq(req LoginReq) *usecase.LoginReq {
 	return &usecase.LoginReq{
 		EmployeeId: req.EmployeeId,
+		Password:   req.Password,
+		NewPin:     req.NewPin,
+	}
+}
+
+func toUseCaseSetUserPinReq(userId int64, req SetUserPinReq) *usecase.SetUserPinReq {
+	return &usecase.SetUserPinReq{
+		UserId: userId,
+		Pin:    req.Pin,
+	}
+}
+
+func toUseCaseRefreshReq(req RefreshReq) *usecase.RefreshReq {
+	return &usecase.RefreshReq{
+		RefreshToken: req.RefreshToken,
 	}
 }
 
 func toDeliveryLoginRes(res *usecase.LoginRes) LoginRes {
 	return LoginRes{
-		Role: res.Role,
+		Role:             res.Role,
+		AccessToken:      res.Tokens.AccessToken,
+		RefreshToken:     res.Tokens.RefreshToken,
+		AccessExpiresAt:  res.Tokens.AccessExpiresAt,
+		RefreshExpiresAt: res.Tokens.RefreshExpiresAt,
 	}
 }
 
@@ -337,6 +1093,7 @@ func toUseCaseRegisterReq(req RegisterReq) *usecase.RegisterReq {
 		EmployeeId: req.EmployeeId,
 		FullName:   req.FullName,
 		Role:       req.Role,
+		Password:   req.Password,
 	}
 }
 
@@ -402,6 +1159,7 @@ func toDeliveryGetQAVerificationRes(res *usecase.GetQAVerificationRes) *GetQAVer
 		ProblematicTools: toDeliveryProblematicTools(res.ProblematicTools),
 		ImageUrl:         res.ImageUrl,
 		Status:           res.Status,
+		Instances:        toArrDeliveryToolInstanceDTO(res.Instances),
 	}
 }
 
@@ -410,6 +1168,9 @@ func toDeliveryProblematicTools(tools *usecase.ProblematicTools) *ProblematicToo
 		ManualCheckTools: toArrDeliveryRecognizedToolDTO(tools.ManualCheckTools),
 		UnknownTools:     toArrDeliveryRecognizedToolDTO(tools.UnknownTools),
 		MissingTools:     toArrDeliveryToolTypeDTO(tools.MissingTools),
+		MisplacedTools:   toArrDeliveryRecognizedToolDTO(tools.MisplacedTools),
+
+		PossiblySubstitutedTools: toArrDeliveryRecognizedToolDTO(tools.PossiblySubstitutedTools),
 	}
 }
 
@@ -511,6 +1272,7 @@ func toDeliveryMlErrorTransaction(res usecase.MlErrorTransaction) MlErrorTransac
 		TransactionID:  res.TransactionID,
 		SourceImageUrl: res.SourceImageUrl,
 		DebugImageUrl:  res.DebugImageUrl,
+		ModelVersion:   res.ModelVersion,
 	}
 }
 
@@ -525,7 +1287,105 @@ func toArrDeliveryMlErrorTransaction(res []usecase.MlErrorTransaction) []MlError
 
 func toDeliveryModelOrHumanStatsRes(res *usecase.ModelOrHumanStatsRes) *ModelOrHumanStatsRes {
 	return &ModelOrHumanStatsRes{
-		MlErrors:    res.MlErrors,
-		HumanErrors: res.HumanErrors,
+		MlErrors:               res.MlErrors,
+		HumanErrors:            res.HumanErrors,
+		MlErrorsByModelVersion: toArrDeliveryModelVersionErrorCount(res.MlErrorsByModelVersion),
+	}
+}
+
+func toDeliveryReadinessRes(res *usecase.ReadinessRes) *ReadinessRes {
+	return &ReadinessRes{
+		Ready: res.Ready,
+		ML: &MLHealthDTO{
+			Healthy:       res.ML.Healthy,
+			CircuitState:  res.ML.CircuitState,
+			LastCheckedAt: res.ML.LastCheckedAt,
+			LastError:     res.ML.LastError,
+			ModelVersion:  res.ML.ModelVersion,
+		},
 	}
 }
+
+func toUseCaseCreateMLClassMappingReq(req CreateMLClassMappingReq) *usecase.CreateMLClassMappingReq {
+	return usecase.NewCreateMLClassMappingReq(req.ModelVersion, *req.ClassIndex, req.ToolTypeId)
+}
+
+func toDeliveryMLClassMappingDTO(mapping *usecase.MLClassMappingDTO) *MLClassMappingDTO {
+	res := &MLClassMappingDTO{
+		Id:           mapping.Id,
+		ModelVersion: mapping.ModelVersion,
+		ClassIndex:   mapping.ClassIndex,
+		ToolTypeId:   mapping.ToolTypeId,
+		CreatedAt:    mapping.CreatedAt,
+	}
+
+	if mapping.ToolType != nil {
+		res.ToolType = toDeliveryToolTypeDTO(mapping.ToolType)
+	}
+
+	return res
+}
+
+func toArrDeliveryMLClassMappingDTO(mappings []*usecase.MLClassMappingDTO) []*MLClassMappingDTO {
+	res := make([]*MLClassMappingDTO, len(mappings))
+	for i, mapping := range mappings {
+		res[i] = toDeliveryMLClassMappingDTO(mapping)
+	}
+
+	return res
+}
+
+func toDeliveryToolSetThresholdDTO(threshold *usecase.ToolSetThresholdDTO) *ToolSetThresholdDTO {
+	return &ToolSetThresholdDTO{
+		ToolType:               toDeliveryToolTypeDTO(threshold.ToolType),
+		ConfidenceThreshold:    threshold.Effective.Confidence,
+		CosineSimThreshold:     threshold.Effective.CosineSim,
+		SetConfidenceThreshold: threshold.SetOverride.Confidence,
+		SetCosineSimThreshold:  threshold.SetOverride.CosineSim,
+	}
+}
+
+func toArrDeliveryToolSetThresholdDTO(thresholds []*usecase.ToolSetThresholdDTO) []*ToolSetThresholdDTO {
+	res := make([]*ToolSetThresholdDTO, len(thresholds))
+	for i, threshold := range thresholds {
+		res[i] = toDeliveryToolSetThresholdDTO(threshold)
+	}
+
+	return res
+}
+
+func toDeliveryQAQueueItemDTO(item *usecase.QAQueueItemDTO) *QAQueueItemDTO {
+	res := &QAQueueItemDTO{
+		TransactionId:      item.TransactionId,
+		ToolSetId:          item.ToolSetId,
+		User:               toDeliveryUserDto(item.User),
+		EnqueuedAt:         item.EnqueuedAt,
+		TimeInQueueSeconds: int64(item.TimeInQueue.Seconds()),
+		SlaSeconds:         int64(item.SLA.Seconds()),
+		Overdue:            item.Overdue,
+		EscalatedAt:        item.EscalatedAt,
+		AssignedAt:         item.AssignedAt,
+		ClaimExpiresAt:     item.ClaimExpiresAt,
+	}
+
+	if item.Assignee != nil {
+		assignee := toDeliveryUserDto(*item.Assignee)
+		res.Assignee = &assignee
+	}
+
+	if item.ClaimedBy != nil {
+		claimer := toDeliveryUserDto(*item.ClaimedBy)
+		res.ClaimedBy = &claimer
+	}
+
+	return res
+}
+
+func toArrDeliveryQAQueueItemDTO(items []*usecase.QAQueueItemDTO) []*QAQueueItemDTO {
+	res := make([]*QAQueueItemDTO, len(items))
+	for i, item := range items {
+		res[i] = toDeliveryQAQueueItemDTO(item)
+	}
+
+	return res
+}
@@ -6,14 +6,27 @@ import (
 	"airport-tools-backend/internal/usecase"
 	"airport-tools-backend/pkg/e"
 	"airport-tools-backend/pkg/parse"
+	"context"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+const (
+	// defaultCalibrationDueDays горизонт отчёта о поверках, если параметр days не передан
+	defaultCalibrationDueDays = 30
+	// scanJobStreamInterval период опроса состояния задания для SSE-потока
+	scanJobStreamInterval = 500 * time.Millisecond
+	// scanJobStreamTimeout максимальная длительность SSE-потока
+	scanJobStreamTimeout = 10 * time.Minute
+)
+
 type Handler struct {
 	service *usecase.Service
 }
@@ -29,44 +42,126 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 	{
 		v1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+		// HEALTH
+		health := v1.Group("/health")
+		{
+			health.GET("/live", h.liveness)   // процесс запущен
+			health.GET("/ready", h.readiness) // ML-сервис доступен, проверки можно выполнять
+		}
+
 		// AUTH
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", h.login)
-			auth.POST("/register", h.register)
+			auth.POST("/refresh", h.refresh)
+			auth.POST("/register", h.userIdentity, h.requireRole(domain.QualityAuditor), h.register)
 		}
 
 		//  USER
 		user := v1.Group("/users")
 		{
 			user.GET("/roles", h.getRoles)
-			user.POST("/check", h.check) // выдача/сдача инструментов пользователем
+			user.POST("/check", h.userIdentity, h.requireRole(domain.Engineer), h.idempotency("users.check"), h.check)                 // выдача/сдача инструментов пользователем
+			user.POST("/check/badge", h.userIdentity, h.requireRole(domain.Kiosk), h.idempotency("users.check.badge"), h.checkByBadge) // выдача/сдача инструментов по пропуску (киоск)
+			user.GET("/check/jobs/:job_id", h.userIdentity, h.getScanJob)                                                              // состояние асинхронной проверки
+			user.GET("/check/jobs/:job_id/events", h.userIdentity, h.streamScanJob)                                                    // SSE-поток состояния асинхронной проверки
 		}
 
 		// QA
-		qa := v1.Group("/qa")
+		qa := v1.Group("/qa", h.userIdentity, h.requireRole(domain.QualityAuditor))
 		{
+			qa.PUT("/users/:user_id/pin", h.setUserPin) // выдача или сброс PIN-кода сотрудника
+
 			transactions := qa.Group("/transactions")
 			{
-				transactions.GET("/", h.list)                                          // список всех проблемных транзакций
-				transactions.GET("/:transaction_id", h.getVerification)                // получение данных для QA
-				transactions.POST("/:transaction_id/verification", h.postVerification) // отправка QA результата
+				transactions.GET("/", h.list)                                                                            // список всех проблемных транзакций
+				transactions.GET("/:transaction_id", h.getVerification)                                                  // получение данных для QA
+				transactions.POST("/:transaction_id/verification", h.idempotency("qa.verification"), h.postVerification) // отправка QA результата
+			}
+
+			queue := qa.Group("/queue")
+			{
+				queue.GET("/", h.getQAQueue)                                     // очередь QA с временем ожидания и SLA, ?mine=true
+				queue.POST("/:transaction_id/claim", h.claimQAQueueItem)         // взять транзакцию в работу
+				queue.DELETE("/:transaction_id/claim", h.releaseQAQueueItem)     // вернуть транзакцию в очередь
+				queue.PUT("/:transaction_id/assignee", h.assignQAQueueItem)      // назначить аудитора
+				queue.DELETE("/:transaction_id/assignee", h.unassignQAQueueItem) // снять назначение
 			}
 
 			// Аналитика QA
 			statisticsGroup := qa.Group("/statistics")
 			{
-				statisticsGroup.GET("/users", h.getUserStatistics)               // Для ?type=users
-				statisticsGroup.GET("/errors", h.getErrorStatistics)             // Для ?type=errors
-				statisticsGroup.GET("/qa", h.getQaStatistics)                    // Для ?type=qa
-				statisticsGroup.GET("/transactions", h.getTransactionStatistics) // Для ?type=transactions
+				statisticsGroup.GET("/users", h.getUserStatistics)                  // Для ?type=users
+				statisticsGroup.GET("/errors", h.getErrorStatistics)                // Для ?type=errors
+				statisticsGroup.GET("/qa", h.getQaStatistics)                       // Для ?type=qa
+				statisticsGroup.GET("/transactions", h.getTransactionStatistics)    // Для ?type=transactions
+				statisticsGroup.GET("/model-versions", h.getModelVersionStatistics) // сводка по версиям модели
+				statisticsGroup.GET("/shadow", h.getShadowComparison)               // сравнение основной и теневой моделей
+				statisticsGroup.GET("/thresholds", h.getThresholdSweep)             // калибровка порогов по решениям QA
 			}
 
 			tools := qa.Group("/tools")
 			{
 				tools.GET("/ml-errors", h.getMlErrorTools)
+				tools.GET("/ml-errors/instances", h.getMlErrorInstances)
 				tools.POST("/new_set", h.addToolSet)
 			}
+
+			toolInstances := qa.Group("/tool-instances")
+			{
+				toolInstances.GET("/", h.listToolInstances)                                   // реестр экземпляров
+				toolInstances.POST("/", h.createToolInstance)                                 // регистрация экземпляра
+				toolInstances.GET("/:tool_instance_id", h.getToolInstance)                    // карточка экземпляра
+				toolInstances.PATCH("/:tool_instance_id/status", h.changeToolInstanceStatus)  // карантин/утеря/списание
+				toolInstances.GET("/calibration-due", h.getCalibrationDue)                    // поверки, истекающие в ближайшие N дней
+				toolInstances.GET("/:tool_instance_id/calibrations", h.getCalibrationHistory) // журнал поверок
+				toolInstances.POST("/:tool_instance_id/calibrations", h.recordCalibration)    // регистрация поверки
+			}
+
+			toolSets := qa.Group("/tool-sets")
+			{
+				toolSets.GET("/", h.listToolSets)                            // последние версии наборов
+				toolSets.GET("/:tool_set_id", h.getToolSet)                  // конкретная версия набора
+				toolSets.GET("/:tool_set_id/versions", h.getToolSetVersions) // история версий
+				toolSets.PUT("/:tool_set_id", h.editToolSet)                 // редактирование = новая версия
+				toolSets.POST("/:tool_set_id/retire", h.retireToolSet)       // вывод версии из оборота
+				toolSets.GET("/:tool_set_id/thresholds", h.getToolSetThresholds)
+				toolSets.PUT("/:tool_set_id/thresholds/:tool_type_id", h.setToolSetThresholds) // пороги типа в наборе
+				toolSets.GET("/:tool_set_id/layout", h.getBoardLayout)
+				toolSets.PUT("/:tool_set_id/layout", h.setBoardLayout) // раскладка ложемента
+			}
+
+			toolTypes := qa.Group("/tool-types")
+			{
+				toolTypes.GET("/", h.getToolTypes)                                                     // справочник типов инструментов
+				toolTypes.POST("/", h.createToolType)                                                  // новый тип инструмента
+				toolTypes.PATCH("/:tool_type_id", h.renameToolType)                                    // переименование
+				toolTypes.DELETE("/:tool_type_id", h.deleteToolType)                                   // удаление неиспользуемого типа
+				toolTypes.PUT("/:tool_type_id/embedding", h.updateToolTypeEmbedding)                   // замена эталонного эмбеддинга
+				toolTypes.PUT("/:tool_type_id/calibration-interval", h.setToolTypeCalibrationInterval) // периодичность поверки
+				toolTypes.PUT("/:tool_type_id/thresholds", h.setToolTypeThresholds)                    // пороги автоматической проверки
+				toolTypes.POST("/:tool_type_id/embedding/recompute", h.recomputeToolTypeEmbedding)     // пересчёт по сохранённым образцам
+				toolTypes.GET("/:tool_type_id/references", h.getToolTypeReferences)
+				toolTypes.POST("/:tool_type_id/references", h.addToolTypeReference)
+				toolTypes.DELETE("/:tool_type_id/references/:reference_id", h.deleteToolTypeReference)
+				toolTypes.GET("/:tool_type_id/samples", h.getToolTypeSamples)  // эталонные фотографии
+				toolTypes.POST("/:tool_type_id/samples", h.addToolTypeSamples) // загрузка эталонных фотографий
+			}
+
+			classMappings := qa.Group("/ml-class-mappings")
+			{
+				classMappings.GET("/", h.getMLClassMappings)                 // сопоставления классов модели с типами, ?model_version=
+				classMappings.POST("/", h.createMLClassMapping)              // новое сопоставление
+				classMappings.PATCH("/:mapping_id", h.updateMLClassMapping)  // переназначение класса на другой тип
+				classMappings.DELETE("/:mapping_id", h.deleteMLClassMapping) // удаление сопоставления
+			}
+
+			badges := qa.Group("/badges")
+			{
+				badges.GET("/", h.getUserBadges)                // пропуска сотрудника
+				badges.POST("/", h.createBadge)                 // регистрация пропуска
+				badges.POST("/:badge_id/revoke", h.revokeBadge) // отзыв утерянного пропуска
+			}
 		}
 	}
 }
@@ -87,6 +182,9 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 //	@Success		200					{object}	StatisticsRes	"Успешный ответ"
 //	@Failure		400					{object}	HTTPError		"Неверные параметры"
 //	@Failure		500					{object}	HTTPError		"Ошибка сервера"
+//	@Failure		401					{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/statistics/users [get]
 func (h *Handler) getUserStatistics(c *gin.Context) {
 	flags, err := parse.ParseCommonFilters(c)
@@ -135,15 +233,19 @@ func (h *Handler) getUserStatistics(c *gin.Context) {
 //
 //	@Summary		Получить статистику ошибок
 //
-//	@Description	Возвращает статистику ошибок системы и QA. Поддерживает:<br/>- `error_type=MODEL_ERR` — список транзакций, где ошиблась ML-модель;<br/>- `error_type=HUMAN_ERR` — статистика ошибок QA-инженеров;<br/>- Без параметров — общее сравнение ML vs Human ошибок.
+//	@Description	Возвращает статистику ошибок системы и QA. Поддерживает:<br/>- `error_type=MODEL_ERR` — список транзакций, где ошиблась ML-модель, с версией модели последнего скана сдачи;<br/>- `error_type=HUMAN_ERR` — статистика ошибок QA-инженеров;<br/>- Без параметров — общее сравнение ML vs Human ошибок с разбивкой ошибок модели по версиям.<br/><br/>`model_version` оставляет только транзакции, последний скан сдачи которых выполнен этой версией модели (не влияет на HUMAN_ERR).
 //
 //	@Tags			statistics
 //	@Produce		json
 //
-//	@Param			error_type	query		string			false	"Тип ошибки: MODEL_ERR или HUMAN_ERR"
+//	@Param			error_type		query		string			false	"Тип ошибки: MODEL_ERR или HUMAN_ERR"
+//	@Param			model_version	query		string			false	"Версия модели"
 //	@Success		200			{object}	StatisticsRes	"Успешный ответ"
 //	@Failure		400			{object}	HTTPError		"Неверные параметры"
 //	@Failure		500			{object}	HTTPError		"Ошибка сервера"
+//	@Failure		401			{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/statistics/errors [get]
 func (h *Handler) getErrorStatistics(c *gin.Context) {
 	flags, err := parse.ParseCommonFilters(c)
@@ -154,7 +256,7 @@ func (h *Handler) getErrorStatistics(c *gin.Context) {
 
 	var res interface{}
 	if flags.ErrorType != nil && *flags.ErrorType == string(domain.ModelError) {
-		result, err := h.service.GetMlErrorTransactions(c.Request.Context())
+		result, err := h.service.GetMlErrorTransactions(c.Request.Context(), parseMlErrorFilter(c))
 		if err != nil {
 			ErrorToHttpRes(err, c)
 			return
@@ -170,7 +272,7 @@ func (h *Handler) getErrorStatistics(c *gin.Context) {
 
 		res = toArrDeliveryHumanErrorStats(result)
 	} else {
-		result, err := h.service.GetMlVsHuman(c.Request.Context())
+		result, err := h.service.GetMlVsHuman(c.Request.Context(), parseMlErrorFilter(c))
 		if err != nil {
 			ErrorToHttpRes(err, c)
 			return
@@ -195,6 +297,9 @@ func (h *Handler) getErrorStatistics(c *gin.Context) {
 //	@Success		200			{object}	StatisticsRes	"Успешный ответ"
 //	@Failure		400			{object}	HTTPError		"Неверные параметры"
 //	@Failure		500			{object}	HTTPError		"Ошибка сервера"
+//	@Failure		401			{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/statistics/qa [get]
 func (h *Handler) getQaStatistics(c *gin.Context) {
 	flags, err := parse.ParseCommonFilters(c)
@@ -224,6 +329,119 @@ func (h *Handler) getQaStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// getModelVersionStatistics
+//
+//	@Summary		Статистика по версиям модели
+//	@Description	Для каждой версии модели, которой выполнялись сканы: число сканов и транзакций, период использования и число ошибок MODEL_ERR, отнесённых к версии по последнему скану сдачи. Сканы, сделанные до учёта версий, собраны в группу с model_version = null.
+//	@Tags			statistics
+//	@Produce		json
+//	@Success		200	{array}		ModelVersionStatsDTO	"Успешный ответ"
+//	@Failure		500	{object}	HTTPError				"Ошибка сервера"
+//	@Failure		401	{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403	{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/statistics/model-versions [get]
+func (h *Handler) getModelVersionStatistics(c *gin.Context) {
+	res, err := h.service.GetModelVersionStats(c.Request.Context())
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryModelVersionStats(res))
+}
+
+// getShadowComparison
+//
+//	@Summary		Сравнение с теневой моделью
+//	@Description	Сравнивает распознавания основной и теневой моделей на одних и тех же изображениях: долю сканов с совпавшими детекциями, долю сканов, по которым теневая модель дала бы тот же статус транзакции, матрицу статусов и расхождения по типам инструментов. Сканы, на которых теневая модель вернула ошибку, учитываются только в failed_count.
+//	@Tags			statistics
+//	@Produce		json
+//	@Param			model_version	query		string				false	"Версия теневой модели"
+//	@Param			from			query		string				false	"Начало периода, RFC3339"
+//	@Param			to				query		string				false	"Конец периода (не включая), RFC3339"
+//	@Success		200				{object}	ShadowComparisonDTO	"Успешный ответ"
+//	@Failure		400				{object}	HTTPError			"Некорректный период"
+//	@Failure		500				{object}	HTTPError			"Ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/statistics/shadow [get]
+func (h *Handler) getShadowComparison(c *gin.Context) {
+	filter, err := parseShadowReportFilter(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.GetShadowComparison(c.Request.Context(), filter)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryShadowComparisonDTO(res))
+}
+
+// getThresholdSweep
+//
+//	@Summary		Калибровка порогов
+//	@Description	Повторяет автоматическую проверку сохранённых сканов сдачи с каждой парой порогов (уверенность, косинусная близость) из сетки. Детекции последних сканов транзакций с QA-решением размечаются по вердикту аудитора: детекция верна, если её тип не отмечен как ошибка модели. Для каждой пары возвращаются precision и recall автоматического прохождения и число сдач, которые ушли бы на QA. При заданном tool_type_id пороги перебираются только для этого типа, остальные типы проверяются с действующими порогами.<br> В отчёт берутся не больше 2000 последних сканов периода; если сканов больше, truncated = true и период стоит сузить.
+//	@Tags			statistics
+//	@Produce		json
+//	@Param			model_version		query		string				false	"Версия модели, выполнившей распознавание"
+//	@Param			from				query		string				false	"Начало периода, RFC3339"
+//	@Param			to					query		string				false	"Конец периода (не включая), RFC3339"
+//	@Param			tool_type_id		query		int					false	"Тип инструмента"
+//	@Param			confidence_min		query		number				false	"Минимальный порог уверенности, по умолчанию 0.5"
+//	@Param			confidence_max		query		number				false	"Максимальный порог уверенности, по умолчанию 0.95"
+//	@Param			confidence_step		query		number				false	"Шаг порога уверенности, по умолчанию 0.05"
+//	@Param			cosine_sim_min		query		number				false	"Минимальный порог косинусной близости, по умолчанию 0.5"
+//	@Param			cosine_sim_max		query		number				false	"Максимальный порог косинусной близости, по умолчанию 0.95"
+//	@Param			cosine_sim_step		query		number				false	"Шаг порога косинусной близости, по умолчанию 0.05"
+//	@Success		200					{object}	ThresholdSweepDTO	"Успешный ответ"
+//	@Failure		400					{object}	HTTPError			"Некорректный период или сетка порогов"
+//	@Failure		404					{object}	HTTPError			"Тип инструмента не найден"
+//	@Failure		500					{object}	HTTPError			"Ошибка сервера"
+//	@Failure		401					{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/statistics/thresholds [get]
+func (h *Handler) getThresholdSweep(c *gin.Context) {
+	filter, err := parseScanHistoryFilter(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	toolTypeId, err := parseOptionalInt64Query(c, "tool_type_id")
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	confidence, err := parseThresholdRange(c, "confidence", usecase.DefaultThresholdRange())
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	cosineSim, err := parseThresholdRange(c, "cosine_sim", usecase.DefaultThresholdRange())
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	req := usecase.NewThresholdSweepReq(filter, toolTypeId, confidence, cosineSim)
+	res, err := h.service.GetThresholdSweep(c.Request.Context(), req)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryThresholdSweepDTO(res))
+}
+
 // getTransactionStatistics
 //
 //	@Summary		Получить общую статистику транзакций
@@ -234,6 +452,9 @@ func (h *Handler) getQaStatistics(c *gin.Context) {
 //	@Success		200	{object}	StatisticsRes	"Успешный ответ"
 //	@Failure		400	{object}	HTTPError		"Неверные параметры"
 //	@Failure		500	{object}	HTTPError		"Ошибка сервера"
+//	@Failure		401	{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403	{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/statistics/transactions [get]
 func (h *Handler) getTransactionStatistics(c *gin.Context) {
 	var res interface{}
@@ -251,15 +472,23 @@ func (h *Handler) getTransactionStatistics(c *gin.Context) {
 // check
 //
 //	@Summary		Операция выдачи/сдачи инструментов
-//	@Description	Принимает табельный номер инженера и фотографию инструментов в формате base64.<br> Сервис анализирует изображение, сопоставляет инструменты с ожидаемым набором и возвращает: <br><br>• URL обработанного изображения <br>• четыре массива: <br>1) access_tools — инструменты, прошедшие автоматическую проверку<br>1) manual_check_tools — инструменты, требующие ручной проверки <br>2) unknown_tools — инструменты, отсутствующие в ожидаемом наборе <br>3) missing_tools — инструменты, отсутствующие на фотографии, но ожидаемые<br>• transaction_type - тип транзакции(Checkin - Сдача/Checkout - Выдача)<br>• status - статус транзакции(OPEN - открыта, CLOSED - закрыта, QA VERIFICATION - QA проверка)<br><br> Если 4 или более инструментов не попали в access_tools или за 3 попытки сканирования транзакция не закрылась, устанавливается флаг "QA ПРОВЕРКА" (QA VERIFICATION). <br><br>Эндпоинт используется как для выдачи инструментов инженеру, так и для их последующей сдачи.
+//	@Description	Принимает фотографию инструментов в формате base64. Инженер определяется по access токену.<br> Сервис анализирует изображение, сопоставляет инструменты с ожидаемым набором и возвращает: <br><br>• URL обработанного изображения <br>• четыре массива: <br>1) access_tools — инструменты, прошедшие автоматическую проверку<br>1) manual_check_tools — инструменты, требующие ручной проверки <br>2) unknown_tools — инструменты, отсутствующие в ожидаемом наборе <br>3) missing_tools — инструменты, отсутствующие на фотографии, но ожидаемые (по записи на каждый недостающий экземпляр)<br>4) misplaced_tools — инструменты не в своём гнезде ложемента, если для набора задана раскладка; требуют ручной проверки<br>5) possibly_substituted_tools — при сдаче: инструменты, непохожие на выданные по этой транзакции; такая сдача уходит на QA<br>• tool_counts — ожидаемое и распознанное количество по каждому типу с излишком (surplus) и недостачей (shortfall); экземпляры сверх ожидаемого попадают в unknown_tools<br>• transaction_type - тип транзакции(Checkin - Сдача/Checkout - Выдача)<br>• status - статус транзакции(OPEN - открыта, CLOSED - закрыта, QA VERIFICATION - QA проверка)<br><br> Если 4 или более инструментов не попали в access_tools или за 3 попытки сканирования транзакция не закрылась, устанавливается флаг "QA ПРОВЕРКА" (QA VERIFICATION). <br><br>Эндпоинт используется как для выдачи инструментов инженеру, так и для их последующей сдачи.
 //
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		CheckReq	true	"Запрос на выдачу или сдачу инструментов"
+//	@Param			Idempotency-Key	header		string		false	"Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ"
+//	@Param			request			body		CheckReq	true	"Запрос на выдачу или сдачу инструментов"
+//	@Param			async			query		bool		false	"Асинхронный режим: вернуть 202 с идентификатором задания, результат получать через /users/check/jobs/{job_id}"
 //	@Success		200		{object}	CheckRes	"Успешная проверка"
+//	@Success		202		{object}	ScanJobDTO	"Проверка поставлена в очередь (async=true)"
 //	@Failure		400		{object}	HTTPError	"Неверное тело запроса"
+//	@Failure		409		{object}	HTTPError	"Набор нельзя выдать (версия выведена из оборота, не хватает исправных экземпляров, истёк срок поверки) или по сотруднику уже выполняется параллельная проверка"
+//	@Failure		422		{object}	HTTPError	"Idempotency-Key уже использован с другим телом запроса"
 //	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/users/check [post]
 func (h *Handler) check(c *gin.Context) {
 	var req CheckReq
@@ -268,7 +497,91 @@ func (h *Handler) check(c *gin.Context) {
 		return
 	}
 
-	res, err := h.service.Check(c.Request.Context(), ToUseCaseCheckReq(&req))
+	async, err := parseAsyncQuery(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	engineer, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	if async {
+		job, err := h.service.EnqueueCheck(c.Request.Context(), ToUseCaseCheckReq(&req, engineer.EmployeeId))
+		if err != nil {
+			ErrorToHttpRes(err, c)
+			return
+		}
+
+		acceptScanJob(c, job)
+		return
+	}
+
+	res, err := h.service.Check(c.Request.Context(), ToUseCaseCheckReq(&req, engineer.EmployeeId))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, ToDeliveryCheckRes(res))
+}
+
+// checkByBadge
+//
+//	@Summary		Операция выдачи/сдачи инструментов по пропуску
+//	@Description	Вариант эндпоинта /users/check для киоска: инженер идентифицируется по UID пропуска (RFID), а не по введённому табельному номеру.<br> Запрос выполняется с access токеном учётной записи киоска (роль Kiosk). Пропуск должен быть активирован и не отозван. Использованный пропуск и киоск сохраняются в транзакции.<br> Формат ответа совпадает с /users/check.
+//
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			Idempotency-Key	header		string			false	"Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ"
+//	@Param			request			body		CheckByBadgeReq	true	"Запрос на выдачу или сдачу инструментов по пропуску"
+//	@Param			async			query		bool			false	"Асинхронный режим: вернуть 202 с идентификатором задания, результат получать через /users/check/jobs/{job_id}"
+//	@Success		200		{object}	CheckRes		"Успешная проверка"
+//	@Success		202		{object}	ScanJobDTO		"Проверка поставлена в очередь (async=true)"
+//	@Failure		400		{object}	HTTPError		"Неверное тело запроса"
+//	@Failure		401		{object}	HTTPError		"Требуется авторизация киоска"
+//	@Failure		403		{object}	HTTPError		"Запрос выполнен не киоском, пропуск отозван или ещё не активирован"
+//	@Failure		404		{object}	HTTPError		"Пропуск не найден"
+//	@Failure		409		{object}	HTTPError		"Набор нельзя выдать (версия выведена из оборота, не хватает исправных экземпляров, истёк срок поверки) или по сотруднику уже выполняется параллельная проверка"
+//	@Failure		422		{object}	HTTPError		"Idempotency-Key уже использован с другим телом запроса"
+//	@Failure		500		{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Security		BearerAuth
+//	@Router			/api/v1/users/check/badge [post]
+func (h *Handler) checkByBadge(c *gin.Context) {
+	var req CheckByBadgeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	async, err := parseAsyncQuery(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	kiosk, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	if async {
+		job, err := h.service.EnqueueCheckByBadge(c.Request.Context(), toUseCaseCheckByBadgeReq(&req, kiosk.Id))
+		if err != nil {
+			ErrorToHttpRes(err, c)
+			return
+		}
+
+		acceptScanJob(c, job)
+		return
+	}
+
+	res, err := h.service.CheckByBadge(c.Request.Context(), toUseCaseCheckByBadgeReq(&req, kiosk.Id))
 	if err != nil {
 		ErrorToHttpRes(err, c)
 		return
@@ -280,17 +593,23 @@ func (h *Handler) check(c *gin.Context) {
 // postVerification
 //
 //	@Summary		QA-проверка и завершение транзакции
-//	@Description	Позволяет QA-сотруднику завершить проверку транзакции, указав причину проблемы (ошибка человека / модели), добавить комментарий и пометить инструменты, на которых модель ошиблась (через ToolIds в теле запроса).
+//	@Description	Позволяет QA-сотруднику завершить проверку транзакции, указав причину проблемы (ошибка человека / модели), добавить комментарий и пометить инструменты, на которых модель ошиблась (через ToolIds в теле запроса).<br> Проверяющий определяется по access токену. Сотрудник не может проверять транзакцию, в которой он сам является инженером.
 //
 //	@Tags			QA
 //	@Accept			json
 //	@Produce		json
 //	@Param			transaction_id	path		string			true	"Идентификатор транзакции"
+//	@Param			Idempotency-Key	header		string			false	"Ключ идемпотентности: повтор с тем же ключом и телом вернёт сохранённый ответ"
 //	@Param			request			body		VerificationReq	true	"Данные завершения QA-проверки"
 //	@Success		200				{object}	VerificationRes	"Успешное закрытие транзакции"
 //	@Failure		400				{object}	HTTPError		"Неверное тело запроса"
 //	@Failure		404				{object}	HTTPError		"Транзакция не найдена"
+//	@Failure		409				{object}	HTTPError		"По транзакции уже принято решение, она в работе у другого аудитора или назначена другому, либо запрос с этим Idempotency-Key ещё выполняется"
+//	@Failure		422				{object}	HTTPError		"Idempotency-Key уже использован с другим телом запроса"
 //	@Failure		500				{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError		"Недостаточно прав или попытка проверить собственную транзакцию"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/transactions/:transaction_id/verification [post]
 func (h *Handler) postVerification(c *gin.Context) {
 	strTransactionId := c.Param("transaction_id")
@@ -306,7 +625,13 @@ func (h *Handler) postVerification(c *gin.Context) {
 		return
 	}
 
-	res, err := h.service.Verification(c.Request.Context(), usecase.NewVerification(int64(transactionId), req.QAEmployeeId, req.Reason, req.Notes, req.ToolIds))
+	qa, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.Verification(c.Request.Context(), usecase.NewVerification(int64(transactionId), qa.Id, req.Reason, req.Notes, req.ToolIds))
 	if err != nil {
 		ErrorToHttpRes(err, c)
 		return
@@ -329,6 +654,9 @@ func (h *Handler) postVerification(c *gin.Context) {
 //	@Failure		400				{object}	HTTPError				"Неверное тело запроса"
 //	@Failure		404				{object}	HTTPError				"Транзакция не найдена"
 //	@Failure		500				{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/transactions/:transaction_id [get]
 func (h *Handler) getVerification(c *gin.Context) {
 	strTransactionId := c.Param("transaction_id")
@@ -359,6 +687,9 @@ func (h *Handler) getVerification(c *gin.Context) {
 //	@Success		200		{object}	ListTransactionsRes	"Список транзакций"
 //	@Failure		400		{object}	HTTPError			"Неверное тело запроса"
 //	@Failure		500		{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/transactions/ [get]
 func (h *Handler) list(c *gin.Context) {
 	status := c.Query("status")
@@ -374,7 +705,7 @@ func (h *Handler) list(c *gin.Context) {
 // login
 //
 //	@Summary		Вход в систему
-//	@Description	Вход в систему по табельному номеру сотрудника.<br> После успешного входа пользователь перенаправляется:<br> • инженеру — на экран загрузки фотографии инструментов;<br> • QA — на экран проверки незавершённых транзакций.
+//	@Description	Вход в систему по табельному номеру и PIN-коду сотрудника.<br> В ответ выдаются access и refresh токены.<br> PIN, выданный или сброшенный QA, нужно заменить при первом входе: без new_pin вход отклоняется с 403, с new_pin PIN заменяется и токены выдаются.<br> После LOGIN_MAX_ATTEMPTS неверных PIN-кодов подряд вход сотрудника блокируется на LOGIN_LOCKOUT (429). Access токен передаётся в заголовке `Authorization: Bearer <token>`.<br> После успешного входа пользователь перенаправляется:<br> • инженеру — на экран загрузки фотографии инструментов;<br> • QA — на экран проверки незавершённых транзакций.
 //
 //	@Tags			auth
 //	@Accept			json
@@ -382,7 +713,9 @@ func (h *Handler) list(c *gin.Context) {
 //	@Param			request	body		LoginReq	true	"Данные для входа"
 //	@Success		200		{object}	LoginRes	"Успешная авторизация"
 //	@Failure		400		{object}	HTTPError	"Неверное тело запроса"
-//	@Failure		404		{object}	HTTPError	"Пользователь не найден"
+//	@Failure		401		{object}	HTTPError	"Неверный табельный номер или PIN-код"
+//	@Failure		403		{object}	HTTPError	"Необходимо сменить PIN-код"
+//	@Failure		429		{object}	HTTPError	"Вход временно заблокирован"
 //	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
 //	@Router			/api/v1/auth/login [post]
 func (h *Handler) login(c *gin.Context) {
@@ -401,10 +734,40 @@ func (h *Handler) login(c *gin.Context) {
 	c.JSON(http.StatusOK, toDeliveryLoginRes(res))
 }
 
+// refresh
+//
+//	@Summary		Обновление токенов
+//	@Description	Выдаёт новую пару access/refresh токенов по действующему refresh токену.<br> Токены, выпущенные до смены или сброса PIN, недействительны.
+//
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		RefreshReq	true	"Refresh токен"
+//	@Success		200		{object}	LoginRes	"Новая пара токенов"
+//	@Failure		400		{object}	HTTPError	"Неверное тело запроса"
+//	@Failure		401		{object}	HTTPError	"Токен недействителен или истёк"
+//	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Router			/api/v1/auth/refresh [post]
+func (h *Handler) refresh(c *gin.Context) {
+	var req RefreshReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.Refresh(c.Request.Context(), toUseCaseRefreshReq(req))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryLoginRes(res))
+}
+
 // register
 //
 //	@Summary		Регистрация сотрудника в системе
-//	@Description	Регистрация сотрудника в системе.<br> Необходимые данные: табельный номер, ФИО и роль (например, "Инженер" или "QA").<br>
+//	@Description	Регистрация сотрудника в системе. Доступна только QA.<br> Необходимые данные: табельный номер, ФИО, роль (например, "Инженер" или "QA") и PIN-код/пароль (не короче 4 символов).<br>
 //
 //	@Tags			auth
 //	@Accept			json
@@ -414,6 +777,9 @@ func (h *Handler) login(c *gin.Context) {
 //	@Failure		400		{object}	HTTPError	"Неверное тело запроса"
 //	@Failure		409		{object}	HTTPError	"Пользователь с таким табельным номером уже существует"
 //	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/auth/register [post]
 func (h *Handler) register(c *gin.Context) {
 	var req RegisterReq
@@ -431,6 +797,43 @@ func (h *Handler) register(c *gin.Context) {
 	c.JSON(http.StatusCreated, toDeliveryRegisterRes(res))
 }
 
+// setUserPin
+//
+//	@Summary		Выдать или сбросить PIN-код сотрудника
+//	@Description	Задаёт сотруднику новый PIN-код. Доступно только QA.<br> Выданные ранее токены сотрудника отзываются, блокировка входа снимается. При следующем входе сотрудник должен заменить PIN на свой (new_pin в /auth/login).
+//	@Tags			auth
+//	@Accept			json
+//	@Param			user_id	path	int				true	"Идентификатор сотрудника"
+//	@Param			request	body	SetUserPinReq	true	"Новый PIN-код"
+//	@Success		204		"PIN-код задан"
+//	@Failure		400		{object}	HTTPError	"Неверное тело запроса"
+//	@Failure		404		{object}	HTTPError	"Пользователь не найден"
+//	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/users/:user_id/pin [put]
+func (h *Handler) setUserPin(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req SetUserPinReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	if err := h.service.SetUserPin(c.Request.Context(), toUseCaseSetUserPinReq(userId, req)); err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // getRoles
 //
 //	@Summary		Получить список ролей
@@ -454,7 +857,7 @@ func (h *Handler) getRoles(c *gin.Context) {
 // addToolSet
 //
 //	@Summary		Создание нового набора инструментов
-//	@Description	Принимает имя нового набора и список инструментов (их айди)
+//	@Description	Принимает имя нового набора и список инструментов (их айди). Повтор айди задаёт количество экземпляров: [3, 3, 3, 3] — четыре инструмента одного типа.
 //
 //	@Tags			tools
 //	@Accept			json
@@ -463,6 +866,9 @@ func (h *Handler) getRoles(c *gin.Context) {
 //	@Success		200		{object}	AddToolSetRes	"Новый набор"
 //	@Failure		400		{object}	HTTPError		"Неверное тело запроса"
 //	@Failure		500		{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/tools/new_set [post]
 func (h *Handler) addToolSet(c *gin.Context) {
 	var req AddToolSetReq
@@ -483,15 +889,19 @@ func (h *Handler) addToolSet(c *gin.Context) {
 // getMlErrorTools
 //
 //	@Summary		Возвращает наборы инструментов с ML-ошибками
-//	@Description	Возвращает список наборов инструментов, где для каждого инструмента указано, сколько раз на нём была зарегистрирована ошибка MODEL_ERR
+//	@Description	Возвращает список наборов инструментов, где для каждого инструмента указано, сколько раз на нём была зарегистрирована ошибка MODEL_ERR, всего и по версиям модели (by_model_version). Ошибка относится к версии модели последнего скана сдачи по транзакции.
 //	@Tags			QA
 //	@Produce		json
+//	@Param			model_version	query		string				false	"Учитывать только ошибки этой версии модели"
 //	@Success		200	{array}		ToolSetWithErrors	"Наборы и инструментами с MODEL_ERR ошибками"
 //	@Failure		400	{object}	HTTPError			"Неверное тело запроса"
 //	@Failure		500	{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401	{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403	{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
 //	@Router			/api/v1/qa/tools/ml-errors [get]
 func (h *Handler) getMlErrorTools(c *gin.Context) {
-	res, err := h.service.GetMlErrorTools(c.Request.Context())
+	res, err := h.service.GetMlErrorTools(c.Request.Context(), parseMlErrorFilter(c))
 	if err != nil {
 		ErrorToHttpRes(err, c)
 		return
@@ -499,3 +909,1604 @@ func (h *Handler) getMlErrorTools(c *gin.Context) {
 
 	c.JSON(http.StatusOK, toArrDeliveryToolSetWithErrors(res))
 }
+
+// createBadge
+//
+//	@Summary		Зарегистрировать пропуск
+//	@Description	Привязывает пропуск (UID RFID-метки) к сотруднику. Если activated_at не указан, пропуск активируется сразу.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateBadgeReq	true	"Данные пропуска"
+//	@Success		201		{object}	BadgeDTO		"Пропуск зарегистрирован"
+//	@Failure		400		{object}	HTTPError		"Неверное тело запроса"
+//	@Failure		404		{object}	HTTPError		"Пользователь не найден"
+//	@Failure		409		{object}	HTTPError		"Пропуск с таким UID уже зарегистрирован"
+//	@Failure		500		{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/badges/ [post]
+func (h *Handler) createBadge(c *gin.Context) {
+	var req CreateBadgeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.CreateBadge(c.Request.Context(), toUseCaseCreateBadgeReq(req))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryBadgeDTO(res))
+}
+
+// getUserBadges
+//
+//	@Summary		Пропуска сотрудника
+//	@Description	Возвращает все пропуска сотрудника, включая отозванные.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			employee_id	query		string		true	"Табельный номер сотрудника"
+//	@Success		200			{array}		BadgeDTO	"Список пропусков"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры"
+//	@Failure		404			{object}	HTTPError	"Пользователь не найден"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/badges/ [get]
+func (h *Handler) getUserBadges(c *gin.Context) {
+	employeeId := c.Query("employee_id")
+	if employeeId == "" {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetUserBadges(c.Request.Context(), employeeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryBadgeDTO(res))
+}
+
+// revokeBadge
+//
+//	@Summary		Отозвать пропуск
+//	@Description	Отзывает утерянный пропуск. Пользователь и его транзакции не затрагиваются, отозванный пропуск больше не принимается киоском.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			badge_id	path		int			true	"Идентификатор пропуска"
+//	@Success		200			{object}	BadgeDTO	"Пропуск отозван"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры или пропуск уже отозван"
+//	@Failure		404			{object}	HTTPError	"Пропуск не найден"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/badges/:badge_id/revoke [post]
+func (h *Handler) revokeBadge(c *gin.Context) {
+	badgeId, err := strconv.ParseInt(c.Param("badge_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.RevokeBadge(c.Request.Context(), badgeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryBadgeDTO(res))
+}
+
+// getToolTypes
+//
+//	@Summary		Справочник типов инструментов
+//	@Description	Возвращает все типы инструментов, которые может распознать система.
+//	@Tags			QA
+//	@Produce		json
+//	@Success		200	{array}		ToolTypeDTO	"Список типов инструментов"
+//	@Failure		500	{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401	{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403	{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/ [get]
+func (h *Handler) getToolTypes(c *gin.Context) {
+	res, err := h.service.GetToolTypes(c.Request.Context())
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolTypeDTO(res))
+}
+
+// createToolType
+//
+//	@Summary		Добавить тип инструмента
+//	@Description	Создаёт тип инструмента с эталонным эмбеддингом. Эмбеддинг должен содержать ровно 1280 значений.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateToolTypeReq	true	"Данные типа инструмента"
+//	@Success		201		{object}	ToolTypeDTO			"Тип инструмента создан"
+//	@Failure		400		{object}	HTTPError			"Неверное тело запроса или размер эмбеддинга"
+//	@Failure		409		{object}	HTTPError			"Тип инструмента с таким партномером уже существует"
+//	@Failure		500		{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/ [post]
+func (h *Handler) createToolType(c *gin.Context) {
+	var req CreateToolTypeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.CreateToolType(c.Request.Context(), toUseCaseCreateToolTypeReq(req))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryToolTypeDTO(res))
+}
+
+// renameToolType
+//
+//	@Summary		Переименовать тип инструмента
+//	@Description	Изменяет название типа инструмента. Партномер и эмбеддинг не меняются.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int					true	"Идентификатор типа инструмента"
+//	@Param			request			body		RenameToolTypeReq	true	"Новое название"
+//	@Success		200				{object}	ToolTypeDTO			"Тип инструмента переименован"
+//	@Failure		400				{object}	HTTPError			"Неверное тело запроса или название не изменилось"
+//	@Failure		404				{object}	HTTPError			"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id [patch]
+func (h *Handler) renameToolType(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req RenameToolTypeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.RenameToolType(c.Request.Context(), toolTypeId, req.Name)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeDTO(res))
+}
+
+// deleteToolType
+//
+//	@Summary		Удалить тип инструмента
+//	@Description	Удаляет тип инструмента. Типы, которые входят в наборы или встречались в сканах, удалить нельзя.
+//	@Tags			QA
+//	@Param			tool_type_id	path	int	true	"Идентификатор типа инструмента"
+//	@Success		204				"Тип инструмента удалён"
+//	@Failure		400				{object}	HTTPError	"Неверные параметры"
+//	@Failure		404				{object}	HTTPError	"Тип инструмента не найден"
+//	@Failure		409				{object}	HTTPError	"Тип инструмента используется"
+//	@Failure		500				{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id [delete]
+func (h *Handler) deleteToolType(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	if err := h.service.DeleteToolType(c.Request.Context(), toolTypeId); err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// updateToolTypeEmbedding
+//
+//	@Summary		Заменить эталонный эмбеддинг
+//	@Description	Заменяет эталонный эмбеддинг типа инструмента, с которым сравниваются распознанные инструменты. Эмбеддинг должен содержать ровно 1280 значений.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int							true	"Идентификатор типа инструмента"
+//	@Param			request			body		UpdateToolTypeEmbeddingReq	true	"Новый эмбеддинг"
+//	@Success		200				{object}	ToolTypeDTO					"Эмбеддинг обновлён"
+//	@Failure		400				{object}	HTTPError					"Неверное тело запроса или размер эмбеддинга"
+//	@Failure		404				{object}	HTTPError					"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError					"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError					"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError					"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/embedding [put]
+func (h *Handler) updateToolTypeEmbedding(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req UpdateToolTypeEmbeddingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.UpdateToolTypeEmbedding(c.Request.Context(), toolTypeId, req.ReferenceEmbedding)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeDTO(res))
+}
+
+// addToolTypeSamples
+//
+//	@Summary		Загрузить эталонные фотографии инструмента
+//	@Description	Принимает фотографии инструмента в формате base64. Каждая фотография распознаётся ML-сервисом, из детекций ожидаемого типа берётся самая уверенная, её эмбеддинг сохраняется как образец.<br> После загрузки эталонный эмбеддинг типа пересчитывается как нормализованное среднее по всем образцам.<br> rejected_images — индексы фотографий, на которых инструмент ожидаемого типа не найден.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int						true	"Идентификатор типа инструмента"
+//	@Param			request			body		AddToolTypeSamplesReq	true	"Фотографии инструмента"
+//	@Success		200				{object}	ToolTypeSamplesRes		"Эталонный эмбеддинг пересчитан"
+//	@Failure		400				{object}	HTTPError				"Неверное тело запроса или инструмент не найден ни на одной фотографии"
+//	@Failure		404				{object}	HTTPError				"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/samples [post]
+func (h *Handler) addToolTypeSamples(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req AddToolTypeSamplesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.AddToolTypeSamples(c.Request.Context(), usecase.NewAddToolTypeSamplesReq(toolTypeId, req.Images))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeSamplesRes(res))
+}
+
+// getToolTypeSamples
+//
+//	@Summary		Эталонные фотографии инструмента
+//	@Description	Возвращает сохранённые образцы, по которым рассчитан эталонный эмбеддинг типа инструмента.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_type_id	path		int					true	"Идентификатор типа инструмента"
+//	@Success		200				{array}		ToolTypeSampleDTO	"Список образцов"
+//	@Failure		400				{object}	HTTPError			"Неверные параметры"
+//	@Failure		404				{object}	HTTPError			"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/samples [get]
+func (h *Handler) getToolTypeSamples(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolTypeSamples(c.Request.Context(), toolTypeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolTypeSampleDTO(res))
+}
+
+// recomputeToolTypeEmbedding
+//
+//	@Summary		Пересчитать эталонный эмбеддинг
+//	@Description	Пересчитывает эталонный эмбеддинг типа инструмента по уже сохранённым образцам без повторного распознавания фотографий.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_type_id	path		int					true	"Идентификатор типа инструмента"
+//	@Success		200				{object}	ToolTypeSamplesRes	"Эталонный эмбеддинг пересчитан"
+//	@Failure		400				{object}	HTTPError			"Неверные параметры или у типа нет образцов"
+//	@Failure		404				{object}	HTTPError			"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/embedding/recompute [post]
+func (h *Handler) recomputeToolTypeEmbedding(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.RecomputeToolTypeEmbedding(c.Request.Context(), toolTypeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeSamplesRes(res))
+}
+
+// getToolTypeReferences
+//
+//	@Summary		Дополнительные эталоны инструмента
+//	@Description	Возвращает дополнительные эталонные эмбеддинги типа инструмента (разные ракурсы и состояния). При проверке используется максимальное сходство по основному и всем дополнительным эталонам.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_type_id	path		int						true	"Идентификатор типа инструмента"
+//	@Success		200				{array}		ToolTypeReferenceDTO	"Список эталонов"
+//	@Failure		400				{object}	HTTPError				"Неверные параметры"
+//	@Failure		404				{object}	HTTPError				"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/references [get]
+func (h *Handler) getToolTypeReferences(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolTypeReferences(c.Request.Context(), toolTypeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolTypeReferenceDTO(res))
+}
+
+// addToolTypeReference
+//
+//	@Summary		Добавить эталон инструмента
+//	@Description	Добавляет типу инструмента дополнительный эталонный эмбеддинг. Эмбеддинг должен содержать ровно 1280 значений.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int						true	"Идентификатор типа инструмента"
+//	@Param			request			body		AddToolTypeReferenceReq	true	"Эталон"
+//	@Success		201				{object}	ToolTypeReferenceDTO	"Эталон добавлен"
+//	@Failure		400				{object}	HTTPError				"Неверное тело запроса или размер эмбеддинга"
+//	@Failure		404				{object}	HTTPError				"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/references [post]
+func (h *Handler) addToolTypeReference(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req AddToolTypeReferenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.AddToolTypeReference(c.Request.Context(), usecase.NewAddToolTypeReferenceReq(toolTypeId, req.Label, req.Embedding))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryToolTypeReferenceDTO(res))
+}
+
+// deleteToolTypeReference
+//
+//	@Summary		Удалить эталон инструмента
+//	@Description	Удаляет дополнительный эталон. В ранее сохранённых сканах ссылка на удалённый эталон обнуляется.
+//	@Tags			QA
+//	@Param			tool_type_id	path	int	true	"Идентификатор типа инструмента"
+//	@Param			reference_id	path	int	true	"Идентификатор эталона"
+//	@Success		204				"Эталон удалён"
+//	@Failure		400				{object}	HTTPError	"Неверные параметры"
+//	@Failure		404				{object}	HTTPError	"Эталон не найден"
+//	@Failure		500				{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/references/:reference_id [delete]
+func (h *Handler) deleteToolTypeReference(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	referenceId, err := strconv.ParseInt(c.Param("reference_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	if err := h.service.DeleteToolTypeReference(c.Request.Context(), toolTypeId, referenceId); err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listToolSets
+//
+//	@Summary		Список наборов инструментов
+//	@Description	Возвращает последнюю версию каждого набора. Выведенные из оборота наборы возвращаются только при include_retired=true.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			include_retired	query		bool		false	"true — включить выведенные из оборота наборы"
+//	@Success		200				{array}		ToolSetDTO	"Список наборов"
+//	@Failure		400				{object}	HTTPError	"Неверные параметры"
+//	@Failure		500				{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/ [get]
+func (h *Handler) listToolSets(c *gin.Context) {
+	includeRetired := false
+	if str := c.Query("include_retired"); str != "" {
+		parsed, err := strconv.ParseBool(str)
+		if err != nil {
+			ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+			return
+		}
+		includeRetired = parsed
+	}
+
+	res, err := h.service.ListToolSets(c.Request.Context(), includeRetired)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolSetDTO(res))
+}
+
+// getToolSet
+//
+//	@Summary		Версия набора инструментов
+//	@Description	Возвращает версию набора вместе с составом инструментов. Выведенные из оборота версии также доступны для аудита.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_set_id	path		int			true	"Идентификатор версии набора"
+//	@Success		200			{object}	ToolSetDTO	"Версия набора"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры"
+//	@Failure		404			{object}	HTTPError	"Набор не найден"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id [get]
+func (h *Handler) getToolSet(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolSet(c.Request.Context(), toolSetId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolSetDTO(res))
+}
+
+// getToolSetVersions
+//
+//	@Summary		История версий набора
+//	@Description	Возвращает все версии набора, к которому относится указанная версия, от новой к старой.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_set_id	path		int			true	"Идентификатор любой версии набора"
+//	@Success		200			{array}		ToolSetDTO	"Версии набора"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры"
+//	@Failure		404			{object}	HTTPError	"Набор не найден"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/versions [get]
+func (h *Handler) getToolSetVersions(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolSetVersions(c.Request.Context(), toolSetId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolSetDTO(res))
+}
+
+// editToolSet
+//
+//	@Summary		Редактировать набор инструментов
+//	@Description	Создаёт новую версию набора с указанным названием и составом. Открытые транзакции продолжают ссылаться на версию, по которой была выдача; новые выдачи идут по последней версии.<br> Набор, последняя версия которого выведена из оборота, не редактируется. Если набор одновременно отредактирован другим пользователем, возвращается 409 и редактирование нужно повторить.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_set_id	path		int				true	"Идентификатор любой версии набора"
+//	@Param			request		body		EditToolSetReq	true	"Новое название и состав набора (повтор айди задаёт количество)"
+//	@Success		201			{object}	ToolSetDTO		"Создана новая версия"
+//	@Failure		400			{object}	HTTPError		"Неверное тело запроса или название уже занято"
+//	@Failure		404			{object}	HTTPError		"Набор не найден"
+//	@Failure		409			{object}	HTTPError		"В наборе числится больше экземпляров, чем требует новая версия; набор выведен из оборота или одновременно изменён"
+//	@Failure		500			{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id [put]
+func (h *Handler) editToolSet(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req EditToolSetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.EditToolSet(c.Request.Context(), usecase.NewEditToolSetReq(toolSetId, req.Name, req.ToolsIds))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryToolSetDTO(res))
+}
+
+// retireToolSet
+//
+//	@Summary		Вывести версию набора из оборота
+//	@Description	Помечает версию набора как выведенную из оборота. По последней выведенной версии нельзя выдавать инструменты, но она остаётся доступной для чтения.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_set_id	path		int			true	"Идентификатор версии набора"
+//	@Success		200			{object}	ToolSetDTO	"Версия выведена из оборота"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры или версия уже выведена"
+//	@Failure		404			{object}	HTTPError	"Набор не найден"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/retire [post]
+func (h *Handler) retireToolSet(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.RetireToolSet(c.Request.Context(), toolSetId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolSetDTO(res))
+}
+
+// getMlErrorInstances
+//
+//	@Summary		Экземпляры инструментов с ML-ошибками
+//	@Description	Детализация отчёта /qa/tools/ml-errors до конкретного экземпляра: для каждого экземпляра указано, сколько раз QA фиксировал ошибку MODEL_ERR на его типе в транзакциях, по которым этот экземпляр был выдан, всего и по версиям модели (by_model_version).
+//	@Tags			QA
+//	@Produce		json
+//	@Param			model_version	query		string						false	"Учитывать только ошибки этой версии модели"
+//	@Success		200	{array}		ToolInstanceWithErrorCount	"Экземпляры с MODEL_ERR ошибками"
+//	@Failure		500	{object}	HTTPError					"Внутренняя ошибка сервера"
+//	@Failure		401	{object}	HTTPError					"Требуется авторизация"
+//	@Failure		403	{object}	HTTPError					"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tools/ml-errors/instances [get]
+func (h *Handler) getMlErrorInstances(c *gin.Context) {
+	res, err := h.service.GetMlErrorInstances(c.Request.Context(), parseMlErrorFilter(c))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolInstanceWithErrorCount(res))
+}
+
+// listToolInstances
+//
+//	@Summary		Реестр экземпляров инструментов
+//	@Description	Возвращает экземпляры инструментов. Поддерживает фильтрацию по типу, набору (любая версия) и статусу.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_type_id	query		int				false	"Тип инструмента"
+//	@Param			tool_set_id		query		int				false	"Набор, к которому приписан экземпляр"
+//	@Param			status			query		string			false	"Статус: IN_SERVICE, QUARANTINED, LOST, SCRAPPED"
+//	@Success		200				{array}		ToolInstanceDTO	"Список экземпляров"
+//	@Failure		400				{object}	HTTPError		"Неверные параметры"
+//	@Failure		404				{object}	HTTPError		"Набор не найден"
+//	@Failure		500				{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/ [get]
+func (h *Handler) listToolInstances(c *gin.Context) {
+	toolTypeId, err := parseOptionalInt64Query(c, "tool_type_id")
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	toolSetId, err := parseOptionalInt64Query(c, "tool_set_id")
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	var status *string
+	if str := c.Query("status"); str != "" {
+		status = &str
+	}
+
+	res, err := h.service.ListToolInstances(c.Request.Context(), usecase.NewListToolInstancesReq(toolTypeId, toolSetId, status))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolInstanceDTO(res))
+}
+
+// createToolInstance
+//
+//	@Summary		Зарегистрировать экземпляр инструмента
+//	@Description	Регистрирует физический экземпляр инструмента по серийному номеру. Если указан набор, экземпляр приписывается к нему (ко всем его версиям) и выдаётся вместе с набором. Экземпляров типа в наборе не может быть больше, чем требует набор: при выдаче выдаются все числящиеся экземпляры.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateToolInstanceReq	true	"Данные экземпляра"
+//	@Success		201		{object}	ToolInstanceDTO			"Экземпляр зарегистрирован"
+//	@Failure		400		{object}	HTTPError				"Неверное тело запроса"
+//	@Failure		404		{object}	HTTPError				"Тип инструмента или набор не найден"
+//	@Failure		409		{object}	HTTPError				"Серийный номер уже зарегистрирован или в наборе нет места для экземпляра"
+//	@Failure		500		{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/ [post]
+func (h *Handler) createToolInstance(c *gin.Context) {
+	var req CreateToolInstanceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.CreateToolInstance(c.Request.Context(), usecase.NewCreateToolInstanceReq(req.SerialNumber, req.ToolTypeId, req.HomeToolSetId))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryToolInstanceDTO(res))
+}
+
+// getToolInstance
+//
+//	@Summary		Карточка экземпляра инструмента
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_instance_id	path		int				true	"Идентификатор экземпляра"
+//	@Success		200					{object}	ToolInstanceDTO	"Экземпляр"
+//	@Failure		400					{object}	HTTPError		"Неверные параметры"
+//	@Failure		404					{object}	HTTPError		"Экземпляр не найден"
+//	@Failure		500					{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401					{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/:tool_instance_id [get]
+func (h *Handler) getToolInstance(c *gin.Context) {
+	instanceId, err := strconv.ParseInt(c.Param("tool_instance_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolInstance(c.Request.Context(), instanceId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolInstanceDTO(res))
+}
+
+// changeToolInstanceStatus
+//
+//	@Summary		Изменить статус экземпляра
+//	@Description	Переводит экземпляр в статус IN_SERVICE, QUARANTINED, LOST или SCRAPPED. Выдаются только экземпляры в статусе IN_SERVICE; списанный экземпляр вернуть в оборот нельзя.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_instance_id	path		int							true	"Идентификатор экземпляра"
+//	@Param			request				body		ChangeToolInstanceStatusReq	true	"Новый статус"
+//	@Success		200					{object}	ToolInstanceDTO				"Статус изменён"
+//	@Failure		400					{object}	HTTPError					"Неверное тело запроса или статус"
+//	@Failure		404					{object}	HTTPError					"Экземпляр не найден"
+//	@Failure		409					{object}	HTTPError					"Экземпляр списан или в наборе нет места для найденного экземпляра"
+//	@Failure		500					{object}	HTTPError					"Внутренняя ошибка сервера"
+//	@Failure		401					{object}	HTTPError					"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError					"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/:tool_instance_id/status [patch]
+func (h *Handler) changeToolInstanceStatus(c *gin.Context) {
+	instanceId, err := strconv.ParseInt(c.Param("tool_instance_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req ChangeToolInstanceStatusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.ChangeToolInstanceStatus(c.Request.Context(), instanceId, req.Status)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolInstanceDTO(res))
+}
+
+// setToolTypeCalibrationInterval
+//
+//	@Summary		Задать периодичность поверки типа инструмента
+//	@Description	Устанавливает интервал поверки в днях для типа инструмента (динамометрические ключи, манометры и т.п.). Значение null отключает контроль поверки.<br> Экземпляры типа с заданной периодичностью, ни разу не прошедшие поверку, считаются просроченными и не выдаются.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int								true	"Идентификатор типа инструмента"
+//	@Param			request			body		SetCalibrationIntervalReq	true	"Периодичность поверки"
+//	@Success		200				{object}	ToolTypeDTO						"Периодичность сохранена"
+//	@Failure		400				{object}	HTTPError						"Неверное тело запроса или значение не изменилось"
+//	@Failure		404				{object}	HTTPError						"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError						"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError						"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError						"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/calibration-interval [put]
+func (h *Handler) setToolTypeCalibrationInterval(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req SetCalibrationIntervalReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.SetToolTypeCalibrationInterval(c.Request.Context(), toolTypeId, req.IntervalDays)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeDTO(res))
+}
+
+// setToolTypeThresholds
+//
+//	@Summary		Задать пороги проверки типа инструмента
+//	@Description	Устанавливает пороги уверенности модели и косинусной близости к эталону, с которыми детекции типа проходят автоматическую проверку. Значение null возвращает глобальный порог (CONFIDENCE, COSINE_SIM). Пороги, заданные для набора, важнее порогов типа.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_type_id	path		int					true	"Идентификатор типа инструмента"
+//	@Param			request			body		SetThresholdsReq	true	"Пороги проверки"
+//	@Success		200				{object}	ToolTypeDTO			"Пороги сохранены"
+//	@Failure		400				{object}	HTTPError			"Неверное тело запроса или порог вне допустимого диапазона"
+//	@Failure		404				{object}	HTTPError			"Тип инструмента не найден"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-types/:tool_type_id/thresholds [put]
+func (h *Handler) setToolTypeThresholds(c *gin.Context) {
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req SetThresholdsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.SetToolTypeThresholds(c.Request.Context(), toolTypeId, req.ConfidenceThreshold, req.CosineSimThreshold)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolTypeDTO(res))
+}
+
+// getToolSetThresholds
+//
+//	@Summary		Пороги проверки набора
+//	@Description	Возвращает для каждого типа инструмента версии набора применяемые пороги автоматической проверки, а также пороги, заданные для набора и для типа.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_set_id	path		int					true	"Идентификатор версии набора"
+//	@Success		200			{array}		ToolSetThresholdDTO	"Пороги проверки"
+//	@Failure		400			{object}	HTTPError			"Неверные параметры"
+//	@Failure		404			{object}	HTTPError			"Набор не найден"
+//	@Failure		500			{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/thresholds [get]
+func (h *Handler) getToolSetThresholds(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetToolSetThresholds(c.Request.Context(), toolSetId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryToolSetThresholdDTO(res))
+}
+
+// setToolSetThresholds
+//
+//	@Summary		Задать пороги типа инструмента в наборе
+//	@Description	Переопределяет пороги автоматической проверки типа инструмента для набора. Пороги действуют для всех версий набора, поэтому редактирование состава их не сбрасывает. Значение null наследует порог типа; если оба порога null, переопределение удаляется.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_set_id		path		int					true	"Идентификатор версии набора"
+//	@Param			tool_type_id	path		int					true	"Идентификатор типа инструмента"
+//	@Param			request			body		SetThresholdsReq	true	"Пороги проверки"
+//	@Success		200				{object}	ToolSetThresholdDTO	"Пороги сохранены"
+//	@Failure		400				{object}	HTTPError			"Неверное тело запроса, порог вне диапазона или тип не входит в набор"
+//	@Failure		404				{object}	HTTPError			"Набор не найден"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/thresholds/:tool_type_id [put]
+func (h *Handler) setToolSetThresholds(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	toolTypeId, err := strconv.ParseInt(c.Param("tool_type_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req SetThresholdsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.SetToolSetThresholds(c.Request.Context(), toolSetId, toolTypeId, req.ConfidenceThreshold, req.CosineSimThreshold)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryToolSetThresholdDTO(res))
+}
+
+// getBoardLayout
+//
+//	@Summary		Получить раскладку ложемента набора
+//	@Description	Возвращает гнёзда ложемента набора: многоугольники в пикселях изображения скана, по одному гнезду на экземпляр типа инструмента. Пустой список — расположение инструментов не проверяется.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_set_id	path		int				true	"Идентификатор версии набора"
+//	@Success		200			{array}		BoardSlotDTO	"Раскладка ложемента"
+//	@Failure		400			{object}	HTTPError		"Неверный идентификатор набора"
+//	@Failure		404			{object}	HTTPError		"Набор не найден"
+//	@Failure		500			{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/layout [get]
+func (h *Handler) getBoardLayout(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetBoardLayout(c.Request.Context(), toolSetId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryBoardSlotDTO(res))
+}
+
+// setBoardLayout
+//
+//	@Summary		Задать раскладку ложемента набора
+//	@Description	Заменяет раскладку ложемента набора. Раскладка действует для всех версий набора. При проверке каждый найденный инструмент должен лежать центром рамки в свободном гнезде своего типа, иначе он попадает в misplaced_tools и требует ручной проверки. Типы без гнёзд не проверяются; пустой список slots отключает проверку расположения.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_set_id	path		int					true	"Идентификатор версии набора"
+//	@Param			request		body		SetBoardLayoutReq	true	"Гнёзда ложемента"
+//	@Success		200			{array}		BoardSlotDTO		"Раскладка сохранена"
+//	@Failure		400			{object}	HTTPError			"Неверное тело запроса, гнездо меньше чем из 3 точек или тип не входит в набор"
+//	@Failure		404			{object}	HTTPError			"Набор не найден"
+//	@Failure		500			{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-sets/:tool_set_id/layout [put]
+func (h *Handler) setBoardLayout(c *gin.Context) {
+	toolSetId, err := strconv.ParseInt(c.Param("tool_set_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req SetBoardLayoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.SetBoardLayout(c.Request.Context(), toUsecaseSetBoardLayoutReq(toolSetId, &req))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryBoardSlotDTO(res))
+}
+
+// recordCalibration
+//
+//	@Summary		Зарегистрировать поверку экземпляра
+//	@Description	Сохраняет запись о поверке и переносит срок следующей поверки экземпляра. Поверитель — текущий пользователь.<br> Если calibrated_at не передан, используется текущее время. Если next_due_at не передан, срок вычисляется по периодичности типа инструмента.<br> Поверка задним числом, более ранняя, чем последняя зарегистрированная, сохраняется только в журнале: даты поверки экземпляра не меняются.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			tool_instance_id	path		int						true	"Идентификатор экземпляра"
+//	@Param			request				body		RecordCalibrationReq	true	"Данные поверки"
+//	@Success		201					{object}	CalibrationEventDTO		"Поверка зарегистрирована"
+//	@Failure		400					{object}	HTTPError				"Неверное тело запроса или даты поверки"
+//	@Failure		404					{object}	HTTPError				"Экземпляр не найден"
+//	@Failure		409					{object}	HTTPError				"Экземпляр списан"
+//	@Failure		500					{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401					{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/:tool_instance_id/calibrations [post]
+func (h *Handler) recordCalibration(c *gin.Context) {
+	instanceId, err := strconv.ParseInt(c.Param("tool_instance_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req RecordCalibrationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	user, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.RecordCalibration(c.Request.Context(), usecase.NewRecordCalibrationReq(instanceId, user.Id, req.CalibratedAt, req.NextDueAt, req.Notes))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryCalibrationEventDTO(res))
+}
+
+// getCalibrationHistory
+//
+//	@Summary		Журнал поверок экземпляра
+//	@Tags			QA
+//	@Produce		json
+//	@Param			tool_instance_id	path		int						true	"Идентификатор экземпляра"
+//	@Success		200					{array}		CalibrationEventDTO	"Поверки, начиная с последней"
+//	@Failure		400					{object}	HTTPError				"Неверные параметры"
+//	@Failure		404					{object}	HTTPError				"Экземпляр не найден"
+//	@Failure		500					{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401					{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403					{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/:tool_instance_id/calibrations [get]
+func (h *Handler) getCalibrationHistory(c *gin.Context) {
+	instanceId, err := strconv.ParseInt(c.Param("tool_instance_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.GetCalibrationHistory(c.Request.Context(), instanceId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryCalibrationEventDTO(res))
+}
+
+// getCalibrationDue
+//
+//	@Summary		Отчёт о предстоящих поверках
+//	@Description	Возвращает экземпляры в обороте (IN_SERVICE, QUARANTINED), поверка которых просрочена или наступает в ближайшие days дней (по умолчанию 30), а также экземпляры, ни разу не прошедшие обязательную поверку.<br> days_left отрицателен для просроченных экземпляров и отсутствует для не поверявшихся.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			days	query		int					false	"Горизонт отчёта в днях (по умолчанию 30)"
+//	@Success		200		{array}		CalibrationDueDTO	"Экземпляры, требующие поверки"
+//	@Failure		400		{object}	HTTPError			"Неверные параметры"
+//	@Failure		500		{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/tool-instances/calibration-due [get]
+func (h *Handler) getCalibrationDue(c *gin.Context) {
+	days := defaultCalibrationDueDays
+	if str := c.Query("days"); str != "" {
+		value, err := strconv.Atoi(str)
+		if err != nil {
+			ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+			return
+		}
+		days = value
+	}
+
+	res, err := h.service.GetCalibrationDue(c.Request.Context(), days)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryCalibrationDueDTO(res))
+}
+
+// getScanJob
+//
+//	@Summary		Состояние асинхронной проверки
+//	@Description	Возвращает состояние задания, созданного запросом /users/check?async=true (или /users/check/badge?async=true).<br> status: PENDING — в очереди, RUNNING — обрабатывается, DONE — готово (result содержит ответ в формате /users/check), FAILED — ошибка (error содержит причину).<br> Задание доступно только пользователю, который его поставил: инженеру или киоску.
+//	@Tags			users
+//	@Produce		json
+//	@Param			job_id	path		string		true	"Идентификатор задания"
+//	@Success		200		{object}	ScanJobDTO	"Состояние задания"
+//	@Failure		401		{object}	HTTPError	"Требуется авторизация"
+//	@Failure		404		{object}	HTTPError	"Задание не найдено"
+//	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Security		BearerAuth
+//	@Router			/api/v1/users/check/jobs/:job_id [get]
+func (h *Handler) getScanJob(c *gin.Context) {
+	requester, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.GetScanJob(c.Request.Context(), c.Param("job_id"), requester.Id)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryScanJobDTO(res))
+}
+
+// streamScanJob
+//
+//	@Summary		Поток состояния асинхронной проверки (SSE)
+//	@Description	Server-Sent Events: событие status с объектом ScanJobDTO отправляется при каждом изменении состояния задания. После DONE или FAILED поток закрывается. Задание доступно только пользователю, который его поставил.
+//	@Tags			users
+//	@Produce		text/event-stream
+//	@Param			job_id	path		string		true	"Идентификатор задания"
+//	@Success		200		{object}	ScanJobDTO	"События status"
+//	@Failure		401		{object}	HTTPError	"Требуется авторизация"
+//	@Failure		404		{object}	HTTPError	"Задание не найдено"
+//	@Failure		500		{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Security		BearerAuth
+//	@Router			/api/v1/users/check/jobs/:job_id/events [get]
+func (h *Handler) streamScanJob(c *gin.Context) {
+	requester, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	jobId := c.Param("job_id")
+	job, err := h.service.GetScanJob(c.Request.Context(), jobId, requester.Id)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	// поток живёт дольше HTTP_WRITE_TIMEOUT, поэтому дедлайн записи ограничивается временем ожидания задания
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(scanJobStreamTimeout)); err != nil {
+		log.Printf("scan job stream: set write deadline: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), scanJobStreamTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(scanJobStreamInterval)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	c.Stream(func(w io.Writer) bool {
+		if job.Status != lastStatus {
+			lastStatus = job.Status
+			c.SSEvent("status", toDeliveryScanJobDTO(job))
+		}
+
+		if job.Status == string(domain.JobDone) || job.Status == string(domain.JobFailed) {
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
+		job, err = h.service.GetScanJob(ctx, jobId, requester.Id)
+		if err != nil {
+			log.Printf("scan job stream: %v", err)
+			return false
+		}
+
+		return true
+	})
+}
+
+// parseAsyncQuery разбирает признак асинхронного режима проверки
+func parseAsyncQuery(c *gin.Context) (bool, error) {
+	str := c.Query("async")
+	if str == "" {
+		return false, nil
+	}
+
+	async, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, e.ErrInvalidRequestBody
+	}
+
+	return async, nil
+}
+
+// acceptScanJob отвечает 202 со ссылкой на задание асинхронной проверки
+func acceptScanJob(c *gin.Context, job *usecase.ScanJobDTO) {
+	c.Header("Location", "/api/v1/users/check/jobs/"+job.Id)
+	c.JSON(http.StatusAccepted, toDeliveryScanJobDTO(job))
+}
+
+// liveness
+//
+//	@Summary	Проверка, что сервис запущен
+//	@Tags		health
+//	@Success	204
+//	@Router		/api/v1/health/live [get]
+func (h *Handler) liveness(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// readiness
+//
+//	@Summary		Готовность сервиса выполнять проверки
+//	@Description	Возвращает состояние ML-сервиса по данным фоновой проверки доступности и состояние circuit breaker'а (CLOSED, OPEN, HALF_OPEN).<br> Если ML-сервис недоступен или цепь разомкнута, отвечает 503.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	ReadinessRes	"Сервис готов"
+//	@Failure		503	{object}	ReadinessRes	"ML-сервис недоступен"
+//	@Router			/api/v1/health/ready [get]
+func (h *Handler) readiness(c *gin.Context) {
+	res := h.service.Readiness()
+
+	status := http.StatusOK
+	if !res.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, toDeliveryReadinessRes(res))
+}
+
+// getMLClassMappings
+//
+//	@Summary		Сопоставления классов модели
+//	@Description	Возвращает сопоставления индексов классов ML-модели с типами инструментов. Сопоставления с версией "*" действуют для всех версий модели, у которых нет собственного сопоставления этого класса.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			model_version	query		string				false	"Только сопоставления этой версии модели"
+//	@Success		200				{array}		MLClassMappingDTO	"Сопоставления классов"
+//	@Failure		400				{object}	HTTPError			"Неверная версия модели"
+//	@Failure		500				{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/ml-class-mappings/ [get]
+func (h *Handler) getMLClassMappings(c *gin.Context) {
+	var modelVersion *string
+	if version, ok := c.GetQuery("model_version"); ok {
+		modelVersion = &version
+	}
+
+	res, err := h.service.GetMLClassMappings(c.Request.Context(), modelVersion)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryMLClassMappingDTO(res))
+}
+
+// createMLClassMapping
+//
+//	@Summary		Добавить сопоставление класса модели
+//	@Description	Сопоставляет индекс класса версии модели с типом инструмента. Версия "*" задаёт сопоставление для всех версий. Детекции несопоставленных классов возвращаются в unknown_tools.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		CreateMLClassMappingReq	true	"Версия модели, индекс класса и тип инструмента"
+//	@Success		201		{object}	MLClassMappingDTO		"Сопоставление создано"
+//	@Failure		400		{object}	HTTPError				"Неверное тело запроса, версия модели или индекс класса"
+//	@Failure		404		{object}	HTTPError				"Тип инструмента не найден"
+//	@Failure		409		{object}	HTTPError				"Класс этой версии модели уже сопоставлен"
+//	@Failure		500		{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/ml-class-mappings/ [post]
+func (h *Handler) createMLClassMapping(c *gin.Context) {
+	var req CreateMLClassMappingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.CreateMLClassMapping(c.Request.Context(), toUseCaseCreateMLClassMappingReq(req))
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toDeliveryMLClassMappingDTO(res))
+}
+
+// updateMLClassMapping
+//
+//	@Summary		Переназначить класс модели
+//	@Description	Сопоставляет класс модели с другим типом инструмента. Уже сохранённые сканы не пересчитываются.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			mapping_id	path		int						true	"Идентификатор сопоставления"
+//	@Param			request		body		UpdateMLClassMappingReq	true	"Новый тип инструмента"
+//	@Success		200			{object}	MLClassMappingDTO		"Сопоставление обновлено"
+//	@Failure		400			{object}	HTTPError				"Неверное тело запроса"
+//	@Failure		404			{object}	HTTPError				"Сопоставление или тип инструмента не найдены"
+//	@Failure		500			{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError				"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError				"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/ml-class-mappings/:mapping_id [patch]
+func (h *Handler) updateMLClassMapping(c *gin.Context) {
+	mappingId, err := strconv.ParseInt(c.Param("mapping_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req UpdateMLClassMappingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.UpdateMLClassMapping(c.Request.Context(), mappingId, req.ToolTypeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryMLClassMappingDTO(res))
+}
+
+// deleteMLClassMapping
+//
+//	@Summary		Удалить сопоставление класса модели
+//	@Description	Удаляет сопоставление. Детекции этого класса будут возвращаться в unknown_tools, если для класса нет сопоставления с версией "*".
+//	@Tags			QA
+//	@Param			mapping_id	path	int	true	"Идентификатор сопоставления"
+//	@Success		204			"Сопоставление удалено"
+//	@Failure		400			{object}	HTTPError	"Неверные параметры"
+//	@Failure		404			{object}	HTTPError	"Сопоставление не найдено"
+//	@Failure		500			{object}	HTTPError	"Внутренняя ошибка сервера"
+//	@Failure		401			{object}	HTTPError	"Требуется авторизация"
+//	@Failure		403			{object}	HTTPError	"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/ml-class-mappings/:mapping_id [delete]
+func (h *Handler) deleteMLClassMapping(c *gin.Context) {
+	mappingId, err := strconv.ParseInt(c.Param("mapping_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	if err := h.service.DeleteMLClassMapping(c.Request.Context(), mappingId); err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getQAQueue
+//
+//	@Summary		Очередь QA-проверки
+//	@Description	Возвращает транзакции в статусе QA VERIFICATION от самых давних к новым. Для каждой указаны время ожидания, SLA, признак просрочки и момент эскалации, назначенный аудитор и аудитор, взявший транзакцию в работу.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			mine	query		bool				false	"true — только транзакции, назначенные текущему аудитору"
+//	@Success		200		{array}		QAQueueItemDTO		"Очередь QA"
+//	@Failure		400		{object}	HTTPError			"Неверные параметры"
+//	@Failure		500		{object}	HTTPError			"Внутренняя ошибка сервера"
+//	@Failure		401		{object}	HTTPError			"Требуется авторизация"
+//	@Failure		403		{object}	HTTPError			"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/queue/ [get]
+func (h *Handler) getQAQueue(c *gin.Context) {
+	mine := false
+	if str := c.Query("mine"); str != "" {
+		parsed, err := strconv.ParseBool(str)
+		if err != nil {
+			ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+			return
+		}
+		mine = parsed
+	}
+
+	var assigneeId *int64
+	if mine {
+		qa, err := getUser(c)
+		if err != nil {
+			ErrorToHttpRes(err, c)
+			return
+		}
+		assigneeId = &qa.Id
+	}
+
+	res, err := h.service.GetQAQueue(c.Request.Context(), assigneeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toArrDeliveryQAQueueItemDTO(res))
+}
+
+// claimQAQueueItem
+//
+//	@Summary		Взять транзакцию в работу
+//	@Description	Блокирует транзакцию за текущим аудитором на время, заданное QA_CLAIM_TTL. Повторный вызов продлевает блокировку. Пока блокировка действует, другой аудитор не может взять транзакцию или отправить по ней решение.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			transaction_id	path		int				true	"Идентификатор транзакции"
+//	@Success		200				{object}	QAQueueItemDTO	"Элемент очереди"
+//	@Failure		400				{object}	HTTPError		"Неверные параметры"
+//	@Failure		404				{object}	HTTPError		"Транзакции нет в очереди QA"
+//	@Failure		409				{object}	HTTPError		"Транзакция в работе у другого аудитора или назначена другому аудитору"
+//	@Failure		500				{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/queue/:transaction_id/claim [post]
+func (h *Handler) claimQAQueueItem(c *gin.Context) {
+	transactionId, err := strconv.ParseInt(c.Param("transaction_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	qa, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.ClaimQAQueueItem(c.Request.Context(), transactionId, qa.Id)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryQAQueueItemDTO(res))
+}
+
+// releaseQAQueueItem
+//
+//	@Summary		Вернуть транзакцию в очередь
+//	@Description	Снимает блокировку, взятую текущим аудитором, не дожидаясь её истечения.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			transaction_id	path		int				true	"Идентификатор транзакции"
+//	@Success		200				{object}	QAQueueItemDTO	"Элемент очереди"
+//	@Failure		400				{object}	HTTPError		"Неверные параметры"
+//	@Failure		404				{object}	HTTPError		"Транзакции нет в очереди QA"
+//	@Failure		409				{object}	HTTPError		"Транзакция не в работе у текущего аудитора"
+//	@Failure		500				{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/queue/:transaction_id/claim [delete]
+func (h *Handler) releaseQAQueueItem(c *gin.Context) {
+	transactionId, err := strconv.ParseInt(c.Param("transaction_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	qa, err := getUser(c)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	res, err := h.service.ReleaseQAQueueItem(c.Request.Context(), transactionId, qa.Id)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryQAQueueItemDTO(res))
+}
+
+// assignQAQueueItem
+//
+//	@Summary		Назначить транзакцию аудитору
+//	@Description	Закрепляет транзакцию за аудитором: взять её в работу и отправить решение сможет только он. Блокировка другого аудитора снимается. Нельзя назначить аудитора, который сам является инженером по транзакции.
+//	@Tags			QA
+//	@Accept			json
+//	@Produce		json
+//	@Param			transaction_id	path		int						true	"Идентификатор транзакции"
+//	@Param			request			body		AssignQAQueueItemReq	true	"Табельный номер аудитора"
+//	@Success		200				{object}	QAQueueItemDTO			"Элемент очереди"
+//	@Failure		400				{object}	HTTPError				"Неверное тело запроса"
+//	@Failure		403				{object}	HTTPError				"Пользователь не аудитор качества или является инженером по транзакции"
+//	@Failure		404				{object}	HTTPError				"Транзакции нет в очереди QA или пользователь не найден"
+//	@Failure		500				{object}	HTTPError				"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError				"Требуется авторизация"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/queue/:transaction_id/assignee [put]
+func (h *Handler) assignQAQueueItem(c *gin.Context) {
+	transactionId, err := strconv.ParseInt(c.Param("transaction_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	var req AssignQAQueueItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.AssignQAQueueItem(c.Request.Context(), transactionId, req.EmployeeId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryQAQueueItemDTO(res))
+}
+
+// unassignQAQueueItem
+//
+//	@Summary		Снять назначение
+//	@Description	Возвращает транзакцию в общую очередь: её снова может взять любой аудитор.
+//	@Tags			QA
+//	@Produce		json
+//	@Param			transaction_id	path		int				true	"Идентификатор транзакции"
+//	@Success		200				{object}	QAQueueItemDTO	"Элемент очереди"
+//	@Failure		400				{object}	HTTPError		"Неверные параметры"
+//	@Failure		404				{object}	HTTPError		"Транзакции нет в очереди QA"
+//	@Failure		500				{object}	HTTPError		"Внутренняя ошибка сервера"
+//	@Failure		401				{object}	HTTPError		"Требуется авторизация"
+//	@Failure		403				{object}	HTTPError		"Недостаточно прав"
+//	@Security		BearerAuth
+//	@Router			/api/v1/qa/queue/:transaction_id/assignee [delete]
+func (h *Handler) unassignQAQueueItem(c *gin.Context) {
+	transactionId, err := strconv.ParseInt(c.Param("transaction_id"), 10, 64)
+	if err != nil {
+		ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+		return
+	}
+
+	res, err := h.service.UnassignQAQueueItem(c.Request.Context(), transactionId)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toDeliveryQAQueueItemDTO(res))
+}
@@ -1,10 +1,14 @@
 package v1
 
 import (
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/internal/usecase"
 	"airport-tools-backend/pkg/e"
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -74,6 +78,87 @@ func ErrorToHttpRes(err error, c *gin.Context) {
 	case errors.Is(err, e.ErrToolSetExists):
 		res.Code = http.StatusBadRequest
 		res.Message = "Набор с таким именем уже существует"
+	case errors.Is(err, e.ErrToolSetRetired):
+		res.Code = http.StatusConflict
+		res.Message = "Набор инструментов выведен из оборота"
+	case errors.Is(err, e.ErrToolSetConcurrentEdit):
+		res.Code = http.StatusConflict
+		res.Message = "Набор одновременно изменён другим пользователем, повторите редактирование"
+	case errors.Is(err, e.ErrToolInstanceNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Экземпляр инструмента не найден"
+	case errors.Is(err, e.ErrToolInstanceExists):
+		res.Code = http.StatusConflict
+		res.Message = "Экземпляр с таким серийным номером уже зарегистрирован"
+	case errors.Is(err, e.ErrToolInstanceStatusInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Недопустимый статус экземпляра. Допустимые значения: IN_SERVICE, QUARANTINED, LOST, SCRAPPED"
+	case errors.Is(err, e.ErrToolInstanceScrapped):
+		res.Code = http.StatusConflict
+		res.Message = "Экземпляр списан и не может быть возвращён в оборот"
+	case errors.Is(err, e.ErrToolInstancesUnavailable):
+		res.Code = http.StatusConflict
+		res.Message = "В наборе не хватает исправных экземпляров инструментов, выдача невозможна"
+	case errors.Is(err, e.ErrToolInstancesExceedQuantity):
+		res.Code = http.StatusConflict
+		res.Message = "В наборе числится больше экземпляров инструмента, чем требует набор: лишние нужно списать"
+	case errors.Is(err, e.ErrToolCalibrationOverdue):
+		res.Code = http.StatusConflict
+		res.Message = "У инструмента из набора истёк срок поверки, выдача невозможна"
+	case errors.Is(err, e.ErrCalibrationIntervalInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Периодичность поверки должна быть положительной"
+	case errors.Is(err, e.ErrCalibrationDueDateRequired):
+		res.Code = http.StatusBadRequest
+		res.Message = "Для типа без периодичности поверки необходимо указать срок следующей поверки"
+	case errors.Is(err, e.ErrCalibrationDateInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Некорректные даты поверки: дата поверки не может быть в будущем, срок следующей поверки должен быть позже"
+	case errors.Is(err, e.ErrTransactionConcurrentCheck):
+		res.Code = http.StatusConflict
+		res.Message = "По этому сотруднику уже выполняется другая проверка. Обновите статус и повторите попытку"
+	case errors.Is(err, e.ErrIdempotencyKeyInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Некорректный заголовок Idempotency-Key: ожидается непустая строка длиной до 128 символов"
+	case errors.Is(err, e.ErrIdempotencyKeyConflict):
+		res.Code = http.StatusUnprocessableEntity
+		res.Message = "Ключ Idempotency-Key уже использован с другим телом запроса"
+	case errors.Is(err, e.ErrIdempotencyKeyInProgress):
+		res.Code = http.StatusConflict
+		res.Message = "Запрос с этим ключом Idempotency-Key ещё выполняется, повторите позже"
+	case errors.Is(err, e.ErrScanJobNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Задание проверки не найдено"
+	case errors.Is(err, e.ErrMLServiceUnavailable):
+		res.Code = http.StatusServiceUnavailable
+		res.Message = "Сервис распознавания временно недоступен, повторите попытку позже"
+	case errors.Is(err, e.ErrMLClassMappingNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Сопоставление класса модели не найдено"
+	case errors.Is(err, e.ErrMLClassMappingExists):
+		res.Code = http.StatusConflict
+		res.Message = "Этот класс версии модели уже сопоставлен с типом инструмента"
+	case errors.Is(err, e.ErrMLClassIndexInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Индекс класса модели не может быть отрицательным"
+	case errors.Is(err, e.ErrModelVersionInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Версия модели должна быть непустой строкой длиной до 255 символов"
+	case errors.Is(err, e.ErrThresholdInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Порог уверенности должен быть в диапазоне [0, 1], порог косинусной близости — в диапазоне [-1, 1]"
+	case errors.Is(err, e.ErrToolTypeNotInToolSet):
+		res.Code = http.StatusBadRequest
+		res.Message = "Тип инструмента не входит в набор"
+	case errors.Is(err, e.ErrBoardSlotInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Гнездо ложемента задаётся многоугольником не менее чем из 3 точек"
+	case errors.Is(err, e.ErrThresholdSweepInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Некорректная сетка порогов: шаг должен быть положительным, min не больше max, уверенность в [0, 1], косинусная близость в [-1, 1], не более 2500 пар"
+	case errors.Is(err, e.ErrDateRangeInvalid):
+		res.Code = http.StatusBadRequest
+		res.Message = "Некорректный период: from и to указываются в формате RFC3339, from должен быть раньше to"
 	case errors.Is(err, e.ErrTransactionStatusNotFound):
 		res.Code = http.StatusBadRequest
 		res.Message = "Такого статуса не существует"
@@ -83,6 +168,81 @@ func ErrorToHttpRes(err error, c *gin.Context) {
 	case errors.Is(err, e.ErrRoleExists):
 		res.Code = http.StatusConflict
 		res.Message = "Роль с таким именем уже существует"
+	case errors.Is(err, e.ErrUnauthorized):
+		res.Code = http.StatusUnauthorized
+		res.Message = "Требуется авторизация"
+	case errors.Is(err, e.ErrInvalidCredentials):
+		res.Code = http.StatusUnauthorized
+		res.Message = "Неверный табельный номер или PIN-код"
+	case errors.Is(err, e.ErrInvalidToken):
+		res.Code = http.StatusUnauthorized
+		res.Message = "Токен недействителен или истёк"
+	case errors.Is(err, e.ErrLoginLocked):
+		res.Code = http.StatusTooManyRequests
+		res.Message = "Слишком много неверных PIN-кодов, вход временно заблокирован"
+	case errors.Is(err, e.ErrPinChangeRequired):
+		res.Code = http.StatusForbidden
+		res.Message = "Необходимо сменить PIN-код: передайте new_pin"
+	case errors.Is(err, e.ErrPinNotChanged):
+		res.Code = http.StatusBadRequest
+		res.Message = "Новый PIN-код должен отличаться от текущего"
+	case errors.Is(err, e.ErrForbidden):
+		res.Code = http.StatusForbidden
+		res.Message = "Недостаточно прав для выполнения операции"
+	case errors.Is(err, e.ErrUserNotQualityAuditor):
+		res.Code = http.StatusForbidden
+		res.Message = "Завершить проверку может только сотрудник QA"
+	case errors.Is(err, e.ErrQAQueueItemNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Транзакции нет в очереди QA"
+	case errors.Is(err, e.ErrQAQueueItemClaimed):
+		res.Code = http.StatusConflict
+		res.Message = "Транзакция в работе у другого аудитора"
+	case errors.Is(err, e.ErrQAQueueItemAssigned):
+		res.Code = http.StatusConflict
+		res.Message = "Транзакция назначена другому аудитору"
+	case errors.Is(err, e.ErrQAQueueItemNotClaimed):
+		res.Code = http.StatusConflict
+		res.Message = "Транзакция не в работе у вас"
+	case errors.Is(err, e.ErrTransactionAlreadyResolved):
+		res.Code = http.StatusConflict
+		res.Message = "По транзакции уже принято решение"
+	case errors.Is(err, e.ErrVerificationSelfReview):
+		res.Code = http.StatusForbidden
+		res.Message = "Нельзя проверять транзакцию, в которой вы являетесь инженером"
+	case errors.Is(err, e.ErrToolTypeNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Тип инструмента не найден"
+	case errors.Is(err, e.ErrToolTypeExists):
+		res.Code = http.StatusConflict
+		res.Message = "Тип инструмента с таким партномером уже существует"
+	case errors.Is(err, e.ErrToolTypeIsUsed):
+		res.Code = http.StatusConflict
+		res.Message = "Тип инструмента используется в наборах или сканах и не может быть удалён"
+	case errors.Is(err, e.ErrInvalidEmbeddingSize):
+		res.Code = http.StatusBadRequest
+		res.Message = "Эталонный эмбеддинг должен содержать ровно 1280 значений"
+	case errors.Is(err, e.ErrToolTypeNoSamples):
+		res.Code = http.StatusBadRequest
+		res.Message = "Ни на одной фотографии не найден инструмент этого типа"
+	case errors.Is(err, e.ErrToolTypeReferenceNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Эталон типа инструмента не найден"
+	case errors.Is(err, e.ErrBadgeNotFound):
+		res.Code = http.StatusNotFound
+		res.Message = "Пропуск не найден"
+	case errors.Is(err, e.ErrBadgeExists):
+		res.Code = http.StatusConflict
+		res.Message = "Пропуск с таким UID уже зарегистрирован"
+	case errors.Is(err, e.ErrBadgeRevoked):
+		res.Code = http.StatusForbidden
+		res.Message = "Пропуск отозван"
+	case errors.Is(err, e.ErrBadgeNotActive):
+		res.Code = http.StatusForbidden
+		res.Message = "Пропуск ещё не активирован"
+	case errors.Is(err, e.ErrNothingToChange):
+		res.Code = http.StatusBadRequest
+		res.Message = "Нет изменений для применения"
 	default:
 		res.Code = http.StatusInternalServerError
 		res.Message = "Внутренняя ошибка сервера"
@@ -90,3 +250,110 @@ func ErrorToHttpRes(err error, c *gin.Context) {
 
 	c.JSON(res.Code, res)
 }
+
+// parseOptionalInt64Query разбирает необязательный числовой query-параметр; отсутствующий параметр даёт nil
+func parseOptionalInt64Query(c *gin.Context, key string) (*int64, error) {
+	str := c.Query(key)
+	if str == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || value <= 0 {
+		return nil, e.ErrInvalidRequestBody
+	}
+
+	return &value, nil
+}
+
+// parseMlErrorFilter разбирает фильтр статистики ошибок модели из query-параметров
+func parseMlErrorFilter(c *gin.Context) repository.MlErrorFilter {
+	var filter repository.MlErrorFilter
+	if modelVersion := c.Query("model_version"); modelVersion != "" {
+		filter.ModelVersion = &modelVersion
+	}
+
+	return filter
+}
+
+// parseShadowReportFilter читает фильтр отчёта теневой модели: ?model_version=, ?from= и ?to= в формате RFC3339
+func parseShadowReportFilter(c *gin.Context) (repository.ShadowReportFilter, error) {
+	var filter repository.ShadowReportFilter
+	if modelVersion := c.Query("model_version"); modelVersion != "" {
+		filter.ModelVersion = &modelVersion
+	}
+
+	var err error
+	filter.From, filter.To, err = parseReportPeriod(c)
+
+	return filter, err
+}
+
+// parseScanHistoryFilter читает фильтр сканов сдачи: ?model_version=, ?from= и ?to= в формате RFC3339
+func parseScanHistoryFilter(c *gin.Context) (repository.ScanHistoryFilter, error) {
+	var filter repository.ScanHistoryFilter
+	if modelVersion := c.Query("model_version"); modelVersion != "" {
+		filter.ModelVersion = &modelVersion
+	}
+
+	var err error
+	filter.From, filter.To, err = parseReportPeriod(c)
+
+	return filter, err
+}
+
+// parseReportPeriod читает необязательные границы периода ?from= и ?to= в формате RFC3339
+func parseReportPeriod(c *gin.Context) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if str := c.Query("from"); str != "" {
+		parsed, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, nil, e.ErrDateRangeInvalid
+		}
+		from = &parsed
+	}
+
+	if str := c.Query("to"); str != "" {
+		parsed, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, nil, e.ErrDateRangeInvalid
+		}
+		to = &parsed
+	}
+
+	return from, to, nil
+}
+
+// parseThresholdRange читает диапазон перебора порога из ?<prefix>_min=, ?<prefix>_max= и ?<prefix>_step=,
+// отсутствующие параметры берутся из defaults
+func parseThresholdRange(c *gin.Context, prefix string, defaults usecase.ThresholdRange) (usecase.ThresholdRange, error) {
+	var res usecase.ThresholdRange
+	var err error
+
+	if res.Min, err = parseFloat32Query(c, prefix+"_min", defaults.Min); err != nil {
+		return res, err
+	}
+	if res.Max, err = parseFloat32Query(c, prefix+"_max", defaults.Max); err != nil {
+		return res, err
+	}
+	if res.Step, err = parseFloat32Query(c, prefix+"_step", defaults.Step); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// parseFloat32Query разбирает необязательный дробный query-параметр порога; отсутствующий параметр даёт def
+func parseFloat32Query(c *gin.Context, key string, def float32) (float32, error) {
+	str := c.Query(key)
+	if str == "" {
+		return def, nil
+	}
+
+	value, err := strconv.ParseFloat(str, 32)
+	if err != nil {
+		return 0, e.ErrThresholdSweepInvalid
+	}
+
+	return float32(value), nil
+}
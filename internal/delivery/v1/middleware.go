@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authorizationHeader string = "Authorization"
+	bearerPrefix        string = "Bearer "
+	userCtx             string = "user"
+	idempotencyHeader   string = "Idempotency-Key"
+	replayedHeader      string = "Idempotent-Replayed"
+)
+
+// userIdentity проверяет access токен из заголовка Authorization и сохраняет пользователя в контексте запроса
+func (h *Handler) userIdentity(c *gin.Context) {
+	header := c.GetHeader(authorizationHeader)
+	if header == "" || !strings.HasPrefix(header, bearerPrefix) {
+		ErrorToHttpRes(e.ErrUnauthorized, c)
+		c.Abort()
+		return
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	if token == "" {
+		ErrorToHttpRes(e.ErrUnauthorized, c)
+		c.Abort()
+		return
+	}
+
+	user, err := h.service.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		ErrorToHttpRes(err, c)
+		c.Abort()
+		return
+	}
+
+	c.Set(userCtx, user)
+	c.Next()
+}
+
+// requireRole пропускает запрос дальше, только если у пользователя одна из перечисленных ролей.
+// Должен использоваться после userIdentity
+func (h *Handler) requireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getUser(c)
+		if err != nil {
+			ErrorToHttpRes(err, c)
+			c.Abort()
+			return
+		}
+
+		if !user.HasRole(roles...) {
+			ErrorToHttpRes(e.ErrForbidden, c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// getUser возвращает пользователя, сохранённого в контексте userIdentity
+func getUser(c *gin.Context) (*domain.User, error) {
+	value, ok := c.Get(userCtx)
+	if !ok {
+		return nil, e.ErrUnauthorized
+	}
+
+	user, ok := value.(*domain.User)
+	if !ok || user == nil {
+		return nil, e.ErrUnauthorized
+	}
+
+	return user, nil
+}
+
+// idempotency обеспечивает повторяемость запроса с заголовком Idempotency-Key: первый ответ сохраняется
+// и отдаётся повторно на запросы с тем же ключом и телом. Запросы без заголовка обрабатываются как обычно.
+// Ключи разделяются по scope и пользователю, поэтому middleware ставится после userIdentity, если он есть
+func (h *Handler) idempotency(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(idempotencyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			ErrorToHttpRes(e.ErrInvalidRequestBody, c)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// путь входит в хеш: тот же ключ для другой транзакции считается конфликтом, а не повтором
+		hasher := sha256.New()
+		hasher.Write([]byte(c.Request.URL.Path))
+		hasher.Write(body)
+		requestHash := hex.EncodeToString(hasher.Sum(nil))
+
+		keyScope := scope
+		if user, err := getUser(c); err == nil {
+			keyScope = fmt.Sprintf("%s:%d", scope, user.Id)
+		}
+
+		replay, err := h.service.BeginIdempotentRequest(c.Request.Context(), keyScope, key, requestHash)
+		if err != nil {
+			ErrorToHttpRes(err, c)
+			c.Abort()
+			return
+		}
+
+		if replay != nil {
+			c.Header(replayedHeader, "true")
+			c.Data(replay.StatusCode, "application/json; charset=utf-8", replay.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// ответ сохраняется даже если клиент уже отключился, иначе повтор выполнит операцию ещё раз
+		ctx := context.WithoutCancel(c.Request.Context())
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := h.service.ReleaseIdempotentRequest(ctx, keyScope, key); err != nil {
+				log.Printf("release idempotency key %q: %v", key, err)
+			}
+			return
+		}
+
+		if err := h.service.CompleteIdempotentRequest(ctx, keyScope, key, recorder.Status(), recorder.body.Bytes()); err != nil {
+			log.Printf("complete idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить для повторов
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"strings"
+	"time"
+)
+
+// Badge описывает пропуск (RFID-метку) сотрудника, по которому он идентифицируется на киоске выдачи
+type Badge struct {
+	Id          int64
+	Uid         string
+	UserId      int64
+	ActivatedAt time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+
+	User *User
+}
+
+func NewBadge(uid string, userId int64, activatedAt time.Time) *Badge {
+	return &Badge{
+		Uid:         NormalizeBadgeUid(uid),
+		UserId:      userId,
+		ActivatedAt: activatedAt,
+	}
+}
+
+// NormalizeBadgeUid приводит UID метки к единому виду, в котором он хранится в базе
+func NormalizeBadgeUid(uid string) string {
+	return strings.ToUpper(strings.TrimSpace(uid))
+}
+
+// IsActive проверяет, что пропуск уже активирован и ещё не отозван на момент now
+func (b *Badge) IsActive(now time.Time) error {
+	if b.RevokedAt != nil && !now.Before(*b.RevokedAt) {
+		return e.ErrBadgeRevoked
+	}
+
+	if now.Before(b.ActivatedAt) {
+		return e.ErrBadgeNotActive
+	}
+
+	return nil
+}
+
+// Revoke отзывает пропуск. Сам пользователь при этом не удаляется
+func (b *Badge) Revoke(at time.Time) error {
+	if b.RevokedAt != nil {
+		return e.ErrNothingToChange
+	}
+
+	b.RevokedAt = &at
+	return nil
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "airport-tools-backend/pkg/e"
+
+// BoardSlot гнездо ложемента под один экземпляр типа инструмента. Polygon задаётся вершинами в пикселях
+// изображения скана. Раскладка задаётся для семейства версий набора, как и пороги набора
+type BoardSlot struct {
+	Id         int64
+	FamilyId   int64
+	ToolTypeId int64
+	Polygon    []Point
+}
+
+// Point точка на изображении скана в пикселях
+type Point struct {
+	X float32
+	Y float32
+}
+
+func NewBoardSlot(familyId, toolTypeId int64, polygon []Point) (*BoardSlot, error) {
+	if len(polygon) < 3 {
+		return nil, e.ErrBoardSlotInvalid
+	}
+
+	return &BoardSlot{
+		FamilyId:   familyId,
+		ToolTypeId: toolTypeId,
+		Polygon:    polygon,
+	}, nil
+}
+
+// Contains проверяет, лежит ли точка внутри многоугольника гнезда (правило чётности пересечений)
+func (s *BoardSlot) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(s.Polygon)-1; i < len(s.Polygon); j, i = i, i+1 {
+		a, b := s.Polygon[i], s.Polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
+
+// CalibrationEvent запись о поверке экземпляра инструмента
+type CalibrationEvent struct {
+	Id             int64
+	ToolInstanceId int64
+	CalibratedAt   time.Time
+	NextDueAt      time.Time
+	PerformedBy    int64
+	Notes          string
+	CreatedAt      time.Time
+}
+
+// NewCalibrationEvent создаёт запись о поверке. Если срок следующей поверки не указан,
+// он вычисляется по периодичности типа инструмента
+func NewCalibrationEvent(instance *ToolInstance, calibratedAt time.Time, nextDueAt *time.Time, performedBy int64, notes string, now time.Time) (*CalibrationEvent, error) {
+	if calibratedAt.After(now) {
+		return nil, e.ErrCalibrationDateInvalid
+	}
+
+	var dueAt time.Time
+	switch {
+	case nextDueAt != nil:
+		dueAt = *nextDueAt
+	case instance.ToolType != nil && instance.ToolType.CalibrationIntervalDays != nil:
+		dueAt = calibratedAt.AddDate(0, 0, *instance.ToolType.CalibrationIntervalDays)
+	default:
+		return nil, e.ErrCalibrationDueDateRequired
+	}
+
+	if !dueAt.After(calibratedAt) {
+		return nil, e.ErrCalibrationDateInvalid
+	}
+
+	return &CalibrationEvent{
+		ToolInstanceId: instance.Id,
+		CalibratedAt:   calibratedAt,
+		NextDueAt:      dueAt,
+		PerformedBy:    performedBy,
+		Notes:          notes,
+	}, nil
+}
@@ -15,17 +15,20 @@ type CvScan struct {
 	ScanType      ScanType
 	ImageUrl      string
 	DebugImageUrl string
-	CreatedAt     time.Time
+	// ModelVersion версия модели, выполнившей распознавание; nil для сканов, сделанных до учёта версий
+	ModelVersion *string
+	CreatedAt    time.Time
 
 	TransactionObj *Transaction
 	DetectedTools  []*CvScanDetail
 }
 
-func NewCvScan(transactionId int64, scanType ScanType, imageUrl, debugImageUrl string) *CvScan {
+func NewCvScan(transactionId int64, scanType ScanType, imageUrl, debugImageUrl string, modelVersion *string) *CvScan {
 	return &CvScan{
 		TransactionId: transactionId,
 		ScanType:      scanType,
 		ImageUrl:      imageUrl,
 		DebugImageUrl: debugImageUrl,
+		ModelVersion:  modelVersion,
 	}
 }
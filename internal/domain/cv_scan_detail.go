@@ -4,15 +4,19 @@ package domain
 type CvScanDetail struct {
 	Id                 int64
 	CvScanId           int64
-	DetectedToolTypeId int64
+	ClassIndex         int64
+	DetectedToolTypeId *int64
 	Confidence         float32
 	Embedding          []float32
 	Bbox               []float32
+	ReferenceId        *int64
+	CosineSimilarity   *float32
 }
 
-func NewCvScanDetail(cvScanId, detectedToolTypeId int64, confidence float32, embedding, bbox []float32) *CvScanDetail {
+func NewCvScanDetail(cvScanId, classIndex int64, detectedToolTypeId *int64, confidence float32, embedding, bbox []float32) *CvScanDetail {
 	return &CvScanDetail{
 		CvScanId:           cvScanId,
+		ClassIndex:         classIndex,
 		DetectedToolTypeId: detectedToolTypeId,
 		Confidence:         confidence,
 		Embedding:          embedding,
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
+
+// MaxIdempotencyKeyLength совпадает с размером столбца idempotency_keys.key
+const MaxIdempotencyKeyLength = 128
+
+// IdempotencyKey сохранённый результат запроса с заголовком Idempotency-Key.
+// Scope разделяет ключи разных эндпоинтов и пользователей, RequestHash — sha256 тела запроса
+type IdempotencyKey struct {
+	Scope        string
+	Key          string
+	RequestHash  string
+	StatusCode   *int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+}
+
+func NewIdempotencyKey(scope, key, requestHash string) *IdempotencyKey {
+	return &IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+	}
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return e.ErrIdempotencyKeyInvalid
+	}
+
+	return nil
+}
+
+// IsCompleted сообщает, сохранён ли уже ответ на запрос
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.CompletedAt != nil && k.StatusCode != nil
+}
+
+// IsStale сообщает, что ключ больше не действует: истёк срок хранения ответа
+// или запрос так и не завершился за lockTimeout (например, процесс упал во время обработки)
+func (k *IdempotencyKey) IsStale(now time.Time, ttl, lockTimeout time.Duration) bool {
+	if k.IsCompleted() {
+		return now.Sub(k.CreatedAt) > ttl
+	}
+
+	return now.Sub(k.CreatedAt) > lockTimeout
+}
+
+// CanReplay проверяет, что повтор пришёл с тем же телом и первый запрос уже завершён
+func (k *IdempotencyKey) CanReplay(requestHash string) error {
+	if k.RequestHash != requestHash {
+		return e.ErrIdempotencyKeyConflict
+	}
+
+	if !k.IsCompleted() {
+		return e.ErrIdempotencyKeyInProgress
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"strings"
+	"time"
+)
+
+// AnyModelVersion версия модели сопоставления, действующего для всех версий,
+// у которых нет собственного сопоставления этого класса
+const AnyModelVersion = "*"
+
+// maxModelVersionLength совпадает с размером столбцов model_version
+const maxModelVersionLength = 255
+
+// MLClassMapping сопоставление индекса класса модели с типом инструмента
+type MLClassMapping struct {
+	Id           int64
+	ModelVersion string
+	ClassIndex   int64
+	ToolTypeId   int64
+	CreatedAt    time.Time
+
+	ToolType *ToolType
+}
+
+func NewMLClassMapping(modelVersion string, classIndex, toolTypeId int64) (*MLClassMapping, error) {
+	modelVersion, err := ValidateModelVersion(modelVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if classIndex < 0 {
+		return nil, e.ErrMLClassIndexInvalid
+	}
+
+	return &MLClassMapping{
+		ModelVersion: modelVersion,
+		ClassIndex:   classIndex,
+		ToolTypeId:   toolTypeId,
+	}, nil
+}
+
+// ValidateModelVersion обрезает пробелы и проверяет идентификатор версии модели
+func ValidateModelVersion(modelVersion string) (string, error) {
+	modelVersion = strings.TrimSpace(modelVersion)
+	if modelVersion == "" || len(modelVersion) > maxModelVersionLength {
+		return "", e.ErrModelVersionInvalid
+	}
+
+	return modelVersion, nil
+}
+
+// ClassToToolType строит таблицу индекс класса -> тип инструмента для версии модели.
+// Сопоставление самой версии имеет приоритет над сопоставлением AnyModelVersion
+func ClassToToolType(mappings []*MLClassMapping, modelVersion *string) map[int64]int64 {
+	res := make(map[int64]int64, len(mappings))
+	for _, mapping := range mappings {
+		if mapping.ModelVersion == AnyModelVersion {
+			if _, exists := res[mapping.ClassIndex]; !exists {
+				res[mapping.ClassIndex] = mapping.ToolTypeId
+			}
+			continue
+		}
+
+		if modelVersion != nil && mapping.ModelVersion == *modelVersion {
+			res[mapping.ClassIndex] = mapping.ToolTypeId
+		}
+	}
+
+	return res
+}
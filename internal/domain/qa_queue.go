@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
+
+// QAQueueItem транзакция в очереди QA-проверки. Аудитор берёт элемент в работу (Claim) на ClaimExpiresAt,
+// чтобы двое не разбирали одну транзакцию; назначенный элемент (AssigneeId) может взять и закрыть только назначенный аудитор
+type QAQueueItem struct {
+	TransactionId  int64
+	EnqueuedAt     time.Time
+	AssigneeId     *int64
+	AssignedAt     *time.Time
+	ClaimedBy      *int64
+	ClaimExpiresAt *time.Time
+	// EscalatedAt момент, когда элемент превысил SLA и был эскалирован; nil — не эскалирован
+	EscalatedAt *time.Time
+
+	Transaction *Transaction
+	Assignee    *User
+	Claimer     *User
+}
+
+func NewQAQueueItem(transactionId int64, enqueuedAt time.Time) *QAQueueItem {
+	return &QAQueueItem{
+		TransactionId: transactionId,
+		EnqueuedAt:    enqueuedAt,
+	}
+}
+
+// IsClaimed сообщает, держит ли кто-то элемент в работе на момент now; истёкшая блокировка не учитывается
+func (i *QAQueueItem) IsClaimed(now time.Time) bool {
+	return i.ClaimedBy != nil && i.ClaimExpiresAt != nil && i.ClaimExpiresAt.After(now)
+}
+
+// Claim берёт элемент в работу аудитором qa до now+ttl. Повторный захват тем же аудитором продлевает блокировку
+func (i *QAQueueItem) Claim(qa *User, now time.Time, ttl time.Duration) error {
+	if i.AssigneeId != nil && *i.AssigneeId != qa.Id {
+		return e.ErrQAQueueItemAssigned
+	}
+
+	if i.IsClaimed(now) && *i.ClaimedBy != qa.Id {
+		return e.ErrQAQueueItemClaimed
+	}
+
+	expiresAt := now.Add(ttl)
+	i.ClaimedBy = &qa.Id
+	i.ClaimExpiresAt = &expiresAt
+	i.Claimer = qa
+
+	return nil
+}
+
+// Release снимает блокировку, взятую аудитором qa
+func (i *QAQueueItem) Release(qa *User, now time.Time) error {
+	if !i.IsClaimed(now) || *i.ClaimedBy != qa.Id {
+		return e.ErrQAQueueItemNotClaimed
+	}
+
+	i.ClaimedBy = nil
+	i.ClaimExpiresAt = nil
+	i.Claimer = nil
+
+	return nil
+}
+
+// Assign закрепляет элемент за аудитором assignee. Блокировка другого аудитора снимается,
+// так как дальше элемент может взять только назначенный
+func (i *QAQueueItem) Assign(assignee *User, now time.Time) {
+	i.AssigneeId = &assignee.Id
+	i.AssignedAt = &now
+	i.Assignee = assignee
+
+	if i.ClaimedBy != nil && *i.ClaimedBy != assignee.Id {
+		i.ClaimedBy = nil
+		i.ClaimExpiresAt = nil
+		i.Claimer = nil
+	}
+}
+
+// Unassign возвращает элемент в общую очередь
+func (i *QAQueueItem) Unassign() {
+	i.AssigneeId = nil
+	i.AssignedAt = nil
+	i.Assignee = nil
+}
+
+// CanBeResolvedBy проверяет, что решение по элементу принимает аудитор, за которым он закреплён или который держит его в работе.
+// Элемент, который никто не взял, может закрыть любой аудитор
+func (i *QAQueueItem) CanBeResolvedBy(qa *User, now time.Time) error {
+	if i.AssigneeId != nil && *i.AssigneeId != qa.Id {
+		return e.ErrQAQueueItemAssigned
+	}
+
+	if i.IsClaimed(now) && *i.ClaimedBy != qa.Id {
+		return e.ErrQAQueueItemClaimed
+	}
+
+	return nil
+}
+
+func (i *QAQueueItem) TimeInQueue(now time.Time) time.Duration {
+	return now.Sub(i.EnqueuedAt)
+}
+
+// IsOverdue сообщает, превысил ли элемент SLA на момент now
+func (i *QAQueueItem) IsOverdue(now time.Time, sla time.Duration) bool {
+	return i.TimeInQueue(now) > sla
+}
@@ -2,17 +2,77 @@ package domain
 
 // RecognizedTool описывает инструмент, который был распознан CV
 type RecognizedTool struct {
-	ToolTypeId int64
+	// ClassIndex индекс класса модели; ToolTypeId тип инструмента по ml_class_mappings, nil — класс не сопоставлен
+	ClassIndex int64
+	ToolTypeId *int64
 	Confidence float32
 	Embedding  []float32
 	Bbox       []float32
+
+	// ReferenceId эталон, давший наибольшее сходство; nil — основной эмбеддинг типа
+	ReferenceId      *int64
+	CosineSimilarity *float32
+	// Thresholds пороги, с которыми детекция сравнивалась при автоматической проверке; nil — тип не входит в набор
+	Thresholds *Thresholds
+	// SlotId гнездо ложемента, в котором лежит инструмент; nil — раскладка не задана или инструмент вне гнёзд
+	SlotId *int64
+	// CheckoutSimilarity косинусная близость к парной детекции того же типа на скане выдачи; nil — не сравнивалась
+	CheckoutSimilarity *float32
+}
+
+// Passes сообщает, прошла ли детекция автоматическую проверку с порогами thresholds
+func (r *RecognizedTool) Passes(thresholds Thresholds) bool {
+	return r.Confidence >= thresholds.Confidence && r.CosineSimilarity != nil && *r.CosineSimilarity >= thresholds.CosineSim
 }
 
-func NewRecognizedTool(toolTypeId int64, confidence float32, embedding, bbox []float32) *RecognizedTool {
+func NewRecognizedTool(classIndex int64, confidence float32, embedding, bbox []float32) *RecognizedTool {
 	return &RecognizedTool{
-		ToolTypeId: toolTypeId,
+		ClassIndex: classIndex,
 		Confidence: confidence,
 		Embedding:  embedding,
 		Bbox:       bbox,
 	}
 }
+
+// IsOfType сообщает, сопоставлена ли детекция с типом toolTypeId
+func (r *RecognizedTool) IsOfType(toolTypeId int64) bool {
+	return r.ToolTypeId != nil && *r.ToolTypeId == toolTypeId
+}
+
+// HasBbox сообщает, что рамка детекции задана в формате x1, y1, x2, y2
+func (r *RecognizedTool) HasBbox() bool {
+	return len(r.Bbox) == 4
+}
+
+// Center возвращает центр рамки детекции
+func (r *RecognizedTool) Center() Point {
+	return Point{
+		X: (r.Bbox[0] + r.Bbox[2]) / 2,
+		Y: (r.Bbox[1] + r.Bbox[3]) / 2,
+	}
+}
+
+// IoU возвращает отношение площади пересечения рамок к площади их объединения; без рамок — 0
+func (r *RecognizedTool) IoU(other *RecognizedTool) float32 {
+	if !r.HasBbox() || !other.HasBbox() {
+		return 0
+	}
+
+	width := min(r.Bbox[2], other.Bbox[2]) - max(r.Bbox[0], other.Bbox[0])
+	height := min(r.Bbox[3], other.Bbox[3]) - max(r.Bbox[1], other.Bbox[1])
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+
+	intersection := width * height
+	union := bboxArea(r.Bbox) + bboxArea(other.Bbox) - intersection
+	if union <= 0 {
+		return 0
+	}
+
+	return intersection / union
+}
+
+func bboxArea(bbox []float32) float32 {
+	return (bbox[2] - bbox[0]) * (bbox[3] - bbox[1])
+}
@@ -3,6 +3,8 @@ package domain
 const (
 	Engineer       string = "Engineer"
 	QualityAuditor string = "Quality Auditor"
+	// Kiosk учётная запись киоска выдачи: проверяет инструменты за инженера, предъявившего пропуск
+	Kiosk string = "Kiosk"
 )
 
 type Role struct {
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ScanJobStatus string
+
+const (
+	JobPending ScanJobStatus = "PENDING"
+	JobRunning ScanJobStatus = "RUNNING"
+	JobDone    ScanJobStatus = "DONE"
+	JobFailed  ScanJobStatus = "FAILED"
+)
+
+// ScanJob задание асинхронной проверки: загрузка фото, распознавание и сверка с набором выполняются воркером.
+// Состояние задания видит только пользователь, который его поставил (RequestedBy)
+type ScanJob struct {
+	Id        string
+	UserId    int64
+	ToolSetId int64
+	BadgeId   *int64
+	KioskId   *int64
+	// RequestedBy пользователь, поставивший задание: киоск, если проверка идёт по пропуску, иначе сам инженер
+	RequestedBy int64
+	// TransactionId и ScanId заполняются вместе с сохранением проверки; заполненный ScanId значит, что проверка уже выполнена
+	TransactionId *int64
+	ScanId        *int64
+	ImageData     string
+	Status        ScanJobStatus
+	Result        []byte
+	Error         *string
+	Attempts      int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+}
+
+func NewScanJob(userId int64, imageData string, toolSetId int64, badgeId, kioskId *int64) *ScanJob {
+	requestedBy := userId
+	if kioskId != nil {
+		requestedBy = *kioskId
+	}
+
+	return &ScanJob{
+		Id:          uuid.NewString(),
+		UserId:      userId,
+		ToolSetId:   toolSetId,
+		BadgeId:     badgeId,
+		KioskId:     kioskId,
+		RequestedBy: requestedBy,
+		ImageData:   imageData,
+		Status:      JobPending,
+	}
+}
+
+// IsRequestedBy сообщает, поставил ли задание пользователь userId
+func (j *ScanJob) IsRequestedBy(userId int64) bool {
+	return j.RequestedBy == userId
+}
+
+// RecordScan связывает задание с транзакцией и сканом, сохранёнными его проверкой
+func (j *ScanJob) RecordScan(transactionId, scanId int64) {
+	j.TransactionId = &transactionId
+	j.ScanId = &scanId
+}
+
+// IsChecked сообщает, сохранила ли одна из прошлых попыток результат проверки
+func (j *ScanJob) IsChecked() bool {
+	return j.ScanId != nil
+}
+
+func (j *ScanJob) IsFinished() bool {
+	return j.Status == JobDone || j.Status == JobFailed
+}
+
+// Complete сохраняет результат проверки. Фото больше не нужно и удаляется из задания
+func (j *ScanJob) Complete(result []byte, now time.Time) {
+	j.Status = JobDone
+	j.Result = result
+	j.ImageData = ""
+	j.FinishedAt = &now
+}
+
+// Fail завершает задание с ошибкой
+func (j *ScanJob) Fail(message string, now time.Time) {
+	j.Status = JobFailed
+	j.Error = &message
+	j.ImageData = ""
+	j.FinishedAt = &now
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// ShadowScan результат распознавания того же изображения теневой (кандидатной) моделью.
+// На результат для инженера не влияет: Status — статус, который получила бы транзакция,
+// если бы решение принимала теневая модель, PrimaryStatus — статус, выставленный по основной модели
+type ShadowScan struct {
+	Id            int64
+	CvScanId      int64
+	ModelVersion  *string
+	DebugImageUrl string
+	// Status nil, если вызов теневой модели завершился ошибкой
+	Status        *Status
+	PrimaryStatus Status
+	Error         *string
+	CreatedAt     time.Time
+
+	Detections []*ShadowScanDetail
+}
+
+// ShadowScanDetail детекция теневой модели; ToolTypeId nil, если класс не сопоставлен с типом инструмента
+type ShadowScanDetail struct {
+	Id           int64
+	ShadowScanId int64
+	ClassIndex   int64
+	ToolTypeId   *int64
+	Confidence   float32
+	Bbox         []float32
+}
+
+func NewShadowScan(cvScanId int64, primaryStatus Status) *ShadowScan {
+	return &ShadowScan{
+		CvScanId:      cvScanId,
+		PrimaryStatus: primaryStatus,
+	}
+}
+
+// Complete заполняет результат успешного распознавания теневой моделью
+func (s *ShadowScan) Complete(modelVersion *string, debugImageUrl string, status Status, tools []*RecognizedTool) {
+	s.ModelVersion = modelVersion
+	s.DebugImageUrl = debugImageUrl
+	s.Status = &status
+	s.Detections = make([]*ShadowScanDetail, len(tools))
+	for i, tool := range tools {
+		s.Detections[i] = &ShadowScanDetail{
+			ClassIndex: tool.ClassIndex,
+			ToolTypeId: tool.ToolTypeId,
+			Confidence: tool.Confidence,
+			Bbox:       tool.Bbox,
+		}
+	}
+}
+
+// Fail запоминает ошибку вызова теневой модели
+func (s *ShadowScan) Fail(err error) {
+	message := err.Error()
+	s.Error = &message
+}
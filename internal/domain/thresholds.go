@@ -0,0 +1,69 @@
+package domain
+
+import "airport-tools-backend/pkg/e"
+
+// Thresholds пороги автоматической проверки распознанного инструмента: детекция проходит проверку,
+// если уверенность модели и косинусная близость к эталону не ниже порогов, иначе уходит на ручную проверку
+type Thresholds struct {
+	Confidence float32
+	CosineSim  float32
+}
+
+// ThresholdsOverride пороги, заданные для типа инструмента или для типа в наборе; nil — порог наследуется
+type ThresholdsOverride struct {
+	Confidence *float32
+	CosineSim  *float32
+}
+
+// NewThresholdsOverride проверяет диапазоны: уверенность в [0, 1], косинусная близость в [-1, 1]
+func NewThresholdsOverride(confidence, cosineSim *float32) (*ThresholdsOverride, error) {
+	if confidence != nil && (*confidence < 0 || *confidence > 1) {
+		return nil, e.ErrThresholdInvalid
+	}
+
+	if cosineSim != nil && (*cosineSim < -1 || *cosineSim > 1) {
+		return nil, e.ErrThresholdInvalid
+	}
+
+	return &ThresholdsOverride{
+		Confidence: confidence,
+		CosineSim:  cosineSim,
+	}, nil
+}
+
+// IsEmpty сообщает, что ни один порог не переопределён
+func (o *ThresholdsOverride) IsEmpty() bool {
+	return o == nil || (o.Confidence == nil && o.CosineSim == nil)
+}
+
+// Apply возвращает base с порогами, переопределёнными в o
+func (o *ThresholdsOverride) Apply(base Thresholds) Thresholds {
+	if o == nil {
+		return base
+	}
+
+	if o.Confidence != nil {
+		base.Confidence = *o.Confidence
+	}
+	if o.CosineSim != nil {
+		base.CosineSim = *o.CosineSim
+	}
+
+	return base
+}
+
+// ToolSetThreshold пороги типа инструмента в наборе. Задаются для семейства версий набора,
+// поэтому редактирование состава набора их не сбрасывает
+type ToolSetThreshold struct {
+	FamilyId   int64
+	ToolTypeId int64
+	ThresholdsOverride
+}
+
+func NewToolSetThreshold(familyId, toolTypeId int64, override *ThresholdsOverride) *ToolSetThreshold {
+	return &ToolSetThreshold{
+		FamilyId:           familyId,
+		ToolTypeId:         toolTypeId,
+		ThresholdsOverride: *override,
+	}
+}
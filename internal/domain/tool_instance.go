@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
+
+type ToolInstanceStatus string
+
+const (
+	InService   ToolInstanceStatus = "IN_SERVICE"
+	Quarantined ToolInstanceStatus = "QUARANTINED"
+	Lost        ToolInstanceStatus = "LOST"
+	Scrapped    ToolInstanceStatus = "SCRAPPED"
+)
+
+// ToolInstance описывает конкретный физический экземпляр инструмента с серийным номером.
+// HomeToolSetId ссылается на семейство набора (FamilyId), поэтому экземпляр остаётся в наборе при выпуске новых версий
+type ToolInstance struct {
+	Id            int64
+	SerialNumber  string
+	ToolTypeId    int64
+	HomeToolSetId *int64
+	Status        ToolInstanceStatus
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	LastCalibratedAt *time.Time
+	CalibrationDueAt *time.Time
+
+	ToolType *ToolType
+}
+
+func NewToolInstance(serialNumber string, toolTypeId int64, homeToolSetId *int64) *ToolInstance {
+	return &ToolInstance{
+		SerialNumber:  serialNumber,
+		ToolTypeId:    toolTypeId,
+		HomeToolSetId: homeToolSetId,
+		Status:        InService,
+	}
+}
+
+func ValidateToolInstanceStatus(status string) (ToolInstanceStatus, error) {
+	switch ToolInstanceStatus(status) {
+	case InService, Quarantined, Lost, Scrapped:
+		return ToolInstanceStatus(status), nil
+	default:
+		return "", e.ErrToolInstanceStatusInvalid
+	}
+}
+
+// ChangeStatus переводит экземпляр в новый статус. Списанный экземпляр вернуть в оборот нельзя
+func (t *ToolInstance) ChangeStatus(status ToolInstanceStatus) error {
+	if t.Status == status {
+		return e.ErrNothingToChange
+	}
+
+	if t.Status == Scrapped {
+		return e.ErrToolInstanceScrapped
+	}
+
+	t.Status = status
+	return nil
+}
+
+// IsRegistered сообщает, числится ли экземпляр в своём наборе. Потерянный или списанный экземпляр место в наборе не занимает
+func (t *ToolInstance) IsRegistered() bool {
+	return t.Status != Lost && t.Status != Scrapped
+}
+
+// RequiresCalibration сообщает, подлежит ли экземпляр поверке: у его типа задана периодичность
+// или экземпляр уже поверялся с указанием срока
+func (t *ToolInstance) RequiresCalibration() bool {
+	if t.CalibrationDueAt != nil {
+		return true
+	}
+
+	return t.ToolType != nil && t.ToolType.CalibrationIntervalDays != nil
+}
+
+// IsCalibrationOverdue сообщает, просрочена ли поверка на момент now.
+// Экземпляр, подлежащий поверке, но ни разу не поверенный, считается просроченным
+func (t *ToolInstance) IsCalibrationOverdue(now time.Time) bool {
+	if !t.RequiresCalibration() {
+		return false
+	}
+
+	return t.CalibrationDueAt == nil || !now.Before(*t.CalibrationDueAt)
+}
+
+// ApplyCalibration фиксирует на экземпляре результат поверки. Поверка, внесённая задним числом раньше последней,
+// попадает только в журнал и не откатывает даты экземпляра
+func (t *ToolInstance) ApplyCalibration(event *CalibrationEvent) error {
+	if t.Status == Scrapped {
+		return e.ErrToolInstanceScrapped
+	}
+
+	if t.LastCalibratedAt != nil && event.CalibratedAt.Before(*t.LastCalibratedAt) {
+		return nil
+	}
+
+	calibratedAt, nextDueAt := event.CalibratedAt, event.NextDueAt
+	t.LastCalibratedAt = &calibratedAt
+	t.CalibrationDueAt = &nextDueAt
+	return nil
+}
+
+// CheckInstancesFitSet проверяет правило комплектности: экземпляров каждого типа, числящихся в наборе,
+// не больше, чем требует набор. Лишние экземпляры нужно списать, иначе было бы неясно, какие из них выдавать
+func CheckInstancesFitSet(set *ToolSet, registered []*ToolInstance) error {
+	quantities := set.Quantities()
+	counts := make(map[int64]int, len(quantities))
+	for _, instance := range registered {
+		if !instance.IsRegistered() {
+			continue
+		}
+
+		counts[instance.ToolTypeId]++
+		if counts[instance.ToolTypeId] > quantities[instance.ToolTypeId] {
+			return e.ErrToolInstancesExceedQuantity
+		}
+	}
+
+	return nil
+}
+
+// SelectInstancesForIssue подбирает экземпляры, которые будут выданы по набору.
+// Типы, для которых в наборе не зарегистрировано ни одного экземпляра, учитываются только на уровне типа.
+// По правилу комплектности (CheckInstancesFitSet) числящихся экземпляров не больше требуемого количества,
+// поэтому выдаются все они, а если исправных и свободных меньше требуемого количества, набор выдать нельзя.
+// Экземпляры с просроченной поверкой не выдаются: если без них количество не набирается, возвращается ErrToolCalibrationOverdue
+func SelectInstancesForIssue(set *ToolSet, registered []*ToolInstance, issuedIds map[int64]bool, now time.Time) ([]*ToolInstance, error) {
+	if err := CheckInstancesFitSet(set, registered); err != nil {
+		return nil, err
+	}
+
+	byType := make(map[int64][]*ToolInstance)
+	for _, instance := range registered {
+		byType[instance.ToolTypeId] = append(byType[instance.ToolTypeId], instance)
+	}
+
+	selected := make([]*ToolInstance, 0, len(registered))
+	for toolTypeId, quantity := range set.Quantities() {
+		instances, ok := byType[toolTypeId]
+		if !ok {
+			continue
+		}
+
+		available := make([]*ToolInstance, 0, len(instances))
+		overdue := 0
+		for _, instance := range instances {
+			if instance.Status != InService || issuedIds[instance.Id] {
+				continue
+			}
+
+			if instance.IsCalibrationOverdue(now) {
+				overdue++
+				continue
+			}
+
+			available = append(available, instance)
+		}
+
+		if len(available) < quantity {
+			if len(available)+overdue >= quantity {
+				return nil, e.ErrToolCalibrationOverdue
+			}
+			return nil, e.ErrToolInstancesUnavailable
+		}
+
+		selected = append(selected, available...)
+	}
+
+	return selected, nil
+}
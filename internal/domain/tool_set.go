@@ -1,14 +1,156 @@
 package domain
 
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
+
+// ToolSet описывает версию набора инструментов. Все версии одного набора имеют общий FamilyId,
+// редактирование набора создаёт новую версию, а транзакции продолжают ссылаться на ту версию, по которой была выдача
 type ToolSet struct {
-	Id   int64
-	Name string
+	Id        int64
+	FamilyId  int64
+	Version   int
+	Name      string
+	RetiredAt *time.Time
+	CreatedAt time.Time
 
 	Tools []*ToolType
+	Items []*ToolSetItem
+	// Thresholds пороги типов инструментов, переопределённые для семейства набора
+	Thresholds []*ToolSetThreshold
+	// Slots раскладка ложемента семейства набора; без гнёзд расположение инструментов не проверяется
+	Slots []*BoardSlot
+}
+
+// ToolSetItem позиция набора: тип инструмента и требуемое количество экземпляров
+type ToolSetItem struct {
+	ToolTypeId int64
+	Quantity   int
+}
+
+func NewToolSetItem(toolTypeId int64, quantity int) *ToolSetItem {
+	return &ToolSetItem{
+		ToolTypeId: toolTypeId,
+		Quantity:   quantity,
+	}
+}
+
+// ItemsFromToolIds собирает позиции набора из списка идентификаторов типов: повтор идентификатора увеличивает количество
+func ItemsFromToolIds(toolsIds []int64) []*ToolSetItem {
+	items := make([]*ToolSetItem, 0, len(toolsIds))
+	index := make(map[int64]*ToolSetItem, len(toolsIds))
+	for _, id := range toolsIds {
+		if item, ok := index[id]; ok {
+			item.Quantity++
+			continue
+		}
+
+		item := NewToolSetItem(id, 1)
+		index[id] = item
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// Quantities возвращает требуемое количество экземпляров по каждому типу инструмента.
+// Для типов без явной позиции считается, что нужен один экземпляр
+func (t *ToolSet) Quantities() map[int64]int {
+	quantities := make(map[int64]int, len(t.Tools))
+	for _, tool := range t.Tools {
+		quantities[tool.Id] = 1
+	}
+
+	for _, item := range t.Items {
+		quantities[item.ToolTypeId] = item.Quantity
+	}
+
+	return quantities
+}
+
+// ThresholdsFor возвращает пороги автоматической проверки типа инструмента в наборе.
+// Порог набора важнее порога типа, порог типа важнее глобального defaults; каждый порог наследуется отдельно
+func (t *ToolSet) ThresholdsFor(toolType *ToolType, defaults Thresholds) Thresholds {
+	thresholds := toolType.Thresholds.Apply(defaults)
+	for _, override := range t.Thresholds {
+		if override.ToolTypeId == toolType.Id {
+			thresholds = override.ThresholdsOverride.Apply(thresholds)
+		}
+	}
+
+	return thresholds
+}
+
+// MinConfidence наименьший порог уверенности среди типов набора: ML-сервис должен вернуть все детекции,
+// которые могут пройти автоматическую проверку хотя бы одного типа
+func (t *ToolSet) MinConfidence(defaults Thresholds) float32 {
+	minConfidence := defaults.Confidence
+	for _, tool := range t.Tools {
+		if confidence := t.ThresholdsFor(tool, defaults).Confidence; confidence < minConfidence {
+			minConfidence = confidence
+		}
+	}
+
+	return minConfidence
+}
+
+// HasToolType сообщает, входит ли тип инструмента в набор
+func (t *ToolSet) HasToolType(toolTypeId int64) bool {
+	for _, tool := range t.Tools {
+		if tool.Id == toolTypeId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TotalQuantity возвращает общее количество инструментов в наборе с учётом количества по позициям
+func (t *ToolSet) TotalQuantity() int {
+	total := 0
+	for _, quantity := range t.Quantities() {
+		total += quantity
+	}
+
+	return total
 }
 
 func NewToolSet(name string) *ToolSet {
 	return &ToolSet{
-		Name: name,
+		Name:    name,
+		Version: 1,
 	}
 }
+
+// NewVersion создаёт следующую версию набора с новым названием
+func (t *ToolSet) NewVersion(name string) *ToolSet {
+	return &ToolSet{
+		FamilyId: t.FamilyId,
+		Version:  t.Version + 1,
+		Name:     name,
+	}
+}
+
+func (t *ToolSet) IsRetired() bool {
+	return t.RetiredAt != nil
+}
+
+// CanBeIssued проверяет, что по версии набора можно выдавать инструменты
+func (t *ToolSet) CanBeIssued() error {
+	if t.IsRetired() {
+		return e.ErrToolSetRetired
+	}
+
+	return nil
+}
+
+// Retire выводит версию набора из оборота. Версия остаётся доступной для чтения
+func (t *ToolSet) Retire(at time.Time) error {
+	if t.IsRetired() {
+		return e.ErrNothingToChange
+	}
+
+	t.RetiredAt = &at
+	return nil
+}
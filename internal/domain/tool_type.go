@@ -2,12 +2,20 @@ package domain
 
 import "airport-tools-backend/pkg/e"
 
+// EmbeddingSize размерность эмбеддинга, совпадает со столбцом VECTOR(1280)
+const EmbeddingSize = 1280
+
 type ToolType struct {
 	Id                 int64
 	PartNumber         string
 	Name               string
 	ReferenceEmbedding []float32
+	// CalibrationIntervalDays периодичность поверки в днях; nil — тип не требует поверки
+	CalibrationIntervalDays *int
+	// Thresholds пороги автоматической проверки типа; непереопределённые пороги берутся из глобальных настроек
+	Thresholds ThresholdsOverride
 
+	References             []*ToolTypeReference
 	ToolSets               []*ToolSet
 	TransactionResolutions []*TransactionResolution
 }
@@ -27,3 +35,32 @@ func (t *ToolType) ValidateName(newName string) error {
 
 	return nil
 }
+
+// SetCalibrationInterval задаёт периодичность поверки; nil отключает контроль поверки для типа
+func (t *ToolType) SetCalibrationInterval(days *int) error {
+	if days != nil && *days <= 0 {
+		return e.ErrCalibrationIntervalInvalid
+	}
+
+	if (t.CalibrationIntervalDays == nil && days == nil) ||
+		(t.CalibrationIntervalDays != nil && days != nil && *t.CalibrationIntervalDays == *days) {
+		return e.ErrNothingToChange
+	}
+
+	t.CalibrationIntervalDays = days
+	return nil
+}
+
+// SetThresholds задаёт пороги автоматической проверки типа; nil возвращает глобальный порог
+func (t *ToolType) SetThresholds(override *ThresholdsOverride) {
+	t.Thresholds = *override
+}
+
+// ValidateReferenceEmbedding проверяет, что эталонный эмбеддинг совпадает по размерности с хранилищем
+func ValidateReferenceEmbedding(embedding []float32) error {
+	if len(embedding) != EmbeddingSize {
+		return e.ErrInvalidEmbeddingSize
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// ToolTypeReference дополнительный эталонный эмбеддинг типа инструмента (например, другой ракурс или состояние инструмента)
+type ToolTypeReference struct {
+	Id         int64
+	ToolTypeId int64
+	Label      string
+	Embedding  []float32
+	CreatedAt  time.Time
+}
+
+func NewToolTypeReference(toolTypeId int64, label string, embedding []float32) (*ToolTypeReference, error) {
+	if err := ValidateReferenceEmbedding(embedding); err != nil {
+		return nil, err
+	}
+
+	return &ToolTypeReference{
+		ToolTypeId: toolTypeId,
+		Label:      label,
+		Embedding:  embedding,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"airport-tools-backend/pkg/e"
+	"math"
+	"time"
+)
+
+// ToolTypeSample описывает эталонную фотографию инструмента и эмбеддинг, полученный по ней от ML-сервиса
+type ToolTypeSample struct {
+	Id         int64
+	ToolTypeId int64
+	ImageUrl   string
+	Confidence float32
+	Embedding  []float32
+	CreatedAt  time.Time
+}
+
+func NewToolTypeSample(toolTypeId int64, imageUrl string, confidence float32, embedding []float32) *ToolTypeSample {
+	return &ToolTypeSample{
+		ToolTypeId: toolTypeId,
+		ImageUrl:   imageUrl,
+		Confidence: confidence,
+		Embedding:  embedding,
+	}
+}
+
+// AverageEmbedding усредняет нормализованные эмбеддинги образцов и нормализует результат
+func AverageEmbedding(samples []*ToolTypeSample) ([]float32, error) {
+	sum := make([]float64, EmbeddingSize)
+	count := 0
+	for _, sample := range samples {
+		if err := ValidateReferenceEmbedding(sample.Embedding); err != nil {
+			continue
+		}
+
+		norm := l2Norm(sample.Embedding)
+		if norm == 0 {
+			continue
+		}
+
+		for i, v := range sample.Embedding {
+			sum[i] += float64(v) / norm
+		}
+		count++
+	}
+
+	if count == 0 {
+		return nil, e.ErrToolTypeNoSamples
+	}
+
+	var sqSum float64
+	for _, v := range sum {
+		sqSum += v * v
+	}
+	norm := math.Sqrt(sqSum)
+	if norm == 0 {
+		return nil, e.ErrToolTypeNoSamples
+	}
+
+	res := make([]float32, EmbeddingSize)
+	for i, v := range sum {
+		res[i] = float32(v / norm)
+	}
+
+	return res, nil
+}
+
+func l2Norm(vector []float32) float64 {
+	var sqSum float64
+	for _, v := range vector {
+		sqSum += float64(v) * float64(v)
+	}
+
+	return math.Sqrt(sqSum)
+}
@@ -17,8 +17,10 @@ const (
 type Transaction struct {
 	Id            int64
 	UserId        int64 // Received в UI, у кого инструмент
-	ToolSetId     int64
+	ToolSetId     int64 // версия набора, по которой выданы инструменты
 	CountOfChecks int64
+	BadgeId       *int64 // пропуск, по которому была выполнена последняя проверка
+	KioskId       *int64 // киоск, через который была выполнена последняя проверка
 	Status        Status
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -55,6 +57,14 @@ func (t *Transaction) EvaluateStatus(manualCheckCount, unknownCount, missingCoun
 	t.Status = status
 }
 
+// EscalateSubstitution отправляет сдачу на QA, если среди сданных инструментов есть возможно подменённые:
+// повторная съёмка этого не исправит, решение принимает аудитор
+func (t *Transaction) EscalateSubstitution(possiblySubstitutedCount int) {
+	if possiblySubstitutedCount > 0 {
+		t.Status = QA
+	}
+}
+
 func (t *Transaction) CheckCountOfChecks() error {
 	if t.CountOfChecks >= 3 {
 		return e.ErrTransactionLimit
@@ -63,6 +73,20 @@ func (t *Transaction) CheckCountOfChecks() error {
 	return nil
 }
 
+// CanBeVerifiedBy проверяет, что QA-проверку выполняет аудитор качества,
+// который сам не является инженером по этой транзакции (принцип четырёх глаз)
+func (t *Transaction) CanBeVerifiedBy(qa *User) error {
+	if !qa.HasRole(QualityAuditor) {
+		return e.ErrUserNotQualityAuditor
+	}
+
+	if t.UserId == qa.Id {
+		return e.ErrVerificationSelfReview
+	}
+
+	return nil
+}
+
 func ValidateStatus(status string) (Status, error) {
 	switch status {
 	case string(OPEN):
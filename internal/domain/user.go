@@ -1,26 +1,68 @@
 package domain
 
-import "airport-tools-backend/pkg/e"
+import (
+	"airport-tools-backend/pkg/e"
+	"time"
+)
 
 type User struct {
-	Id         int64
-	EmployeeId string
-	FullName   string
-	RoleId     int64
+	Id           int64
+	EmployeeId   string
+	FullName     string
+	RoleId       int64
+	PasswordHash string
+	// MustChangePin PIN задан администратором и должен быть заменён при следующем входе
+	MustChangePin       bool
+	FailedLoginAttempts int
+	// LoginLockedUntil до этого момента вход отклоняется после серии неверных PIN-кодов
+	LoginLockedUntil *time.Time
+	// TokenVersion увеличивается при смене PIN; токены с прежней версией недействительны
+	TokenVersion int
 
 	Role                   *Role
 	Transactions           []*Transaction
 	TransactionResolutions []*TransactionResolution
 }
 
-func NewUser(fullName, employeeId string, roleId int64) *User {
+func NewUser(fullName, employeeId string, roleId int64, passwordHash string) *User {
 	return &User{
-		FullName:   fullName,
-		EmployeeId: employeeId,
-		RoleId:     roleId,
+		FullName:     fullName,
+		EmployeeId:   employeeId,
+		RoleId:       roleId,
+		PasswordHash: passwordHash,
 	}
 }
 
+// IsLoginLocked проверяет, что вход временно заблокирован после серии неверных PIN-кодов
+func (u *User) IsLoginLocked(now time.Time) bool {
+	return u.LoginLockedUntil != nil && now.Before(*u.LoginLockedUntil)
+}
+
+// SetPin заменяет PIN, снимает блокировку входа и отзывает выданные ранее токены.
+// mustChange требует заменить PIN при следующем входе
+func (u *User) SetPin(passwordHash string, mustChange bool) {
+	u.PasswordHash = passwordHash
+	u.MustChangePin = mustChange
+	u.FailedLoginAttempts = 0
+	u.LoginLockedUntil = nil
+	u.TokenVersion++
+}
+
+// HasRole проверяет, что у пользователя одна из переданных ролей
+func (u *User) HasRole(roles ...string) bool {
+	if u.Role == nil {
+		return false
+	}
+
+	for _, role := range roles {
+		if u.Role.Name == role {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (u *User) CanCheckout() error {
 	if len(u.Transactions) == 0 {
 		return nil
@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"
+	CircuitOpen     CircuitState = usecase.MLCircuitOpen
+	CircuitHalfOpen CircuitState = "HALF_OPEN"
+)
+
+// CircuitBreaker размыкается после threshold подряд неудачных вызовов и в течение cooldown
+// сразу отклоняет запросы. По истечении cooldown пропускает один пробный вызов
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		state:     CircuitClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow разрешает вызов или возвращает ErrMLServiceUnavailable, если цепь разомкнута
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitClosed {
+		return nil
+	}
+
+	// в полуоткрытом состоянии пробный вызов уже выполняется; если он так и не завершился
+	// (например, запрос отменил клиент), через cooldown разрешается следующий
+	if time.Since(b.openedAt) < b.cooldown {
+		return e.ErrMLServiceUnavailable
+	}
+
+	b.state = CircuitHalfOpen
+	b.openedAt = time.Now()
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
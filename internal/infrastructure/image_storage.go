@@ -47,3 +47,14 @@ func (i *ImageStorage) UploadImage(ctx context.Context, req *usecase.UploadImage
 
 	return usecase.NewUploadImageRes(image.Key, image.ImageUrl), nil
 }
+
+// DeleteImage удаляет загруженное изображение по ключу, который вернул UploadImage
+func (i *ImageStorage) DeleteImage(ctx context.Context, key string) error {
+	const op = "ImageStorage.DeleteImage"
+
+	if err := i.imageRepo.Delete(ctx, key); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package infrastructure
+
+import (
+	"airport-tools-backend/internal/config"
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DebugImages string = "debug_images"
+)
+
+// ResilientMLGateway шлюз ML-сервиса с фоновой проверкой доступности
+type ResilientMLGateway interface {
+	usecase.MLGateway
+	// RunHealthProbe периодически проверяет доступность ML-сервиса до отмены ctx
+	RunHealthProbe(ctx context.Context)
+	Close() error
+}
+
+// NewMLGatewayFromConfig создаёт шлюз ML-сервиса с транспортом, выбранным в конфигурации (HTTP или gRPC)
+func NewMLGatewayFromConfig(cfg config.ML, s3 usecase.ImageStorage) (ResilientMLGateway, error) {
+	if cfg.Protocol == config.MLProtocolGrpc {
+		return NewMlGrpcGateway(cfg, s3)
+	}
+
+	return NewMlGateway(&http.Client{}, cfg, s3), nil
+}
+
+// mlDetection детекция ML-сервиса в независимом от транспорта виде
+type mlDetection struct {
+	Bbox       []float32
+	Class      int64
+	Confidence float32
+	Embedding  []float32
+}
+
+// mlPrediction ответ ML-сервиса в независимом от транспорта виде; DebugImage в base64.
+// ModelVersion пуст, если сервис не сообщает версию модели в ответе
+type mlPrediction struct {
+	Detections   []mlDetection
+	DebugImage   string
+	ModelVersion string
+}
+
+// retryableError ошибка попытки, после которой вызов имеет смысл повторить
+type retryableError struct {
+	err error
+}
+
+func (r *retryableError) Error() string {
+	return r.err.Error()
+}
+
+func (r *retryableError) Unwrap() error {
+	return r.err
+}
+
+// mlClient общая для всех транспортов часть шлюза: каждая попытка ограничена таймаутом,
+// повторяемые ошибки повторяются с экспоненциальной задержкой, а после серии неудач вызовы
+// отклоняются circuit breaker'ом без обращения к сервису
+type mlClient struct {
+	cfg     config.ML
+	s3      usecase.ImageStorage
+	breaker *CircuitBreaker
+
+	healthMu sync.RWMutex
+	health   usecase.MLHealth
+}
+
+func newMlClient(cfg config.ML, s3 usecase.ImageStorage) *mlClient {
+	return &mlClient{
+		cfg:     cfg,
+		s3:      s3,
+		breaker: NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+// scan выполняет попытки predict с учётом circuit breaker'а, загружает отладочное изображение
+// и возвращает распознанные инструменты
+func (c *mlClient) scan(ctx context.Context, op string, predict func(ctx context.Context) (*mlPrediction, error)) (*usecase.ScanResult, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	prediction, err := c.predictWithRetry(ctx, predict)
+	if err != nil {
+		// запрос отменён вызывающей стороной: о состоянии сервиса это ничего не говорит
+		if ctx.Err() != nil {
+			return nil, e.Wrap(op, err)
+		}
+
+		var retryable *retryableError
+		if errors.As(err, &retryable) {
+			c.breaker.Failure()
+			return nil, e.Wrap(op, fmt.Errorf("%w: %v", e.ErrMLServiceUnavailable, err))
+		}
+
+		// сервис ответил, пусть и ошибкой запроса: на состояние цепи это не влияет
+		c.breaker.Success()
+		return nil, e.Wrap(op, err)
+	}
+	c.breaker.Success()
+
+	uplImageReq := usecase.NewUploadImageReq(prediction.DebugImage, DebugImages)
+	uploadImageRes, err := c.s3.UploadImage(ctx, uplImageReq)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	var scanResult usecase.ScanResult
+	scanResult.DebugImageUrl = uploadImageRes.ImageUrl
+	scanResult.ModelVersion = c.Health().ModelVersion
+	if prediction.ModelVersion != "" {
+		scanResult.ModelVersion = &prediction.ModelVersion
+	}
+	for _, detection := range prediction.Detections {
+		recognizedTool := domain.NewRecognizedTool(detection.Class, detection.Confidence, detection.Embedding, detection.Bbox)
+		scanResult.Tools = append(scanResult.Tools, recognizedTool)
+	}
+
+	return &scanResult, nil
+}
+
+// predictWithRetry выполняет попытки с собственным таймаутом, повторяя их при повторяемых ошибках
+func (c *mlClient) predictWithRetry(ctx context.Context, predict func(ctx context.Context) (*mlPrediction, error)) (*mlPrediction, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay(attempt)):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		prediction, err := predict(attemptCtx)
+		cancel()
+		if err == nil {
+			return prediction, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		lastErr = err
+		log.Printf("ml service attempt %d/%d failed: %v", attempt+1, c.cfg.MaxRetries+1, err)
+	}
+
+	return nil, lastErr
+}
+
+// retryDelay экспоненциальная задержка перед повтором с равномерным jitter, чтобы киоски не повторяли запросы синхронно
+func (c *mlClient) retryDelay(attempt int) time.Duration {
+	backoff := c.cfg.RetryBaseDelay << (attempt - 1)
+	return backoff + rand.N(c.cfg.RetryBaseDelay)
+}
+
+// runHealthProbe вызывает check каждые HealthInterval до отмены ctx и запоминает результат.
+// check возвращает версию загруженной модели, если сервис её сообщает
+func (c *mlClient) runHealthProbe(ctx context.Context, check func(ctx context.Context) (string, error)) {
+	ticker := time.NewTicker(c.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		c.probe(ctx, check)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *mlClient) probe(ctx context.Context, check func(ctx context.Context) (string, error)) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	modelVersion, probeErr := check(probeCtx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	c.healthMu.Lock()
+	defer c.healthMu.Unlock()
+
+	c.health.Healthy = probeErr == nil
+	c.health.LastCheckedAt = &now
+	c.health.LastError = nil
+	if modelVersion != "" {
+		c.health.ModelVersion = &modelVersion
+	}
+	if probeErr != nil {
+		message := probeErr.Error()
+		c.health.LastError = &message
+	}
+}
+
+// Health возвращает результат последней проверки доступности и состояние circuit breaker'а
+func (c *mlClient) Health() *usecase.MLHealth {
+	c.healthMu.RLock()
+	health := c.health
+	c.healthMu.RUnlock()
+
+	health.CircuitState = string(c.breaker.State())
+	return &health
+}
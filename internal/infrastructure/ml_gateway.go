@@ -1,32 +1,32 @@
 package infrastructure
 
 import (
-	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/config"
 	"airport-tools-backend/internal/usecase"
 	"airport-tools-backend/pkg/e"
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 )
 
-const (
-	DebugImages string = "debug_images"
-)
-
-// MlGateway клиент для взаимодействия с ML-сервисом распознавания инструментов
+// MlGateway HTTP/JSON клиент ML-сервиса распознавания инструментов
 type MlGateway struct {
+	*mlClient
 	client  *http.Client
 	baseUrl string
-	s3      usecase.ImageStorage
 }
 
-func NewMlGateway(client *http.Client, baseUrl string, s3 usecase.ImageStorage) *MlGateway {
+func NewMlGateway(client *http.Client, cfg config.ML, s3 usecase.ImageStorage) *MlGateway {
 	return &MlGateway{
-		client:  client,
-		baseUrl: baseUrl,
-		s3:      s3,
+		mlClient: newMlClient(cfg, s3),
+		client:   client,
+		baseUrl:  cfg.Url,
 	}
 }
 
@@ -35,57 +35,184 @@ type mlAPIResponse struct {
 	ImageId     string `json:"image_id"`
 	Instruments []struct {
 		Bbox       []float32 `json:"bbox"`
-		ToolTypeId int64     `json:"class"`
+		Class      int64     `json:"class"`
 		Confidence float32   `json:"confidence"`
 		Embedding  []float32 `json:"embedding"`
 	} `json:"instruments"`
-	DebugImage string `json:"debug_image"`
+	DebugImage   string `json:"debug_image"`
+	ModelVersion string `json:"model_version"`
+}
+
+// mlVersionResponse структура для декодирования ответа /version
+type mlVersionResponse struct {
+	ModelVersion string `json:"model_version"`
 }
 
 // ScanTools отправляет изображение на ML-сервис и возвращает распознанные инструменты
 func (ml *MlGateway) ScanTools(ctx context.Context, req *usecase.ScanRequest) (*usecase.ScanResult, error) {
 	const op = "MlGateway.ScanTools"
 
-	getUrl := fmt.Sprintf("%s/predict/?image_id=%s&url=%s&thresh=%s",
-		ml.baseUrl,
-		url.QueryEscape(req.ImageId),
-		url.QueryEscape(req.ImageUrl),
-		url.QueryEscape(fmt.Sprintf("%f", req.Threshold)),
-	)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, getUrl, nil)
+	newRequest, err := ml.predictRequestBuilder(req)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
+	return ml.scan(ctx, op, func(ctx context.Context) (*mlPrediction, error) {
+		return ml.predict(ctx, newRequest)
+	})
+}
+
+// predictRequestBuilder возвращает функцию, собирающую запрос распознавания в режиме передачи из конфигурации.
+// Запрос собирается заново на каждую попытку, так как тело запроса вычитывается при отправке
+func (ml *MlGateway) predictRequestBuilder(req *usecase.ScanRequest) (func(ctx context.Context) (*http.Request, error), error) {
+	query := url.Values{}
+	query.Set("image_id", req.ImageId)
+	query.Set("thresh", fmt.Sprintf("%f", req.Threshold))
+
+	if ml.cfg.Transport != config.MLTransportUpload {
+		query.Set("url", req.ImageUrl)
+		getUrl := fmt.Sprintf("%s/predict/?%s", ml.baseUrl, query.Encode())
+
+		return func(ctx context.Context) (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodGet, getUrl, nil)
+		}, nil
+	}
+
+	imgBytes, err := base64.StdEncoding.DecodeString(req.ImageData)
+	if err != nil {
+		return nil, e.ErrIncorrectImage
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", req.ImageId+".jpg")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(imgBytes); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	postUrl := fmt.Sprintf("%s/predict/?%s", ml.baseUrl, query.Encode())
+	payload := body.Bytes()
+	contentType := writer.FormDataContentType()
+
+	return func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, postUrl, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+
+		return httpReq, nil
+	}, nil
+}
+
+// predict выполняет одну попытку запроса; сетевые ошибки, таймаут и 5xx помечаются как повторяемые
+func (ml *MlGateway) predict(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (*mlPrediction, error) {
+	httpReq, err := newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := ml.client.Do(httpReq)
 	if err != nil {
-		return nil, e.Wrap(op, err)
+		return nil, &retryableError{err: err}
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode >= http.StatusInternalServerError {
+		io.Copy(io.Discard, res.Body)
+		return nil, &retryableError{err: fmt.Errorf("%w: %d", e.ErrMLServiceNonOK, res.StatusCode)}
+	}
+
 	if res.StatusCode != http.StatusOK {
-		return nil, e.Wrap(op, fmt.Errorf("%s: %d", e.ErrMLServiceNonOK, res.StatusCode))
+		return nil, fmt.Errorf("%w: %d", e.ErrMLServiceNonOK, res.StatusCode)
 	}
 
 	var apiResp mlAPIResponse
 	decoder := json.NewDecoder(res.Body)
 	if err := decoder.Decode(&apiResp); err != nil {
-		return nil, e.Wrap(op, fmt.Errorf("%s: %w", e.ErrMLServiceDecode, err))
+		// тело не дочитано до истечения таймаута попытки
+		if ctx.Err() != nil {
+			return nil, &retryableError{err: err}
+		}
+		return nil, fmt.Errorf("%w: %v", e.ErrMLServiceDecode, err)
+	}
+
+	prediction := &mlPrediction{
+		Detections:   make([]mlDetection, 0, len(apiResp.Instruments)),
+		DebugImage:   apiResp.DebugImage,
+		ModelVersion: apiResp.ModelVersion,
 	}
+	for _, instrument := range apiResp.Instruments {
+		prediction.Detections = append(prediction.Detections, mlDetection{
+			Bbox:       instrument.Bbox,
+			Class:      instrument.Class,
+			Confidence: instrument.Confidence,
+			Embedding:  instrument.Embedding,
+		})
+	}
+
+	return prediction, nil
+}
 
-	uplImageReq := usecase.NewUploadImageReq(apiResp.DebugImage, DebugImages)
-	uploadImageRes, err := ml.s3.UploadImage(ctx, uplImageReq)
+// RunHealthProbe периодически проверяет доступность ML-сервиса до отмены ctx
+func (ml *MlGateway) RunHealthProbe(ctx context.Context) {
+	ml.runHealthProbe(ctx, ml.checkHealth)
+}
+
+// checkHealth проверяет доступность сервиса и запрашивает версию модели.
+// Эндпоинт /version необязателен: если его нет, версия берётся только из ответов на распознавание
+func (ml *MlGateway) checkHealth(ctx context.Context) (string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, ml.cfg.HealthUrl, nil)
 	if err != nil {
-		return nil, e.Wrap(op, err)
+		return "", err
 	}
 
-	var scanResult usecase.ScanResult
-	scanResult.DebugImageUrl = uploadImageRes.ImageUrl
-	for _, instrument := range apiResp.Instruments {
-		recognizedTool := domain.NewRecognizedTool(instrument.ToolTypeId+1, instrument.Confidence, instrument.Embedding, instrument.Bbox)
-		scanResult.Tools = append(scanResult.Tools, recognizedTool)
+	res, err := ml.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %d", e.ErrMLServiceNonOK, res.StatusCode)
 	}
 
-	return &scanResult, nil
+	return ml.fetchModelVersion(ctx), nil
+}
+
+func (ml *MlGateway) fetchModelVersion(ctx context.Context) string {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, ml.baseUrl+"/version", nil)
+	if err != nil {
+		return ""
+	}
+
+	res, err := ml.client.Do(httpReq)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, res.Body)
+		return ""
+	}
+
+	var versionResp mlVersionResponse
+	if err := json.NewDecoder(res.Body).Decode(&versionResp); err != nil {
+		return ""
+	}
+
+	return versionResp.ModelVersion
+}
+
+// Close у HTTP-клиента нет долгоживущих соединений, требующих явного закрытия
+func (ml *MlGateway) Close() error {
+	return nil
 }
@@ -0,0 +1,321 @@
+package infrastructure_test
+
+import (
+	mlv1 "airport-tools-backend/api/ml/v1"
+	"airport-tools-backend/internal/config"
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/infrastructure"
+	"airport-tools-backend/internal/mlstub"
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	stubModelVersion         = "mlstub-contract"
+	contractBreakerThreshold = 2
+)
+
+// stubClasses классы, на которые заглушка возвращает детекции; 17 проверяет, что номер класса не путается с позицией детекции
+var stubClasses = []int64{0, 5, 17}
+
+// faultInjector считает обращения к распознаванию и отвечает ошибкой недоступности на первые failures из них
+type faultInjector struct {
+	attempts atomic.Int32
+	failures atomic.Int32
+}
+
+func (f *faultInjector) fail() bool {
+	f.attempts.Add(1)
+	for {
+		left := f.failures.Load()
+		if left <= 0 {
+			return false
+		}
+		if f.failures.CompareAndSwap(left, left-1) {
+			return true
+		}
+	}
+}
+
+type stubImageStorage struct{}
+
+func (stubImageStorage) UploadImage(ctx context.Context, req *usecase.UploadImageReq) (*usecase.UploadImageRes, error) {
+	return &usecase.UploadImageRes{Key: "debug", ImageUrl: "http://storage.test/debug.jpg"}, nil
+}
+
+func (stubImageStorage) DeleteImage(ctx context.Context, key string) error {
+	return nil
+}
+
+// startHttpStub поднимает HTTP API заглушки; повторяемая ошибка транспорта — 503
+func startHttpStub(t *testing.T, faults *faultInjector) config.ML {
+	stub := mlstub.NewStub(stubClasses, stubModelVersion).HTTPHandler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/predict") && faults.fail() {
+			http.Error(w, "injected failure", http.StatusServiceUnavailable)
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return config.ML{
+		Protocol:  config.MLProtocolHttp,
+		Url:       server.URL,
+		HealthUrl: server.URL + "/health",
+	}
+}
+
+// startGrpcStub поднимает gRPC API заглушки вместе с grpc.health.v1; повторяемая ошибка транспорта — Unavailable
+func startGrpcStub(t *testing.T, faults *faultInjector) config.ML {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if info.FullMethod == mlv1.Detector_Predict_FullMethodName && faults.fail() {
+			return nil, status.Error(codes.Unavailable, "injected failure")
+		}
+		return handler(ctx, req)
+	}))
+	mlv1.RegisterDetectorServer(server, mlstub.NewStub(stubClasses, stubModelVersion))
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(mlv1.Detector_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return config.ML{
+		Protocol: config.MLProtocolGrpc,
+		GrpcAddr: listener.Addr().String(),
+	}
+}
+
+func newScanRequest() *usecase.ScanRequest {
+	return &usecase.ScanRequest{
+		ImageId:   "contract",
+		ImageUrl:  "http://storage.test/source.jpg",
+		ImageData: base64.StdEncoding.EncodeToString([]byte("image")),
+		Threshold: 0.5,
+	}
+}
+
+// TestMLGatewayContract прогоняет HTTP- и gRPC-шлюз через одинаковые сценарии против заглушки ML-сервиса:
+// оба транспорта должны давать одинаковый результат распознавания и одинаково обрабатывать ошибки
+func TestMLGatewayContract(t *testing.T) {
+	transports := []struct {
+		name  string
+		start func(t *testing.T, faults *faultInjector) config.ML
+	}{
+		{name: "http", start: startHttpStub},
+		{name: "grpc", start: startGrpcStub},
+	}
+
+	cases := []struct {
+		name      string
+		transport config.MLTransport
+		failures  int32
+		check     func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector)
+	}{
+		{
+			name:      "detections",
+			transport: config.MLTransportUpload,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				res, err := gateway.ScanTools(context.Background(), newScanRequest())
+				if err != nil {
+					t.Fatalf("scan: %v", err)
+				}
+
+				if len(res.Tools) != len(stubClasses) {
+					t.Fatalf("want %d detections, got %d", len(stubClasses), len(res.Tools))
+				}
+
+				for i, tool := range res.Tools {
+					x := float32(i * 100)
+					wantBbox := []float32{x, 0, x + 90, 90}
+					if tool.ClassIndex != stubClasses[i] || tool.Confidence != 0.95 || !equalFloats(tool.Bbox, wantBbox) {
+						t.Errorf("detection %d: got class %d, confidence %v, bbox %v", i, tool.ClassIndex, tool.Confidence, tool.Bbox)
+					}
+				}
+
+				if res.DebugImageUrl != "http://storage.test/debug.jpg" {
+					t.Errorf("unexpected debug image url %q", res.DebugImageUrl)
+				}
+			},
+		},
+		{
+			name:      "embeddings",
+			transport: config.MLTransportUpload,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				res, err := gateway.ScanTools(context.Background(), newScanRequest())
+				if err != nil {
+					t.Fatalf("scan: %v", err)
+				}
+
+				for i, tool := range res.Tools {
+					want := make([]float32, domain.EmbeddingSize)
+					want[int(stubClasses[i])%domain.EmbeddingSize] = 1
+					if !equalFloats(tool.Embedding, want) {
+						t.Errorf("detection %d: embedding differs from the stub's unit vector", i)
+					}
+				}
+			},
+		},
+		{
+			name:      "model version in response",
+			transport: config.MLTransportUrl,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				res, err := gateway.ScanTools(context.Background(), newScanRequest())
+				if err != nil {
+					t.Fatalf("scan: %v", err)
+				}
+
+				if res.ModelVersion == nil || *res.ModelVersion != stubModelVersion {
+					t.Errorf("want model version %q, got %v", stubModelVersion, res.ModelVersion)
+				}
+			},
+		},
+		{
+			name:      "model version from health probe",
+			transport: config.MLTransportUrl,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				go gateway.RunHealthProbe(ctx)
+
+				deadline := time.Now().Add(2 * time.Second)
+				for time.Now().Before(deadline) {
+					health := gateway.Health()
+					if health.Healthy && health.ModelVersion != nil {
+						if *health.ModelVersion != stubModelVersion {
+							t.Errorf("want model version %q, got %q", stubModelVersion, *health.ModelVersion)
+						}
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+
+				t.Fatalf("health probe did not report the model version: %+v", gateway.Health())
+			},
+		},
+		{
+			name:      "retryable error is retried",
+			transport: config.MLTransportUpload,
+			failures:  1,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				if _, err := gateway.ScanTools(context.Background(), newScanRequest()); err != nil {
+					t.Fatalf("scan: %v", err)
+				}
+
+				if attempts := faults.attempts.Load(); attempts != 2 {
+					t.Errorf("want 2 attempts, got %d", attempts)
+				}
+			},
+		},
+		{
+			// без ссылки на изображение заглушка отвечает ошибкой запроса: 400 по HTTP, InvalidArgument по gRPC
+			name:      "non-retryable error is not retried",
+			transport: config.MLTransportUrl,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				req := newScanRequest()
+				req.ImageUrl = ""
+
+				_, err := gateway.ScanTools(context.Background(), req)
+				if !errors.Is(err, e.ErrMLServiceNonOK) || errors.Is(err, e.ErrMLServiceUnavailable) {
+					t.Fatalf("want non-retryable ErrMLServiceNonOK, got %v", err)
+				}
+
+				if attempts := faults.attempts.Load(); attempts != 1 {
+					t.Errorf("want 1 attempt, got %d", attempts)
+				}
+
+				if state := gateway.Health().CircuitState; state != string(infrastructure.CircuitClosed) {
+					t.Errorf("non-retryable error must not affect the breaker, state %s", state)
+				}
+			},
+		},
+		{
+			name:      "breaker opens",
+			transport: config.MLTransportUpload,
+			failures:  1000,
+			check: func(t *testing.T, gateway infrastructure.ResilientMLGateway, faults *faultInjector) {
+				for i := 0; i < contractBreakerThreshold; i++ {
+					if _, err := gateway.ScanTools(context.Background(), newScanRequest()); !errors.Is(err, e.ErrMLServiceUnavailable) {
+						t.Fatalf("call %d: want ErrMLServiceUnavailable, got %v", i, err)
+					}
+				}
+
+				if state := gateway.Health().CircuitState; state != usecase.MLCircuitOpen {
+					t.Fatalf("want breaker %s, got %s", usecase.MLCircuitOpen, state)
+				}
+
+				attempts := faults.attempts.Load()
+				if _, err := gateway.ScanTools(context.Background(), newScanRequest()); !errors.Is(err, e.ErrMLServiceUnavailable) {
+					t.Fatalf("want ErrMLServiceUnavailable from open breaker, got %v", err)
+				}
+
+				if faults.attempts.Load() != attempts {
+					t.Errorf("open breaker must reject calls without reaching the service")
+				}
+			},
+		},
+	}
+
+	for _, transport := range transports {
+		for _, tc := range cases {
+			t.Run(transport.name+"/"+tc.name, func(t *testing.T) {
+				faults := &faultInjector{}
+				faults.failures.Store(tc.failures)
+
+				cfg := transport.start(t, faults)
+				cfg.Transport = tc.transport
+				cfg.Timeout = 2 * time.Second
+				cfg.MaxRetries = 2
+				cfg.RetryBaseDelay = time.Millisecond
+				cfg.BreakerThreshold = contractBreakerThreshold
+				cfg.BreakerCooldown = time.Minute
+				cfg.HealthInterval = 10 * time.Millisecond
+
+				gateway, err := infrastructure.NewMLGatewayFromConfig(cfg, stubImageStorage{})
+				if err != nil {
+					t.Fatalf("new gateway: %v", err)
+				}
+				t.Cleanup(func() { gateway.Close() })
+
+				tc.check(t, gateway, faults)
+			})
+		}
+	}
+}
+
+func equalFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
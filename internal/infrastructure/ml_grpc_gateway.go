@@ -0,0 +1,135 @@
+package infrastructure
+
+import (
+	mlv1 "airport-tools-backend/api/ml/v1"
+	"airport-tools-backend/internal/config"
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// mlGrpcMaxMessageSize ограничение размера сообщений: изображения и отладочные изображения больше 4 МБ по умолчанию
+const mlGrpcMaxMessageSize = 64 << 20
+
+// MlGrpcGateway gRPC клиент ML-сервиса. Эмбеддинги передаются packed float32, а не JSON-текстом
+type MlGrpcGateway struct {
+	*mlClient
+	conn         *grpc.ClientConn
+	detector     mlv1.DetectorClient
+	healthClient healthpb.HealthClient
+}
+
+func NewMlGrpcGateway(cfg config.ML, s3 usecase.ImageStorage) (*MlGrpcGateway, error) {
+	const op = "NewMlGrpcGateway"
+
+	conn, err := grpc.NewClient(cfg.GrpcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(mlGrpcMaxMessageSize),
+			grpc.MaxCallSendMsgSize(mlGrpcMaxMessageSize),
+		),
+	)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return &MlGrpcGateway{
+		mlClient:     newMlClient(cfg, s3),
+		conn:         conn,
+		detector:     mlv1.NewDetectorClient(conn),
+		healthClient: healthpb.NewHealthClient(conn),
+	}, nil
+}
+
+// ScanTools отправляет изображение на ML-сервис по gRPC и возвращает распознанные инструменты
+func (ml *MlGrpcGateway) ScanTools(ctx context.Context, req *usecase.ScanRequest) (*usecase.ScanResult, error) {
+	const op = "MlGrpcGateway.ScanTools"
+
+	predictReq := &mlv1.PredictRequest{
+		ImageId:   req.ImageId,
+		Threshold: req.Threshold,
+	}
+
+	if ml.cfg.Transport == config.MLTransportUpload {
+		imgBytes, err := base64.StdEncoding.DecodeString(req.ImageData)
+		if err != nil {
+			return nil, e.Wrap(op, e.ErrIncorrectImage)
+		}
+		predictReq.Image = &mlv1.PredictRequest_ImageData{ImageData: imgBytes}
+	} else {
+		predictReq.Image = &mlv1.PredictRequest_ImageUrl{ImageUrl: req.ImageUrl}
+	}
+
+	return ml.scan(ctx, op, func(ctx context.Context) (*mlPrediction, error) {
+		return ml.predict(ctx, predictReq)
+	})
+}
+
+// predict выполняет одну попытку вызова; коды, означающие недоступность или перегрузку сервиса, помечаются как повторяемые
+func (ml *MlGrpcGateway) predict(ctx context.Context, req *mlv1.PredictRequest) (*mlPrediction, error) {
+	res, err := ml.detector.Predict(ctx, req)
+	if err != nil {
+		code := status.Code(err)
+		statusErr := fmt.Errorf("%w: %s", e.ErrMLServiceNonOK, code)
+
+		switch code {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unknown:
+			return nil, &retryableError{err: statusErr}
+		default:
+			return nil, statusErr
+		}
+	}
+
+	prediction := &mlPrediction{
+		Detections:   make([]mlDetection, 0, len(res.GetDetections())),
+		DebugImage:   base64.StdEncoding.EncodeToString(res.GetDebugImage()),
+		ModelVersion: res.GetModelVersion(),
+	}
+	for _, detection := range res.GetDetections() {
+		prediction.Detections = append(prediction.Detections, mlDetection{
+			Bbox:       detection.GetBbox(),
+			Class:      detection.GetClass(),
+			Confidence: detection.GetConfidence(),
+			Embedding:  detection.GetEmbedding(),
+		})
+	}
+
+	return prediction, nil
+}
+
+// RunHealthProbe периодически проверяет доступность ML-сервиса через grpc.health.v1 до отмены ctx
+func (ml *MlGrpcGateway) RunHealthProbe(ctx context.Context) {
+	ml.runHealthProbe(ctx, ml.checkHealth)
+}
+
+// checkHealth проверяет доступность сервиса и запрашивает версию модели; ошибка запроса версии на доступность не влияет
+func (ml *MlGrpcGateway) checkHealth(ctx context.Context) (string, error) {
+	res, err := ml.healthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: mlv1.Detector_ServiceDesc.ServiceName})
+	if err != nil {
+		return "", err
+	}
+
+	if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return "", fmt.Errorf("%w: %s", e.ErrMLServiceNonOK, res.GetStatus())
+	}
+
+	versionRes, err := ml.detector.GetModelVersion(ctx, &mlv1.GetModelVersionRequest{})
+	if err != nil {
+		return "", nil
+	}
+
+	return versionRes.GetModelVersion(), nil
+}
+
+// Close закрывает соединение с ML-сервисом
+func (ml *MlGrpcGateway) Close() error {
+	return ml.conn.Close()
+}
@@ -0,0 +1,47 @@
+package infrastructure
+
+import (
+	"airport-tools-backend/pkg/e"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher хеширует PIN-коды/пароли пользователей с помощью bcrypt
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &BcryptHasher{
+		cost: cost,
+	}
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	const op = "BcryptHasher.Hash"
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", e.Wrap(op, err)
+	}
+
+	return string(hash), nil
+}
+
+func (b *BcryptHasher) Compare(hash, password string) error {
+	const op = "BcryptHasher.Compare"
+
+	if hash == "" {
+		return e.Wrap(op, e.ErrInvalidCredentials)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return e.Wrap(op, e.ErrInvalidCredentials)
+	}
+
+	return nil
+}
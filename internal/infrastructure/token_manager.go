@@ -0,0 +1,116 @@
+package infrastructure
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	accessTokenType  string = "access"
+	refreshTokenType string = "refresh"
+)
+
+// JwtTokenManager выпускает и проверяет JWT-токены, подписанные HMAC-SHA256
+type JwtTokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewJwtTokenManager(secret string, accessTTL, refreshTTL time.Duration) *JwtTokenManager {
+	return &JwtTokenManager{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// tokenClaims полезная нагрузка токена
+type tokenClaims struct {
+	EmployeeId string `json:"employee_id"`
+	Role       string `json:"role"`
+	TokenType  string `json:"token_type"`
+	// TokenVersion версия токенов пользователя на момент выпуска; смена PIN её увеличивает
+	TokenVersion int `json:"token_version"`
+	jwt.RegisteredClaims
+}
+
+// NewTokenPair выпускает access и refresh токены для пользователя
+func (t *JwtTokenManager) NewTokenPair(user *domain.User) (*usecase.TokenPair, error) {
+	const op = "JwtTokenManager.NewTokenPair"
+
+	now := time.Now()
+	accessExpiresAt := now.Add(t.accessTTL)
+	refreshExpiresAt := now.Add(t.refreshTTL)
+
+	accessToken, err := t.sign(user, accessTokenType, now, accessExpiresAt)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	refreshToken, err := t.sign(user, refreshTokenType, now, refreshExpiresAt)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return usecase.NewTokenPair(accessToken, refreshToken, accessExpiresAt, refreshExpiresAt), nil
+}
+
+// ParseAccessToken проверяет подпись и срок действия access токена
+func (t *JwtTokenManager) ParseAccessToken(token string) (*usecase.TokenClaims, error) {
+	return t.parse(token, accessTokenType)
+}
+
+// ParseRefreshToken проверяет подпись и срок действия refresh токена
+func (t *JwtTokenManager) ParseRefreshToken(token string) (*usecase.TokenClaims, error) {
+	return t.parse(token, refreshTokenType)
+}
+
+func (t *JwtTokenManager) sign(user *domain.User, tokenType string, issuedAt, expiresAt time.Time) (string, error) {
+	var role string
+	if user.Role != nil {
+		role = user.Role.Name
+	}
+
+	claims := tokenClaims{
+		EmployeeId:   user.EmployeeId,
+		Role:         role,
+		TokenType:    tokenType,
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(user.Id, 10),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+func (t *JwtTokenManager) parse(token, tokenType string) (*usecase.TokenClaims, error) {
+	const op = "JwtTokenManager.parse"
+
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, e.Wrap(op, e.ErrInvalidToken)
+	}
+
+	if claims.TokenType != tokenType {
+		return nil, e.Wrap(op, e.ErrInvalidToken)
+	}
+
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, e.Wrap(op, e.ErrInvalidToken)
+	}
+
+	return usecase.NewTokenClaims(userId, claims.EmployeeId, claims.Role, claims.TokenVersion), nil
+}
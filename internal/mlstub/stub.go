@@ -0,0 +1,172 @@
+// Package mlstub локальная заглушка ML-сервиса для разработки без GPU-модели.
+// Отдаёт одинаковые детерминированные детекции по HTTP/JSON и по gRPC, чтобы оба транспорта шлюза
+// можно было проверить на одном и том же контракте
+package mlstub
+
+import (
+	mlv1 "airport-tools-backend/api/ml/v1"
+	"airport-tools-backend/internal/domain"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxUploadSize ограничение размера изображения, принимаемого в теле запроса
+const maxUploadSize = 32 << 20
+
+// Stub возвращает по одной детекции на каждый класс из Classes
+type Stub struct {
+	mlv1.UnimplementedDetectorServer
+	Classes      []int64
+	ModelVersion string
+}
+
+func NewStub(classes []int64, modelVersion string) *Stub {
+	return &Stub{Classes: classes, ModelVersion: modelVersion}
+}
+
+// predict формирует ответ: рамки идут слева направо, уверенность 0.95, эмбеддинг — единичный вектор по номеру класса.
+// Отладочным изображением служит само входное изображение, если оно передано байтами
+func (s *Stub) predict(imageId string, threshold float32, image []byte) *mlv1.PredictResponse {
+	const confidence = 0.95
+
+	res := &mlv1.PredictResponse{
+		ImageId:      imageId,
+		Detections:   make([]*mlv1.Detection, 0, len(s.Classes)),
+		DebugImage:   image,
+		ModelVersion: s.ModelVersion,
+	}
+	if confidence < threshold {
+		return res
+	}
+
+	for i, class := range s.Classes {
+		embedding := make([]float32, domain.EmbeddingSize)
+		embedding[int(class)%domain.EmbeddingSize] = 1
+
+		x := float32(i * 100)
+		res.Detections = append(res.Detections, &mlv1.Detection{
+			Bbox:       []float32{x, 0, x + 90, 90},
+			Class:      class,
+			Confidence: confidence,
+			Embedding:  embedding,
+		})
+	}
+
+	return res
+}
+
+// Predict реализует mlv1.DetectorServer
+func (s *Stub) Predict(ctx context.Context, req *mlv1.PredictRequest) (*mlv1.PredictResponse, error) {
+	if req.GetImageUrl() == "" && len(req.GetImageData()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image_url or image_data is required")
+	}
+
+	return s.predict(req.GetImageId(), req.GetThreshold(), req.GetImageData()), nil
+}
+
+// GetModelVersion реализует mlv1.DetectorServer
+func (s *Stub) GetModelVersion(ctx context.Context, req *mlv1.GetModelVersionRequest) (*mlv1.GetModelVersionResponse, error) {
+	return &mlv1.GetModelVersionResponse{ModelVersion: s.ModelVersion}, nil
+}
+
+// httpInstrument детекция в формате JSON-ответа ML-сервиса
+type httpInstrument struct {
+	Bbox       []float32 `json:"bbox"`
+	Class      int64     `json:"class"`
+	Confidence float32   `json:"confidence"`
+	Embedding  []float32 `json:"embedding"`
+}
+
+type httpPredictResponse struct {
+	ImageId      string           `json:"image_id"`
+	Instruments  []httpInstrument `json:"instruments"`
+	DebugImage   string           `json:"debug_image"`
+	ModelVersion string           `json:"model_version"`
+}
+
+type httpVersionResponse struct {
+	ModelVersion string `json:"model_version"`
+}
+
+// HTTPHandler обработчик HTTP/JSON API: GET /predict/ со ссылкой в url, POST /predict/ с файлом в поле file,
+// GET /health и GET /version
+func (s *Stub) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(httpVersionResponse{ModelVersion: s.ModelVersion})
+	})
+	mux.HandleFunc("/predict/", s.handlePredict)
+
+	return mux
+}
+
+func (s *Stub) handlePredict(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var threshold float32
+	if thresh := query.Get("thresh"); thresh != "" {
+		parsed, err := strconv.ParseFloat(thresh, 32)
+		if err != nil {
+			http.Error(w, "invalid thresh", http.StatusBadRequest)
+			return
+		}
+		threshold = float32(parsed)
+	}
+
+	var image []byte
+	switch r.Method {
+	case http.MethodGet:
+		if query.Get("url") == "" {
+			http.Error(w, "url is required", http.StatusBadRequest)
+			return
+		}
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		image, err = io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "failed to read file", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res := s.predict(query.Get("image_id"), threshold, image)
+
+	httpRes := httpPredictResponse{
+		ImageId:      res.GetImageId(),
+		Instruments:  make([]httpInstrument, 0, len(res.GetDetections())),
+		DebugImage:   base64.StdEncoding.EncodeToString(res.GetDebugImage()),
+		ModelVersion: res.GetModelVersion(),
+	}
+	for _, detection := range res.GetDetections() {
+		httpRes.Instruments = append(httpRes.Instruments, httpInstrument{
+			Bbox:       detection.GetBbox(),
+			Class:      detection.GetClass(),
+			Confidence: detection.GetConfidence(),
+			Embedding:  detection.GetEmbedding(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(httpRes)
+}
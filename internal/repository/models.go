@@ -1,5 +1,10 @@
 package repository
 
+import (
+	"airport-tools-backend/internal/domain"
+	"time"
+)
+
 // HumanErrorStats — структура для хранения статистики ошибок, допущенных конкретным сотрудником (не Ml моделью)
 type HumanErrorStats struct {
 	FullName    string
@@ -8,9 +13,10 @@ type HumanErrorStats struct {
 }
 
 type ToolWithErrorCount struct {
-	ID           int64
-	Name         string
-	MLErrorCount int64
+	ID             int64
+	Name           string
+	MLErrorCount   int64
+	ByModelVersion []ModelVersionErrorCount
 }
 
 type ToolSetWithErrors struct {
@@ -18,3 +24,113 @@ type ToolSetWithErrors struct {
 	Name  string
 	Tools []ToolWithErrorCount
 }
+
+type ToolInstanceWithErrorCount struct {
+	ID             int64
+	SerialNumber   string
+	ToolTypeId     int64
+	ToolTypeName   string
+	MLErrorCount   int64
+	ByModelVersion []ModelVersionErrorCount
+}
+
+// MlErrorFilter фильтр статистики ошибок модели, нулевые значения не ограничивают выборку
+type MlErrorFilter struct {
+	ModelVersion *string
+}
+
+// ModelVersionErrorCount число ошибок, отнесённых к версии модели.
+// Ошибка относится к версии модели последнего скана сдачи по транзакции; nil — версия неизвестна
+type ModelVersionErrorCount struct {
+	ModelVersion *string
+	MLErrorCount int64
+}
+
+// ReasonModelVersionCount число решений QA с причиной Reason по версии модели
+type ReasonModelVersionCount struct {
+	Reason       domain.Reason
+	ModelVersion *string
+	Count        int64
+}
+
+// ModelVersionStats сводка по сканам одной версии модели
+type ModelVersionStats struct {
+	ModelVersion      *string
+	ScansCount        int64
+	TransactionsCount int64
+	MLErrorCount      int64
+	FirstSeenAt       time.Time
+	LastSeenAt        time.Time
+}
+
+// ToolInstanceFilter фильтр списка экземпляров, нулевые значения не ограничивают выборку
+type ToolInstanceFilter struct {
+	ToolTypeId    *int64
+	HomeToolSetId *int64
+	Status        *domain.ToolInstanceStatus
+}
+
+// ShadowReportFilter фильтр отчёта сравнения с теневой моделью, нулевые значения не ограничивают выборку
+type ShadowReportFilter struct {
+	ModelVersion *string
+	From         *time.Time
+	To           *time.Time
+}
+
+// ShadowScanSummary итоги сравнения: AgreedScans — сканы, в которых обе модели нашли одинаковое число
+// инструментов каждого типа, StatusAgreedScans — сканы, по которым теневая модель дала бы тот же статус
+type ShadowScanSummary struct {
+	ScansCount        int64
+	FailedCount       int64
+	AgreedScans       int64
+	StatusAgreedScans int64
+}
+
+// ShadowStatusCount число сканов с парой статусов основной и теневой модели
+type ShadowStatusCount struct {
+	PrimaryStatus domain.Status
+	ShadowStatus  domain.Status
+	Count         int64
+}
+
+// ShadowToolTypeDiff расхождение моделей по типу инструмента: суммарное число детекций каждой модели
+// и число сканов, в которых количество детекций этого типа различается
+type ShadowToolTypeDiff struct {
+	ToolTypeId    int64
+	ToolTypeName  string
+	PrimaryCount  int64
+	ShadowCount   int64
+	ScansWithDiff int64
+}
+
+// ShadowComparison отчёт сравнения основной и теневой моделей
+type ShadowComparison struct {
+	Summary   ShadowScanSummary
+	Statuses  []*ShadowStatusCount
+	ToolTypes []*ShadowToolTypeDiff
+}
+
+// ScanHistoryFilter фильтр сканов сдачи по версии модели и периоду, нулевые значения не ограничивают выборку
+type ScanHistoryFilter struct {
+	ModelVersion *string
+	From         *time.Time
+	To           *time.Time
+}
+
+// CheckinScanHistory сохранённый скан сдачи для повторного прогона порогов. CheckNumber — порядковый номер сдачи
+// в транзакции. Resolved — скан последний в транзакции, по которой вынесено QA-решение; по нему аудитор
+// оценивал результат модели, ModelErrToolTypeIds — типы, отмеченные в решении как ошибки модели
+type CheckinScanHistory struct {
+	ScanId              int64
+	TransactionId       int64
+	ToolSetId           int64
+	CheckNumber         int64
+	Resolved            bool
+	ModelErrToolTypeIds []int64
+	Details             []*domain.CvScanDetail
+}
+
+// QAQueueFilter фильтр очереди QA-проверки, нулевые значения не ограничивают выборку
+type QAQueueFilter struct {
+	AssigneeId *int64
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type BadgeRepository struct {
+	DB *gorm.DB
+}
+
+func NewBadgeRepository(db *gorm.DB) *BadgeRepository {
+	return &BadgeRepository{
+		DB: db,
+	}
+}
+
+func (b *BadgeRepository) Create(ctx context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	const op = "BadgeRepository.Create"
+
+	model := toBadgeModel(badge)
+	result := b.DB.WithContext(ctx).Omit("User").Create(model)
+	if err := postgresDuplicate(result, e.ErrBadgeExists); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainBadge(model), nil
+}
+
+func (b *BadgeRepository) GetById(ctx context.Context, id int64) (*domain.Badge, error) {
+	const op = "BadgeRepository.GetById"
+
+	var model BadgeModel
+	result := b.DB.WithContext(ctx).First(&model, "id = ?", id)
+	if err := checkGetQueryResult(result, e.ErrBadgeNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainBadge(&model), nil
+}
+
+func (b *BadgeRepository) GetByUidWithUser(ctx context.Context, uid string) (*domain.Badge, error) {
+	const op = "BadgeRepository.GetByUidWithUser"
+
+	var model BadgeModel
+	result := b.DB.WithContext(ctx).Preload("User.Role").First(&model, "uid = ?", uid)
+	if err := checkGetQueryResult(result, e.ErrBadgeNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainBadge(&model), nil
+}
+
+func (b *BadgeRepository) GetAllByUserId(ctx context.Context, userId int64) ([]*domain.Badge, error) {
+	const op = "BadgeRepository.GetAllByUserId"
+
+	var models []*BadgeModel
+	result := b.DB.WithContext(ctx).Where("user_id = ?", userId).Order("id DESC").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainBadge(models), nil
+}
+
+func (b *BadgeRepository) Update(ctx context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	const op = "BadgeRepository.Update"
+
+	updates := map[string]interface{}{
+		"activated_at": badge.ActivatedAt,
+		"revoked_at":   badge.RevokedAt,
+	}
+
+	var updBadge BadgeModel
+	result := b.DB.WithContext(ctx).Model(&BadgeModel{}).Where("id = ?", badge.Id).Updates(updates).Scan(&updBadge)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, e.Wrap(op, e.ErrBadgeNotFound)
+	}
+
+	return toDomainBadge(&updBadge), nil
+}
+
+func toBadgeModel(b *domain.Badge) *BadgeModel {
+	return &BadgeModel{
+		Id:          b.Id,
+		Uid:         b.Uid,
+		UserId:      b.UserId,
+		ActivatedAt: b.ActivatedAt,
+		RevokedAt:   b.RevokedAt,
+		CreatedAt:   b.CreatedAt,
+	}
+}
+
+func toDomainBadge(m *BadgeModel) *domain.Badge {
+	badge := &domain.Badge{
+		Id:          m.Id,
+		Uid:         m.Uid,
+		UserId:      m.UserId,
+		ActivatedAt: m.ActivatedAt,
+		RevokedAt:   m.RevokedAt,
+		CreatedAt:   m.CreatedAt,
+	}
+
+	if m.User != nil {
+		badge.User = toDomainUser(m.User)
+	}
+
+	return badge
+}
+
+func toArrDomainBadge(models []*BadgeModel) []*domain.Badge {
+	badges := make([]*domain.Badge, len(models))
+	for i, model := range models {
+		badges[i] = toDomainBadge(model)
+	}
+
+	return badges
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalibrationEventRepository struct {
+	DB *gorm.DB
+}
+
+func NewCalibrationEventRepository(db *gorm.DB) *CalibrationEventRepository {
+	return &CalibrationEventRepository{
+		DB: db,
+	}
+}
+
+// Create сохраняет запись о поверке и в той же транзакции обновляет даты поверки экземпляра,
+// если поверка не раньше последней зарегистрированной
+func (c *CalibrationEventRepository) Create(ctx context.Context, event *domain.CalibrationEvent) (*domain.CalibrationEvent, error) {
+	const op = "CalibrationEventRepository.Create"
+
+	model := toCalibrationEventModel(event)
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"last_calibrated_at": model.CalibratedAt,
+			"calibration_due_at": model.NextDueAt,
+			"updated_at":         time.Now().UTC(),
+		}
+
+		result := tx.Model(&ToolInstanceModel{}).
+			Where("id = ? AND (last_calibrated_at IS NULL OR last_calibrated_at <= ?)", model.ToolInstanceId, model.CalibratedAt).
+			Updates(updates)
+		if err := result.Error; err != nil {
+			return err
+		}
+
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		// даты не обновлены: экземпляра нет или уже зарегистрирована более поздняя поверка
+		var count int64
+		if err := tx.Model(&ToolInstanceModel{}).Where("id = ?", model.ToolInstanceId).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return e.ErrToolInstanceNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainCalibrationEvent(model), nil
+}
+
+func (c *CalibrationEventRepository) GetAllByToolInstanceId(ctx context.Context, toolInstanceId int64) ([]*domain.CalibrationEvent, error) {
+	const op = "CalibrationEventRepository.GetAllByToolInstanceId"
+
+	var models []*CalibrationEventModel
+	result := c.DB.WithContext(ctx).Where("tool_instance_id = ?", toolInstanceId).Order("calibrated_at DESC, id DESC").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainCalibrationEvent(models), nil
+}
+
+func toCalibrationEventModel(c *domain.CalibrationEvent) *CalibrationEventModel {
+	return &CalibrationEventModel{
+		Id:             c.Id,
+		ToolInstanceId: c.ToolInstanceId,
+		CalibratedAt:   c.CalibratedAt,
+		NextDueAt:      c.NextDueAt,
+		PerformedBy:    c.PerformedBy,
+		Notes:          c.Notes,
+		CreatedAt:      c.CreatedAt,
+	}
+}
+
+func toDomainCalibrationEvent(m *CalibrationEventModel) *domain.CalibrationEvent {
+	return &domain.CalibrationEvent{
+		Id:             m.Id,
+		ToolInstanceId: m.ToolInstanceId,
+		CalibratedAt:   m.CalibratedAt,
+		NextDueAt:      m.NextDueAt,
+		PerformedBy:    m.PerformedBy,
+		Notes:          m.Notes,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+func toArrDomainCalibrationEvent(models []*CalibrationEventModel) []*domain.CalibrationEvent {
+	events := make([]*domain.CalibrationEvent, len(models))
+	for i, m := range models {
+		events[i] = toDomainCalibrationEvent(m)
+	}
+
+	return events
+}
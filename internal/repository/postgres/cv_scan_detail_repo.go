@@ -32,6 +32,26 @@ func (c *CvScanDetailRepository) Create(ctx context.Context, cvScanDetail *domai
 	return toDomainCvScanDetail(model), nil
 }
 
+// CreateBatch сохраняет детали скана одним INSERT
+func (c *CvScanDetailRepository) CreateBatch(ctx context.Context, cvScanDetails []*domain.CvScanDetail) error {
+	const op = "CvScanDetailRepository.CreateBatch"
+
+	if len(cvScanDetails) == 0 {
+		return nil
+	}
+
+	models := toArrCvScanDetailModel(cvScanDetails)
+	if err := c.DB.WithContext(ctx).Create(&models).Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	for i, model := range models {
+		cvScanDetails[i].Id = model.Id
+	}
+
+	return nil
+}
+
 func (c *CvScanDetailRepository) GetById(ctx context.Context, id int64) (*domain.CvScanDetail, error) {
 	const op = "CvScanDetailRepository.GetById"
 
@@ -69,10 +89,13 @@ func toCvScanDetailModel(c *domain.CvScanDetail) *CvScanDetailModel {
 	return &CvScanDetailModel{
 		Id:                 c.Id,
 		CvScanId:           c.CvScanId,
+		ClassIndex:         c.ClassIndex,
 		DetectedToolTypeId: c.DetectedToolTypeId,
 		Confidence:         c.Confidence,
 		Embedding:          pgvector.NewVector(c.Embedding),
 		Bbox:               bbox,
+		ReferenceId:        c.ReferenceId,
+		CosineSimilarity:   c.CosineSimilarity,
 	}
 }
 
@@ -85,10 +108,13 @@ func toDomainCvScanDetail(c *CvScanDetailModel) *domain.CvScanDetail {
 	return &domain.CvScanDetail{
 		Id:                 c.Id,
 		CvScanId:           c.CvScanId,
+		ClassIndex:         c.ClassIndex,
 		DetectedToolTypeId: c.DetectedToolTypeId,
 		Confidence:         c.Confidence,
 		Embedding:          c.Embedding.Slice(),
 		Bbox:               bbox,
+		ReferenceId:        c.ReferenceId,
+		CosineSimilarity:   c.CosineSimilarity,
 	}
 }
 
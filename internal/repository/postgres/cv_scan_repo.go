@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
 	"airport-tools-backend/pkg/e"
 	"context"
+	"strings"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
@@ -78,6 +81,172 @@ func (c *CvScanRepository) GetByTransactionIdWithDetectedToolsAndTransaction(ctx
 	return toDomainCvScan(&model), nil
 }
 
+// GetLastByTransactionIdAndTypeWithDetectedTools возвращает последний скан транзакции указанного типа с детекциями
+func (c *CvScanRepository) GetLastByTransactionIdAndTypeWithDetectedTools(ctx context.Context, transactionId int64, scanType domain.ScanType) (*domain.CvScan, error) {
+	const op = "CvScanRepository.GetLastByTransactionIdAndTypeWithDetectedTools"
+
+	var model CvScanModel
+	result := c.DB.WithContext(ctx).Preload("DetectedTools").Order("created_at DESC, id DESC").
+		First(&model, "transaction_id = ? AND scan_type = ?", transactionId, scanType)
+	if err := checkGetQueryResult(result, e.ErrCvScanNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainCvScan(&model), nil
+}
+
+// GetModelVersionStats возвращает сводку по версиям модели: число сканов и транзакций, период использования
+// и число ошибок MODEL_ERR, отнесённых к версии по последнему скану сдачи. Сканы без версии попадают в группу NULL
+func (c *CvScanRepository) GetModelVersionStats(ctx context.Context) ([]*repository.ModelVersionStats, error) {
+	const op = "CvScanRepository.GetModelVersionStats"
+
+	var res []*repository.ModelVersionStats
+	result := c.DB.WithContext(ctx).Raw(`
+		WITH errors AS (
+			SELECT sv.model_version, COUNT(*) AS ml_error_count
+			FROM transaction_resolutions tr
+			`+resolutionModelVersionJoin+`
+			WHERE tr.reason = ?
+			GROUP BY sv.model_version
+		)
+		SELECT
+			s.model_version,
+			s.scans_count,
+			s.transactions_count,
+			COALESCE(er.ml_error_count, 0) AS ml_error_count,
+			s.first_seen_at,
+			s.last_seen_at
+		FROM (
+			SELECT
+				model_version,
+				COUNT(*) AS scans_count,
+				COUNT(DISTINCT transaction_id) AS transactions_count,
+				MIN(created_at) AS first_seen_at,
+				MAX(created_at) AS last_seen_at
+			FROM cv_scans
+			GROUP BY model_version
+		) s
+		LEFT JOIN errors er ON er.model_version IS NOT DISTINCT FROM s.model_version
+		ORDER BY s.last_seen_at DESC
+	`, domain.ModelError).Scan(&res)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// checkinHistoryQuery сканы сдачи с порядковым номером сдачи и QA-решением по транзакции. Нумерация и признак
+// последнего скана считаются по всем сдачам транзакции до применения фильтра
+const checkinHistoryQuery = `
+	SELECT h.scan_id, h.transaction_id, h.tool_set_id, h.check_number, h.resolved, h.model_err_tool_type_ids
+	FROM (
+		SELECT
+			s.id AS scan_id,
+			s.transaction_id,
+			t.tool_set_id,
+			s.model_version,
+			s.created_at,
+			ROW_NUMBER() OVER (PARTITION BY s.transaction_id ORDER BY s.created_at, s.id) AS check_number,
+			r.id IS NOT NULL AND ROW_NUMBER() OVER (
+				PARTITION BY s.transaction_id ORDER BY s.created_at DESC, s.id DESC
+			) = 1 AS resolved,
+			COALESCE(r.model_err_tool_type_ids, '{}') AS model_err_tool_type_ids
+		FROM cv_scans s
+		JOIN transactions t ON t.id = s.transaction_id
+		LEFT JOIN LATERAL (
+			SELECT tr.id, ARRAY(
+				SELECT mei.tool_type_id FROM model_err_items mei WHERE mei.resolution_id = tr.id
+			) AS model_err_tool_type_ids
+			FROM transaction_resolutions tr
+			WHERE tr.transaction_id = s.transaction_id
+			ORDER BY tr.created_at DESC, tr.id DESC
+			LIMIT 1
+		) r ON TRUE
+		WHERE s.scan_type = 'checkin'
+	) h
+	WHERE TRUE`
+
+// GetCheckinHistory возвращает не больше limit последних сканов сдачи с детекциями (включая эмбеддинги)
+// для повторного прогона порогов. У детекций читаются только поля, нужные для прогона
+func (c *CvScanRepository) GetCheckinHistory(ctx context.Context, filter repository.ScanHistoryFilter, limit int) ([]*repository.CheckinScanHistory, error) {
+	const op = "CvScanRepository.GetCheckinHistory"
+
+	where, args := scanHistoryConditions(filter)
+	query := checkinHistoryQuery + where
+	db := c.DB.WithContext(ctx)
+
+	var rows []struct {
+		ScanId              int64
+		TransactionId       int64
+		ToolSetId           int64
+		CheckNumber         int64
+		Resolved            bool
+		ModelErrToolTypeIds pq.Int64Array `gorm:"type:bigint[]"`
+	}
+	result := db.Raw(query+" ORDER BY h.scan_id DESC LIMIT ?", append(args, limit)...).Scan(&rows)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if len(rows) == 0 {
+		return []*repository.CheckinScanHistory{}, nil
+	}
+
+	scanIds := make([]int64, len(rows))
+	for i, row := range rows {
+		scanIds[i] = row.ScanId
+	}
+
+	var details []*CvScanDetailModel
+	result = db.Select("id", "cv_scan_id", "class_index", "detected_tool_type_id", "confidence", "embedding", "bbox").
+		Where("cv_scan_id IN ?", scanIds).Order("cv_scan_id, id").Find(&details)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	detailsByScan := make(map[int64][]*domain.CvScanDetail, len(rows))
+	for _, detail := range details {
+		detailsByScan[detail.CvScanId] = append(detailsByScan[detail.CvScanId], toDomainCvScanDetail(detail))
+	}
+
+	res := make([]*repository.CheckinScanHistory, len(rows))
+	for i, row := range rows {
+		res[i] = &repository.CheckinScanHistory{
+			ScanId:              row.ScanId,
+			TransactionId:       row.TransactionId,
+			ToolSetId:           row.ToolSetId,
+			CheckNumber:         row.CheckNumber,
+			Resolved:            row.Resolved,
+			ModelErrToolTypeIds: row.ModelErrToolTypeIds,
+			Details:             detailsByScan[row.ScanId],
+		}
+	}
+
+	return res, nil
+}
+
+// scanHistoryConditions условия фильтра по выборке checkinHistoryQuery с псевдонимом h
+func scanHistoryConditions(filter repository.ScanHistoryFilter) (string, []interface{}) {
+	var conditions strings.Builder
+	args := make([]interface{}, 0, 3)
+
+	if filter.ModelVersion != nil {
+		conditions.WriteString(" AND h.model_version = ?")
+		args = append(args, *filter.ModelVersion)
+	}
+	if filter.From != nil {
+		conditions.WriteString(" AND h.created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions.WriteString(" AND h.created_at < ?")
+		args = append(args, *filter.To)
+	}
+
+	return conditions.String(), args
+}
+
 func toCvScanModel(c *domain.CvScan) *CvScanModel {
 	model := &CvScanModel{
 		Id:            c.Id,
@@ -85,6 +254,7 @@ func toCvScanModel(c *domain.CvScan) *CvScanModel {
 		ScanType:      c.ScanType,
 		ImageUrl:      c.ImageUrl,
 		DebugImageUrl: c.DebugImageUrl,
+		ModelVersion:  c.ModelVersion,
 		CreatedAt:     c.CreatedAt,
 	}
 
@@ -106,6 +276,7 @@ func toDomainCvScan(c *CvScanModel) *domain.CvScan {
 		ScanType:      c.ScanType,
 		ImageUrl:      c.ImageUrl,
 		DebugImageUrl: c.DebugImageUrl,
+		ModelVersion:  c.ModelVersion,
 		CreatedAt:     c.CreatedAt,
 	}
 
@@ -34,6 +34,16 @@ func postgresDuplicate(result *gorm.DB, ErrIsExists error) error {
 	return nil
 }
 
+// postgresDuplicateConstraint возвращает имя уникального ограничения, если ошибка вызвана его нарушением
+func postgresDuplicateConstraint(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return pgErr.ConstraintName, true
+	}
+
+	return "", false
+}
+
 // postgresForeignKeyViolation проверяет, была ли нарушена ссылка внешнего ключа
 func postgresForeignKeyViolation(result *gorm.DB, ErrInUse error) error {
 	if err := result.Error; err != nil {
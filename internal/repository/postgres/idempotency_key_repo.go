@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyKeyRepository struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{
+		DB: db,
+	}
+}
+
+// Reserve вставляет ключ через ON CONFLICT DO NOTHING, поэтому из параллельных запросов ключ достаётся только одному
+func (i *IdempotencyKeyRepository) Reserve(ctx context.Context, key *domain.IdempotencyKey) (bool, error) {
+	const op = "IdempotencyKeyRepository.Reserve"
+
+	model := toIdempotencyKeyModel(key)
+	result := i.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(model)
+	if err := result.Error; err != nil {
+		return false, e.Wrap(op, err)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (i *IdempotencyKeyRepository) Get(ctx context.Context, scope, key string) (*domain.IdempotencyKey, error) {
+	const op = "IdempotencyKeyRepository.Get"
+
+	var model IdempotencyKeyModel
+	result := i.DB.WithContext(ctx).First(&model, "scope = ? AND key = ?", scope, key)
+	if err := checkGetQueryResult(result, e.ErrIdempotencyKeyNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainIdempotencyKey(&model), nil
+}
+
+func (i *IdempotencyKeyRepository) Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error {
+	const op = "IdempotencyKeyRepository.Complete"
+
+	updates := map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": responseBody,
+		"completed_at":  time.Now().UTC(),
+	}
+
+	result := i.DB.WithContext(ctx).Model(&IdempotencyKeyModel{}).Where("scope = ? AND key = ?", scope, key).Updates(updates)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrIdempotencyKeyNotFound)
+	}
+
+	return nil
+}
+
+func (i *IdempotencyKeyRepository) Delete(ctx context.Context, scope, key string) error {
+	const op = "IdempotencyKeyRepository.Delete"
+
+	result := i.DB.WithContext(ctx).Where("scope = ? AND key = ?", scope, key).Delete(&IdempotencyKeyModel{})
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// DeleteCreatedBefore удаляет ключи, созданные раньше before; отбор идёт по индексу idempotency_keys_created_at_idx
+func (i *IdempotencyKeyRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "IdempotencyKeyRepository.DeleteCreatedBefore"
+
+	result := i.DB.WithContext(ctx).Where("created_at < ?", before).Delete(&IdempotencyKeyModel{})
+	if err := result.Error; err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func toIdempotencyKeyModel(k *domain.IdempotencyKey) *IdempotencyKeyModel {
+	return &IdempotencyKeyModel{
+		Scope:        k.Scope,
+		Key:          k.Key,
+		RequestHash:  k.RequestHash,
+		StatusCode:   k.StatusCode,
+		ResponseBody: k.ResponseBody,
+		CreatedAt:    k.CreatedAt,
+		CompletedAt:  k.CompletedAt,
+	}
+}
+
+func toDomainIdempotencyKey(m *IdempotencyKeyModel) *domain.IdempotencyKey {
+	return &domain.IdempotencyKey{
+		Scope:        m.Scope,
+		Key:          m.Key,
+		RequestHash:  m.RequestHash,
+		StatusCode:   m.StatusCode,
+		ResponseBody: m.ResponseBody,
+		CreatedAt:    m.CreatedAt,
+		CompletedAt:  m.CompletedAt,
+	}
+}
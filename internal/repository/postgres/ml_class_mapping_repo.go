@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type MLClassMappingRepository struct {
+	DB *gorm.DB
+}
+
+func NewMLClassMappingRepository(db *gorm.DB) *MLClassMappingRepository {
+	return &MLClassMappingRepository{
+		DB: db,
+	}
+}
+
+func (m *MLClassMappingRepository) Create(ctx context.Context, mapping *domain.MLClassMapping) (*domain.MLClassMapping, error) {
+	const op = "MLClassMappingRepository.Create"
+
+	model := toMLClassMappingModel(mapping)
+	result := m.DB.WithContext(ctx).Omit("ToolType").Create(model)
+	if err := postgresDuplicate(result, e.ErrMLClassMappingExists); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainMLClassMapping(model), nil
+}
+
+func (m *MLClassMappingRepository) GetById(ctx context.Context, id int64) (*domain.MLClassMapping, error) {
+	const op = "MLClassMappingRepository.GetById"
+
+	var model MLClassMappingModel
+	result := m.DB.WithContext(ctx).Preload("ToolType").First(&model, "id = ?", id)
+	if err := checkGetQueryResult(result, e.ErrMLClassMappingNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainMLClassMapping(&model), nil
+}
+
+func (m *MLClassMappingRepository) GetAll(ctx context.Context, modelVersion *string) ([]*domain.MLClassMapping, error) {
+	const op = "MLClassMappingRepository.GetAll"
+
+	query := m.DB.WithContext(ctx).Preload("ToolType")
+	if modelVersion != nil {
+		query = query.Where("model_version = ?", *modelVersion)
+	}
+
+	var models []*MLClassMappingModel
+	result := query.Order("model_version, class_index").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainMLClassMapping(models), nil
+}
+
+func (m *MLClassMappingRepository) GetForModelVersion(ctx context.Context, modelVersion *string) ([]*domain.MLClassMapping, error) {
+	const op = "MLClassMappingRepository.GetForModelVersion"
+
+	versions := []string{domain.AnyModelVersion}
+	if modelVersion != nil {
+		versions = append(versions, *modelVersion)
+	}
+
+	var models []*MLClassMappingModel
+	result := m.DB.WithContext(ctx).Where("model_version IN ?", versions).Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainMLClassMapping(models), nil
+}
+
+func (m *MLClassMappingRepository) Update(ctx context.Context, mapping *domain.MLClassMapping) (*domain.MLClassMapping, error) {
+	const op = "MLClassMappingRepository.Update"
+
+	updates := map[string]interface{}{
+		"tool_type_id": mapping.ToolTypeId,
+	}
+
+	var updMapping MLClassMappingModel
+	result := m.DB.WithContext(ctx).Model(&MLClassMappingModel{}).Where("id = ?", mapping.Id).Updates(updates).Scan(&updMapping)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, e.Wrap(op, e.ErrMLClassMappingNotFound)
+	}
+
+	return toDomainMLClassMapping(&updMapping), nil
+}
+
+func (m *MLClassMappingRepository) Delete(ctx context.Context, id int64) error {
+	const op = "MLClassMappingRepository.Delete"
+
+	result := m.DB.WithContext(ctx).Delete(&MLClassMappingModel{}, id)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrMLClassMappingNotFound)
+	}
+
+	return nil
+}
+
+func toMLClassMappingModel(m *domain.MLClassMapping) *MLClassMappingModel {
+	return &MLClassMappingModel{
+		Id:           m.Id,
+		ModelVersion: m.ModelVersion,
+		ClassIndex:   m.ClassIndex,
+		ToolTypeId:   m.ToolTypeId,
+		CreatedAt:    m.CreatedAt,
+	}
+}
+
+func toDomainMLClassMapping(m *MLClassMappingModel) *domain.MLClassMapping {
+	mapping := &domain.MLClassMapping{
+		Id:           m.Id,
+		ModelVersion: m.ModelVersion,
+		ClassIndex:   m.ClassIndex,
+		ToolTypeId:   m.ToolTypeId,
+		CreatedAt:    m.CreatedAt,
+	}
+
+	if m.ToolType != nil {
+		mapping.ToolType = toDomainToolType(m.ToolType)
+	}
+
+	return mapping
+}
+
+func toArrDomainMLClassMapping(models []*MLClassMappingModel) []*domain.MLClassMapping {
+	mappings := make([]*domain.MLClassMapping, len(models))
+	for i, m := range models {
+		mappings[i] = toDomainMLClassMapping(m)
+	}
+
+	return mappings
+}
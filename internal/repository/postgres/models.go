@@ -9,31 +9,50 @@ import (
 )
 
 type ToolTypeModel struct {
-	Id                 int64
-	PartNumber         string
-	Name               string
-	ReferenceEmbedding pgvector.Vector `gorm:"type:vector(1280)"`
+	Id                      int64
+	PartNumber              string
+	Name                    string
+	ReferenceEmbedding      pgvector.Vector `gorm:"type:vector(1280)"`
+	CalibrationIntervalDays *int
+	ConfidenceThreshold     *float32
+	CosineSimThreshold      *float32
 
+	References             []*ToolTypeReferenceModel     `gorm:"foreignKey:ToolTypeId"`
 	ToolSets               []*ToolSetModel               `gorm:"many2many:tool_set_items;joinForeignKey:ToolTypeId;joinReferences:ToolSetId"`
 	TransactionResolutions []*TransactionResolutionModel `gorm:"many2many:model_err_items;joinForeignKey:ToolTypeId;joinReferences:ResolutionId"`
 }
 
 type ToolSetModel struct {
-	Id   int64
-	Name string
+	Id        int64
+	FamilyId  int64
+	Version   int
+	Name      string
+	RetiredAt *time.Time
+	CreatedAt time.Time
+	// IsLatest последняя версия семейства; по последним версиям БД проверяет уникальность названия
+	IsLatest bool
 
-	Tools []*ToolTypeModel `gorm:"many2many:tool_set_items;joinForeignKey:ToolSetId;joinReferences:ToolTypeId"`
+	Tools      []*ToolTypeModel         `gorm:"many2many:tool_set_items;joinForeignKey:ToolSetId;joinReferences:ToolTypeId"`
+	Items      []*ToolSetItemModel      `gorm:"foreignKey:ToolSetId"`
+	Thresholds []*ToolSetThresholdModel `gorm:"foreignKey:FamilyId;references:FamilyId"`
+	Slots      []*BoardSlotModel        `gorm:"foreignKey:FamilyId;references:FamilyId"`
 }
 type ToolSetItemModel struct {
 	ToolSetId  int64 `gorm:"column:tool_set_id"`
 	ToolTypeId int64 `gorm:"column:tool_type_id"`
+	Quantity   int   `gorm:"column:quantity"`
 }
 
 type UserModel struct {
-	Id         int64
-	EmployeeId string
-	FullName   string
-	RoleId     int64
+	Id                  int64
+	EmployeeId          string
+	FullName            string
+	RoleId              int64
+	PasswordHash        string
+	MustChangePin       bool
+	FailedLoginAttempts int
+	LoginLockedUntil    *time.Time
+	TokenVersion        int
 
 	Role                   *RoleModel                    `gorm:"foreignKey:RoleId;references:Id"`
 	Transactions           []*TransactionModel           `gorm:"foreignkey:UserId"`
@@ -45,6 +64,8 @@ type TransactionModel struct {
 	UserId        int64
 	ToolSetId     int64
 	CountOfChecks int64
+	BadgeId       *int64
+	KioskId       *int64
 	Status        domain.Status
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -59,6 +80,7 @@ type CvScanModel struct {
 	ScanType      domain.ScanType
 	ImageUrl      string
 	DebugImageUrl string
+	ModelVersion  *string
 	CreatedAt     time.Time
 
 	Transaction   *TransactionModel    `gorm:"foreignKey:TransactionId"`
@@ -68,10 +90,13 @@ type CvScanModel struct {
 type CvScanDetailModel struct {
 	Id                 int64
 	CvScanId           int64
-	DetectedToolTypeId int64
+	ClassIndex         int64
+	DetectedToolTypeId *int64
 	Confidence         float32
 	Embedding          pgvector.Vector `gorm:"type:vector(1280)"`
 	Bbox               pq.Float64Array `gorm:"type:double precision[]"`
+	ReferenceId        *int64
+	CosineSimilarity   *float32
 }
 
 type TransactionResolutionModel struct {
@@ -93,6 +118,53 @@ type RoleModel struct {
 	Users []*UserModel `gorm:"foreignKey:RoleId;references:Id"`
 }
 
+type BadgeModel struct {
+	Id          int64
+	Uid         string
+	UserId      int64
+	ActivatedAt time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+
+	User *UserModel `gorm:"foreignKey:UserId;references:Id"`
+}
+
+type ToolTypeSampleModel struct {
+	Id         int64
+	ToolTypeId int64
+	ImageUrl   string
+	Confidence float32
+	Embedding  pgvector.Vector `gorm:"type:vector(1280)"`
+	CreatedAt  time.Time
+}
+
+type ToolTypeReferenceModel struct {
+	Id         int64
+	ToolTypeId int64
+	Label      string
+	Embedding  pgvector.Vector `gorm:"type:vector(1280)"`
+	CreatedAt  time.Time
+}
+
+type ToolInstanceModel struct {
+	Id               int64
+	SerialNumber     string
+	ToolTypeId       int64
+	HomeToolSetId    *int64
+	Status           domain.ToolInstanceStatus
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LastCalibratedAt *time.Time
+	CalibrationDueAt *time.Time
+
+	ToolType *ToolTypeModel `gorm:"foreignKey:ToolTypeId;references:Id"`
+}
+
+type TransactionInstanceModel struct {
+	TransactionId  int64
+	ToolInstanceId int64
+}
+
 type ModelErrItemModel struct {
 	ResolutionId int64
 	ToolTypeId   int64
@@ -102,6 +174,10 @@ func (ModelErrItemModel) TableName() string {
 	return "model_err_items"
 }
 
+func (BadgeModel) TableName() string {
+	return "badges"
+}
+
 func (RoleModel) TableName() string {
 	return "roles"
 }
@@ -135,3 +211,157 @@ func (CvScanDetailModel) TableName() string {
 }
 
 func (TransactionResolutionModel) TableName() string { return "transaction_resolutions" }
+
+func (ToolTypeSampleModel) TableName() string {
+	return "tool_type_samples"
+}
+
+func (ToolTypeReferenceModel) TableName() string {
+	return "tool_type_references"
+}
+
+func (ToolInstanceModel) TableName() string {
+	return "tool_instances"
+}
+
+func (TransactionInstanceModel) TableName() string {
+	return "transaction_instances"
+}
+
+type CalibrationEventModel struct {
+	Id             int64
+	ToolInstanceId int64
+	CalibratedAt   time.Time
+	NextDueAt      time.Time
+	PerformedBy    int64
+	Notes          string
+	CreatedAt      time.Time
+}
+
+func (CalibrationEventModel) TableName() string {
+	return "calibration_events"
+}
+
+type IdempotencyKeyModel struct {
+	Scope        string `gorm:"primaryKey"`
+	Key          string `gorm:"primaryKey"`
+	RequestHash  string
+	StatusCode   *int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+}
+
+func (IdempotencyKeyModel) TableName() string {
+	return "idempotency_keys"
+}
+
+type ScanJobModel struct {
+	Id        string
+	UserId    int64
+	ToolSetId int64
+	BadgeId   *int64
+	KioskId   *int64
+	// RequestedBy пользователь, поставивший задание; только ему доступно его состояние
+	RequestedBy   int64
+	TransactionId *int64
+	ScanId        *int64
+	ImageData     string
+	Status        domain.ScanJobStatus
+	Result        []byte `gorm:"type:jsonb"`
+	Error         *string
+	Attempts      int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+}
+
+func (ScanJobModel) TableName() string {
+	return "scan_jobs"
+}
+
+type MLClassMappingModel struct {
+	Id           int64
+	ModelVersion string
+	ClassIndex   int64
+	ToolTypeId   int64
+	CreatedAt    time.Time
+
+	ToolType *ToolTypeModel `gorm:"foreignKey:ToolTypeId;references:Id"`
+}
+
+func (MLClassMappingModel) TableName() string {
+	return "ml_class_mappings"
+}
+
+type ShadowScanModel struct {
+	Id            int64
+	CvScanId      int64
+	ModelVersion  *string
+	DebugImageUrl string
+	Status        *domain.Status
+	PrimaryStatus domain.Status
+	Error         *string
+	CreatedAt     time.Time
+
+	Detections []*ShadowScanDetailModel `gorm:"foreignKey:ShadowScanId"`
+}
+
+func (ShadowScanModel) TableName() string {
+	return "shadow_scans"
+}
+
+type ShadowScanDetailModel struct {
+	Id           int64
+	ShadowScanId int64
+	ClassIndex   int64
+	ToolTypeId   *int64
+	Confidence   float32
+	Bbox         pq.Float64Array `gorm:"type:double precision[]"`
+}
+
+func (ShadowScanDetailModel) TableName() string {
+	return "shadow_scan_details"
+}
+
+type ToolSetThresholdModel struct {
+	FamilyId            int64 `gorm:"primaryKey;autoIncrement:false"`
+	ToolTypeId          int64 `gorm:"primaryKey;autoIncrement:false"`
+	ConfidenceThreshold *float32
+	CosineSimThreshold  *float32
+}
+
+func (ToolSetThresholdModel) TableName() string {
+	return "tool_set_thresholds"
+}
+
+type BoardSlotModel struct {
+	Id         int64
+	FamilyId   int64
+	ToolTypeId int64
+	Polygon    pq.Float64Array `gorm:"type:double precision[]"`
+	CreatedAt  time.Time
+}
+
+func (BoardSlotModel) TableName() string {
+	return "board_slots"
+}
+
+type QAQueueItemModel struct {
+	TransactionId  int64 `gorm:"primaryKey;autoIncrement:false"`
+	EnqueuedAt     time.Time
+	AssigneeId     *int64
+	AssignedAt     *time.Time
+	ClaimedBy      *int64
+	ClaimExpiresAt *time.Time
+	EscalatedAt    *time.Time
+
+	Transaction *TransactionModel `gorm:"foreignKey:TransactionId;references:Id"`
+	Assignee    *UserModel        `gorm:"foreignKey:AssigneeId;references:Id"`
+	Claimer     *UserModel        `gorm:"foreignKey:ClaimedBy;references:Id"`
+}
+
+func (QAQueueItemModel) TableName() string {
+	return "qa_queue_items"
+}
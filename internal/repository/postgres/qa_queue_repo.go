@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QAQueueRepository struct {
+	DB *gorm.DB
+}
+
+func NewQAQueueRepository(db *gorm.DB) *QAQueueRepository {
+	return &QAQueueRepository{
+		DB: db,
+	}
+}
+
+// Enqueue ставит транзакцию в очередь; если она уже в очереди, время постановки и назначение сохраняются
+func (q *QAQueueRepository) Enqueue(ctx context.Context, item *domain.QAQueueItem) error {
+	const op = "QAQueueRepository.Enqueue"
+
+	model := toQAQueueItemModel(item)
+	result := q.DB.WithContext(ctx).Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(model)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (q *QAQueueRepository) Remove(ctx context.Context, transactionId int64) error {
+	const op = "QAQueueRepository.Remove"
+
+	if err := q.DB.WithContext(ctx).Delete(&QAQueueItemModel{}, "transaction_id = ?", transactionId).Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// GetByTransactionIdForUpdate блокирует строку очереди до конца транзакции БД, чтобы захват, назначение
+// и решение по одной транзакции не выполнялись параллельно
+func (q *QAQueueRepository) GetByTransactionIdForUpdate(ctx context.Context, transactionId int64) (*domain.QAQueueItem, error) {
+	const op = "QAQueueRepository.GetByTransactionIdForUpdate"
+
+	var model QAQueueItemModel
+	result := q.DB.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Transaction.User").Preload("Assignee").Preload("Claimer").
+		First(&model, "transaction_id = ?", transactionId)
+	if err := checkGetQueryResult(result, e.ErrQAQueueItemNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainQAQueueItem(&model), nil
+}
+
+func (q *QAQueueRepository) Update(ctx context.Context, item *domain.QAQueueItem) error {
+	const op = "QAQueueRepository.Update"
+
+	updates := map[string]interface{}{
+		"assignee_id":      item.AssigneeId,
+		"assigned_at":      item.AssignedAt,
+		"claimed_by":       item.ClaimedBy,
+		"claim_expires_at": item.ClaimExpiresAt,
+	}
+
+	result := q.DB.WithContext(ctx).Model(&QAQueueItemModel{}).Where("transaction_id = ?", item.TransactionId).Updates(updates)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrQAQueueItemNotFound)
+	}
+
+	return nil
+}
+
+// List возвращает очередь от самых давних элементов к новым
+func (q *QAQueueRepository) List(ctx context.Context, filter repository.QAQueueFilter) ([]*domain.QAQueueItem, error) {
+	const op = "QAQueueRepository.List"
+
+	query := q.DB.WithContext(ctx).Preload("Transaction.User").Preload("Assignee").Preload("Claimer")
+	if filter.AssigneeId != nil {
+		query = query.Where("assignee_id = ?", *filter.AssigneeId)
+	}
+
+	var models []*QAQueueItemModel
+	if err := query.Order("enqueued_at, transaction_id").Find(&models).Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	items := make([]*domain.QAQueueItem, len(models))
+	for i, model := range models {
+		items[i] = toDomainQAQueueItem(model)
+	}
+
+	return items, nil
+}
+
+func (q *QAQueueRepository) EscalateOverdue(ctx context.Context, enqueuedBefore, now time.Time) (int64, error) {
+	const op = "QAQueueRepository.EscalateOverdue"
+
+	result := q.DB.WithContext(ctx).
+		Model(&QAQueueItemModel{}).
+		Where("escalated_at IS NULL AND enqueued_at < ?", enqueuedBefore).
+		Update("escalated_at", now)
+	if err := result.Error; err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func toQAQueueItemModel(i *domain.QAQueueItem) *QAQueueItemModel {
+	return &QAQueueItemModel{
+		TransactionId:  i.TransactionId,
+		EnqueuedAt:     i.EnqueuedAt,
+		AssigneeId:     i.AssigneeId,
+		AssignedAt:     i.AssignedAt,
+		ClaimedBy:      i.ClaimedBy,
+		ClaimExpiresAt: i.ClaimExpiresAt,
+		EscalatedAt:    i.EscalatedAt,
+	}
+}
+
+func toDomainQAQueueItem(m *QAQueueItemModel) *domain.QAQueueItem {
+	item := &domain.QAQueueItem{
+		TransactionId:  m.TransactionId,
+		EnqueuedAt:     m.EnqueuedAt,
+		AssigneeId:     m.AssigneeId,
+		AssignedAt:     m.AssignedAt,
+		ClaimedBy:      m.ClaimedBy,
+		ClaimExpiresAt: m.ClaimExpiresAt,
+		EscalatedAt:    m.EscalatedAt,
+		Transaction:    toDomainTransaction(m.Transaction),
+	}
+
+	if m.Assignee != nil {
+		item.Assignee = toDomainUser(m.Assignee)
+	}
+
+	if m.Claimer != nil {
+		item.Claimer = toDomainUser(m.Claimer)
+	}
+
+	return item
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScanJobRepository struct {
+	DB *gorm.DB
+}
+
+func NewScanJobRepository(db *gorm.DB) *ScanJobRepository {
+	return &ScanJobRepository{
+		DB: db,
+	}
+}
+
+func (s *ScanJobRepository) Create(ctx context.Context, job *domain.ScanJob) (*domain.ScanJob, error) {
+	const op = "ScanJobRepository.Create"
+
+	model := toScanJobModel(job)
+	if err := s.DB.WithContext(ctx).Create(model).Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainScanJob(model), nil
+}
+
+func (s *ScanJobRepository) GetById(ctx context.Context, id string) (*domain.ScanJob, error) {
+	const op = "ScanJobRepository.GetById"
+
+	var model ScanJobModel
+	result := s.DB.WithContext(ctx).Omit("ImageData").First(&model, "id = ?", id)
+	if err := checkGetQueryResult(result, e.ErrScanJobNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainScanJob(&model), nil
+}
+
+// ClaimNext переводит задание в RUNNING через FOR UPDATE SKIP LOCKED, поэтому воркеры не блокируют друг друга
+func (s *ScanJobRepository) ClaimNext(ctx context.Context) (*domain.ScanJob, error) {
+	const op = "ScanJobRepository.ClaimNext"
+
+	now := time.Now().UTC()
+	var model ScanJobModel
+	result := s.DB.WithContext(ctx).Raw(`
+		UPDATE scan_jobs SET status = ?, attempts = attempts + 1, started_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM scan_jobs
+			WHERE status = ?
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`, domain.JobRunning, now, now, domain.JobPending).Scan(&model)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, e.Wrap(op, e.ErrScanJobNotFound)
+	}
+
+	return toDomainScanJob(&model), nil
+}
+
+// RecordScan сохраняет транзакцию и скан проверки задания. Запись проходит, только пока задание выполняется
+// в той же попытке, иначе ErrScanJobNotRunning: результат устаревшей попытки не должен фиксироваться
+func (s *ScanJobRepository) RecordScan(ctx context.Context, job *domain.ScanJob) error {
+	const op = "ScanJobRepository.RecordScan"
+
+	updates := map[string]interface{}{
+		"transaction_id": job.TransactionId,
+		"scan_id":        job.ScanId,
+		"updated_at":     time.Now().UTC(),
+	}
+
+	result := s.DB.WithContext(ctx).Model(&ScanJobModel{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.Id, domain.JobRunning, job.Attempts).
+		Updates(updates)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrScanJobNotRunning)
+	}
+
+	return nil
+}
+
+// Finish завершает попытку обработки задания. Если задание уже возвращено в очередь или завершено другой попыткой,
+// возвращает ErrScanJobNotRunning и ничего не меняет
+func (s *ScanJobRepository) Finish(ctx context.Context, job *domain.ScanJob) error {
+	const op = "ScanJobRepository.Finish"
+
+	updates := map[string]interface{}{
+		"status":      job.Status,
+		"result":      job.Result,
+		"error":       job.Error,
+		"image_data":  job.ImageData,
+		"finished_at": job.FinishedAt,
+		"updated_at":  time.Now().UTC(),
+	}
+
+	result := s.DB.WithContext(ctx).Model(&ScanJobModel{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.Id, domain.JobRunning, job.Attempts).
+		Updates(updates)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrScanJobNotRunning)
+	}
+
+	return nil
+}
+
+func (s *ScanJobRepository) RequeueStale(ctx context.Context, startedBefore time.Time, maxAttempts int) (int64, error) {
+	const op = "ScanJobRepository.RequeueStale"
+
+	now := time.Now().UTC()
+	result := s.DB.WithContext(ctx).Exec(`
+		UPDATE scan_jobs SET
+			status = CASE WHEN attempts >= ? THEN ? ELSE ? END,
+			error = CASE WHEN attempts >= ? THEN 'processing attempts exhausted' ELSE error END,
+			image_data = CASE WHEN attempts >= ? THEN '' ELSE image_data END,
+			finished_at = CASE WHEN attempts >= ? THEN ?::timestamp ELSE NULL END,
+			updated_at = ?
+		WHERE status = ? AND started_at < ?`,
+		maxAttempts, domain.JobFailed, domain.JobPending,
+		maxAttempts,
+		maxAttempts,
+		maxAttempts, now,
+		now,
+		domain.JobRunning, startedBefore)
+	if err := result.Error; err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func toScanJobModel(j *domain.ScanJob) *ScanJobModel {
+	return &ScanJobModel{
+		Id:            j.Id,
+		UserId:        j.UserId,
+		ToolSetId:     j.ToolSetId,
+		BadgeId:       j.BadgeId,
+		KioskId:       j.KioskId,
+		RequestedBy:   j.RequestedBy,
+		TransactionId: j.TransactionId,
+		ScanId:        j.ScanId,
+		ImageData:     j.ImageData,
+		Status:        j.Status,
+		Result:        j.Result,
+		Error:         j.Error,
+		Attempts:      j.Attempts,
+		CreatedAt:     j.CreatedAt,
+		UpdatedAt:     j.UpdatedAt,
+		StartedAt:     j.StartedAt,
+		FinishedAt:    j.FinishedAt,
+	}
+}
+
+func toDomainScanJob(m *ScanJobModel) *domain.ScanJob {
+	return &domain.ScanJob{
+		Id:            m.Id,
+		UserId:        m.UserId,
+		ToolSetId:     m.ToolSetId,
+		BadgeId:       m.BadgeId,
+		KioskId:       m.KioskId,
+		RequestedBy:   m.RequestedBy,
+		TransactionId: m.TransactionId,
+		ScanId:        m.ScanId,
+		ImageData:     m.ImageData,
+		Status:        m.Status,
+		Result:        m.Result,
+		Error:         m.Error,
+		Attempts:      m.Attempts,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		StartedAt:     m.StartedAt,
+		FinishedAt:    m.FinishedAt,
+	}
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"strings"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type ShadowScanRepository struct {
+	DB *gorm.DB
+}
+
+func NewShadowScanRepository(db *gorm.DB) *ShadowScanRepository {
+	return &ShadowScanRepository{
+		DB: db,
+	}
+}
+
+func (s *ShadowScanRepository) Create(ctx context.Context, scan *domain.ShadowScan) (*domain.ShadowScan, error) {
+	const op = "ShadowScanRepository.Create"
+
+	model := toShadowScanModel(scan)
+	if err := s.DB.WithContext(ctx).Create(model).Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainShadowScan(model), nil
+}
+
+// shadowPerTypeCounts число детекций каждого типа в каждом успешном теневом скане у основной (primary_count)
+// и теневой (shadow_count) моделей. Ожидает CTE scans с отобранными теневыми сканами
+const shadowPerTypeCounts = `
+	primary_counts AS (
+		SELECT s.id AS shadow_scan_id, d.detected_tool_type_id AS tool_type_id, COUNT(*) AS cnt
+		FROM scans s
+		JOIN cv_scan_details d ON d.cv_scan_id = s.cv_scan_id
+		WHERE d.detected_tool_type_id IS NOT NULL
+		GROUP BY s.id, d.detected_tool_type_id
+	),
+	shadow_counts AS (
+		SELECT s.id AS shadow_scan_id, sd.tool_type_id, COUNT(*) AS cnt
+		FROM scans s
+		JOIN shadow_scan_details sd ON sd.shadow_scan_id = s.id
+		WHERE sd.tool_type_id IS NOT NULL
+		GROUP BY s.id, sd.tool_type_id
+	),
+	diff AS (
+		SELECT
+			COALESCE(p.shadow_scan_id, sh.shadow_scan_id) AS shadow_scan_id,
+			COALESCE(p.tool_type_id, sh.tool_type_id) AS tool_type_id,
+			COALESCE(p.cnt, 0) AS primary_count,
+			COALESCE(sh.cnt, 0) AS shadow_count
+		FROM primary_counts p
+		FULL JOIN shadow_counts sh ON sh.shadow_scan_id = p.shadow_scan_id AND sh.tool_type_id = p.tool_type_id
+	)`
+
+// GetComparison сравнивает детекции и статусы основной и теневой моделей на одних и тех же изображениях.
+// Сканы, на которых вызов теневой модели завершился ошибкой, учитываются только в FailedCount
+func (s *ShadowScanRepository) GetComparison(ctx context.Context, filter repository.ShadowReportFilter) (*repository.ShadowComparison, error) {
+	const op = "ShadowScanRepository.GetComparison"
+
+	where, args := shadowReportConditions(filter)
+	scans := `WITH scans AS (
+		SELECT ss.id, ss.cv_scan_id, ss.status, ss.primary_status
+		FROM shadow_scans ss
+		WHERE ss.error IS NULL` + where + `
+	),` + shadowPerTypeCounts
+
+	var res repository.ShadowComparison
+	db := s.DB.WithContext(ctx)
+
+	result := db.Raw(`
+		SELECT
+			COUNT(*) AS scans_count,
+			COUNT(*) FILTER (WHERE ss.error IS NOT NULL) AS failed_count
+		FROM shadow_scans ss
+		WHERE TRUE`+where, args...).Scan(&res.Summary)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	var agreement struct {
+		AgreedScans       int64
+		StatusAgreedScans int64
+	}
+	result = db.Raw(scans+`
+		SELECT
+			COUNT(*) FILTER (WHERE NOT EXISTS (
+				SELECT 1 FROM diff WHERE diff.shadow_scan_id = scans.id AND diff.primary_count <> diff.shadow_count
+			)) AS agreed_scans,
+			COUNT(*) FILTER (WHERE scans.status = scans.primary_status) AS status_agreed_scans
+		FROM scans
+	`, args...).Scan(&agreement)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	res.Summary.AgreedScans = agreement.AgreedScans
+	res.Summary.StatusAgreedScans = agreement.StatusAgreedScans
+
+	result = db.Raw(`
+		SELECT ss.primary_status, ss.status AS shadow_status, COUNT(*) AS count
+		FROM shadow_scans ss
+		WHERE ss.error IS NULL`+where+`
+		GROUP BY ss.primary_status, ss.status
+		ORDER BY count DESC
+	`, args...).Scan(&res.Statuses)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	result = db.Raw(scans+`
+		SELECT
+			diff.tool_type_id,
+			tt.name AS tool_type_name,
+			SUM(diff.primary_count) AS primary_count,
+			SUM(diff.shadow_count) AS shadow_count,
+			COUNT(*) FILTER (WHERE diff.primary_count <> diff.shadow_count) AS scans_with_diff
+		FROM diff
+		JOIN tool_types tt ON tt.id = diff.tool_type_id
+		GROUP BY diff.tool_type_id, tt.name
+		ORDER BY scans_with_diff DESC, diff.tool_type_id
+	`, args...).Scan(&res.ToolTypes)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return &res, nil
+}
+
+// shadowReportConditions условия фильтра по таблице shadow_scans с псевдонимом ss
+func shadowReportConditions(filter repository.ShadowReportFilter) (string, []interface{}) {
+	var conditions strings.Builder
+	args := make([]interface{}, 0, 3)
+
+	if filter.ModelVersion != nil {
+		conditions.WriteString(" AND ss.model_version = ?")
+		args = append(args, *filter.ModelVersion)
+	}
+	if filter.From != nil {
+		conditions.WriteString(" AND ss.created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions.WriteString(" AND ss.created_at < ?")
+		args = append(args, *filter.To)
+	}
+
+	return conditions.String(), args
+}
+
+func toShadowScanModel(s *domain.ShadowScan) *ShadowScanModel {
+	model := &ShadowScanModel{
+		Id:            s.Id,
+		CvScanId:      s.CvScanId,
+		ModelVersion:  s.ModelVersion,
+		DebugImageUrl: s.DebugImageUrl,
+		Status:        s.Status,
+		PrimaryStatus: s.PrimaryStatus,
+		Error:         s.Error,
+		CreatedAt:     s.CreatedAt,
+	}
+
+	model.Detections = make([]*ShadowScanDetailModel, len(s.Detections))
+	for i, detection := range s.Detections {
+		bbox := make(pq.Float64Array, len(detection.Bbox))
+		for j, f := range detection.Bbox {
+			bbox[j] = float64(f)
+		}
+
+		model.Detections[i] = &ShadowScanDetailModel{
+			Id:           detection.Id,
+			ShadowScanId: detection.ShadowScanId,
+			ClassIndex:   detection.ClassIndex,
+			ToolTypeId:   detection.ToolTypeId,
+			Confidence:   detection.Confidence,
+			Bbox:         bbox,
+		}
+	}
+
+	return model
+}
+
+func toDomainShadowScan(m *ShadowScanModel) *domain.ShadowScan {
+	scan := &domain.ShadowScan{
+		Id:            m.Id,
+		CvScanId:      m.CvScanId,
+		ModelVersion:  m.ModelVersion,
+		DebugImageUrl: m.DebugImageUrl,
+		Status:        m.Status,
+		PrimaryStatus: m.PrimaryStatus,
+		Error:         m.Error,
+		CreatedAt:     m.CreatedAt,
+	}
+
+	scan.Detections = make([]*domain.ShadowScanDetail, len(m.Detections))
+	for i, detection := range m.Detections {
+		bbox := make([]float32, len(detection.Bbox))
+		for j, f := range detection.Bbox {
+			bbox[j] = float32(f)
+		}
+
+		scan.Detections[i] = &domain.ShadowScanDetail{
+			Id:           detection.Id,
+			ShadowScanId: detection.ShadowScanId,
+			ClassIndex:   detection.ClassIndex,
+			ToolTypeId:   detection.ToolTypeId,
+			Confidence:   detection.Confidence,
+			Bbox:         bbox,
+		}
+	}
+
+	return scan
+}
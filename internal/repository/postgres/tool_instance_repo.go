@@ -0,0 +1,323 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/pkg/e"
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ToolInstanceRepository struct {
+	DB *gorm.DB
+}
+
+func NewToolInstanceRepository(db *gorm.DB) *ToolInstanceRepository {
+	return &ToolInstanceRepository{
+		DB: db,
+	}
+}
+
+func (t *ToolInstanceRepository) Create(ctx context.Context, instance *domain.ToolInstance) (*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.Create"
+
+	model := toToolInstanceModel(instance)
+	result := t.DB.WithContext(ctx).Omit("ToolType").Create(model)
+	if err := postgresDuplicate(result, e.ErrToolInstanceExists); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainToolInstance(model), nil
+}
+
+func (t *ToolInstanceRepository) GetById(ctx context.Context, id int64) (*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetById"
+
+	var model ToolInstanceModel
+	result := t.DB.WithContext(ctx).Preload("ToolType").First(&model, "id = ?", id)
+	if err := checkGetQueryResult(result, e.ErrToolInstanceNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainToolInstance(&model), nil
+}
+
+func (t *ToolInstanceRepository) GetAll(ctx context.Context, filter repository.ToolInstanceFilter) ([]*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetAll"
+
+	query := t.DB.WithContext(ctx).Preload("ToolType")
+	if filter.ToolTypeId != nil {
+		query = query.Where("tool_type_id = ?", *filter.ToolTypeId)
+	}
+	if filter.HomeToolSetId != nil {
+		query = query.Where("home_tool_set_id = ?", *filter.HomeToolSetId)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+
+	var models []*ToolInstanceModel
+	if err := query.Order("id").Find(&models).Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolInstance(models), nil
+}
+
+func (t *ToolInstanceRepository) Update(ctx context.Context, instance *domain.ToolInstance) (*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.Update"
+
+	updates := map[string]interface{}{
+		"status":           instance.Status,
+		"home_tool_set_id": instance.HomeToolSetId,
+		"updated_at":       time.Now().UTC(),
+	}
+
+	var updInstance ToolInstanceModel
+	result := t.DB.WithContext(ctx).Model(&ToolInstanceModel{}).Where("id = ?", instance.Id).Updates(updates).Scan(&updInstance)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, e.Wrap(op, e.ErrToolInstanceNotFound)
+	}
+
+	return toDomainToolInstance(&updInstance), nil
+}
+
+func (t *ToolInstanceRepository) GetAllByHomeToolSet(ctx context.Context, homeToolSetId int64) ([]*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetAllByHomeToolSet"
+
+	var models []*ToolInstanceModel
+	result := t.DB.WithContext(ctx).Preload("ToolType").Where("home_tool_set_id = ?", homeToolSetId).Order("id").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolInstance(models), nil
+}
+
+// GetAllByHomeToolSetForUpdate возвращает экземпляры набора и блокирует их строки до конца транзакции,
+// чтобы параллельные выдачи одного набора подбирали экземпляры по очереди
+func (t *ToolInstanceRepository) GetAllByHomeToolSetForUpdate(ctx context.Context, homeToolSetId int64) ([]*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetAllByHomeToolSetForUpdate"
+
+	var models []*ToolInstanceModel
+	result := t.DB.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("ToolType").
+		Where("home_tool_set_id = ?", homeToolSetId).
+		Order("id").
+		Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolInstance(models), nil
+}
+
+// GetIssuedIds возвращает экземпляры набора, которые сейчас числятся выданными по другим незакрытым транзакциям
+func (t *ToolInstanceRepository) GetIssuedIds(ctx context.Context, homeToolSetId, excludeTransactionId int64) (map[int64]bool, error) {
+	const op = "ToolInstanceRepository.GetIssuedIds"
+
+	var ids []int64
+	result := t.DB.WithContext(ctx).
+		Table("transaction_instances AS ti").
+		Joins("JOIN transactions t ON t.id = ti.transaction_id").
+		Joins("JOIN tool_instances i ON i.id = ti.tool_instance_id").
+		Where("i.home_tool_set_id = ? AND t.id <> ? AND t.status IN ?", homeToolSetId, excludeTransactionId, []domain.Status{domain.OPEN, domain.QA}).
+		Pluck("ti.tool_instance_id", &ids)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	issued := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		issued[id] = true
+	}
+
+	return issued, nil
+}
+
+// AttachToTransaction заменяет список экземпляров, выданных по транзакции
+func (t *ToolInstanceRepository) AttachToTransaction(ctx context.Context, transactionId int64, instanceIds []int64) error {
+	const op = "ToolInstanceRepository.AttachToTransaction"
+
+	err := t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transaction_id = ?", transactionId).Delete(&TransactionInstanceModel{}).Error; err != nil {
+			return err
+		}
+
+		if len(instanceIds) == 0 {
+			return nil
+		}
+
+		models := make([]*TransactionInstanceModel, len(instanceIds))
+		for i, id := range instanceIds {
+			models[i] = &TransactionInstanceModel{
+				TransactionId:  transactionId,
+				ToolInstanceId: id,
+			}
+		}
+
+		return tx.Create(&models).Error
+	})
+	if err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (t *ToolInstanceRepository) GetAllByTransactionId(ctx context.Context, transactionId int64) ([]*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetAllByTransactionId"
+
+	var models []*ToolInstanceModel
+	result := t.DB.WithContext(ctx).
+		Preload("ToolType").
+		Joins("JOIN transaction_instances ti ON ti.tool_instance_id = tool_instances.id").
+		Where("ti.transaction_id = ?", transactionId).
+		Order("tool_instances.id").
+		Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolInstance(models), nil
+}
+
+// GetMlErrorInstances считает ошибки MODEL_ERR по экземплярам: ошибка относится к экземпляру,
+// если он был выдан по транзакции, а QA отметил ошибку модели на его типе.
+// Для каждого экземпляра возвращается разбивка по версиям модели
+func (t *ToolInstanceRepository) GetMlErrorInstances(ctx context.Context, filter repository.MlErrorFilter) ([]*repository.ToolInstanceWithErrorCount, error) {
+	const op = "ToolInstanceRepository.GetMlErrorInstances"
+
+	type instanceErrorCount struct {
+		Id           int64
+		SerialNumber string
+		ToolTypeId   int64
+		ToolTypeName string
+		ModelVersion *string
+		MLErrorCount int64
+	}
+
+	query := t.DB.WithContext(ctx).
+		Table("model_err_items AS mei").
+		Select("i.id AS id, i.serial_number, i.tool_type_id, tt.name AS tool_type_name, sv.model_version, COUNT(*) AS ml_error_count").
+		Joins("JOIN transaction_resolutions tr ON tr.id = mei.resolution_id").
+		Joins(resolutionModelVersionJoin).
+		Joins("JOIN transaction_instances ti ON ti.transaction_id = tr.transaction_id").
+		Joins("JOIN tool_instances i ON i.id = ti.tool_instance_id AND i.tool_type_id = mei.tool_type_id").
+		Joins("JOIN tool_types tt ON tt.id = i.tool_type_id")
+	if filter.ModelVersion != nil {
+		query = query.Where("sv.model_version = ?", *filter.ModelVersion)
+	}
+
+	var counts []instanceErrorCount
+	result := query.
+		Group("i.id, i.serial_number, i.tool_type_id, tt.name, sv.model_version").
+		Order("i.id, sv.model_version NULLS LAST").
+		Scan(&counts)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res := make([]*repository.ToolInstanceWithErrorCount, 0)
+	byId := make(map[int64]*repository.ToolInstanceWithErrorCount)
+	for _, c := range counts {
+		instance, ok := byId[c.Id]
+		if !ok {
+			instance = &repository.ToolInstanceWithErrorCount{
+				ID:             c.Id,
+				SerialNumber:   c.SerialNumber,
+				ToolTypeId:     c.ToolTypeId,
+				ToolTypeName:   c.ToolTypeName,
+				ByModelVersion: []repository.ModelVersionErrorCount{},
+			}
+			byId[c.Id] = instance
+			res = append(res, instance)
+		}
+
+		instance.MLErrorCount += c.MLErrorCount
+		instance.ByModelVersion = append(instance.ByModelVersion, repository.ModelVersionErrorCount{
+			ModelVersion: c.ModelVersion,
+			MLErrorCount: c.MLErrorCount,
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].MLErrorCount > res[j].MLErrorCount
+	})
+
+	return res, nil
+}
+
+// GetCalibrationDue возвращает экземпляры в обороте, срок поверки которых наступает до dueBefore,
+// а также ни разу не поверенные экземпляры типов с заданной периодичностью поверки
+func (t *ToolInstanceRepository) GetCalibrationDue(ctx context.Context, dueBefore time.Time) ([]*domain.ToolInstance, error) {
+	const op = "ToolInstanceRepository.GetCalibrationDue"
+
+	var models []*ToolInstanceModel
+	result := t.DB.WithContext(ctx).
+		Preload("ToolType").
+		Joins("JOIN tool_types tt ON tt.id = tool_instances.tool_type_id").
+		Where("tool_instances.status IN ?", []domain.ToolInstanceStatus{domain.InService, domain.Quarantined}).
+		Where("tool_instances.calibration_due_at < ? OR (tool_instances.calibration_due_at IS NULL AND tt.calibration_interval_days IS NOT NULL)", dueBefore).
+		Order("tool_instances.calibration_due_at NULLS FIRST, tool_instances.id").
+		Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolInstance(models), nil
+}
+
+func toToolInstanceModel(t *domain.ToolInstance) *ToolInstanceModel {
+	return &ToolInstanceModel{
+		Id:            t.Id,
+		SerialNumber:  t.SerialNumber,
+		ToolTypeId:    t.ToolTypeId,
+		HomeToolSetId: t.HomeToolSetId,
+		Status:        t.Status,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+
+		LastCalibratedAt: t.LastCalibratedAt,
+		CalibrationDueAt: t.CalibrationDueAt,
+	}
+}
+
+func toDomainToolInstance(m *ToolInstanceModel) *domain.ToolInstance {
+	instance := &domain.ToolInstance{
+		Id:            m.Id,
+		SerialNumber:  m.SerialNumber,
+		ToolTypeId:    m.ToolTypeId,
+		HomeToolSetId: m.HomeToolSetId,
+		Status:        m.Status,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+
+		LastCalibratedAt: m.LastCalibratedAt,
+		CalibrationDueAt: m.CalibrationDueAt,
+	}
+
+	if m.ToolType != nil {
+		instance.ToolType = toDomainToolType(m.ToolType)
+	}
+
+	return instance
+}
+
+func toArrDomainToolInstance(models []*ToolInstanceModel) []*domain.ToolInstance {
+	instances := make([]*domain.ToolInstance, len(models))
+	for i, m := range models {
+		instances[i] = toDomainToolInstance(m)
+	}
+
+	return instances
+}
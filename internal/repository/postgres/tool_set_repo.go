@@ -5,7 +5,9 @@ import (
 	"airport-tools-backend/pkg/e"
 	"context"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ToolSetRepository struct {
@@ -21,7 +23,7 @@ func (t *ToolSetRepository) Create(ctx context.Context, toolSet *domain.ToolSet)
 
 	model := toToolSetModel(toolSet)
 	result := t.DB.Where(model).Create(model)
-	if err := postgresDuplicate(result, e.ErrToolSetExists); err != nil {
+	if err := toolSetDuplicate(result); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
@@ -68,17 +70,18 @@ func (t *ToolSetRepository) Update(ctx context.Context, toolSet *domain.ToolSet)
 	const op = "ToolSetRepository.Update"
 
 	updates := map[string]interface{}{
-		"name": toolSet.Name,
+		"name":       toolSet.Name,
+		"retired_at": toolSet.RetiredAt,
 	}
 
 	var updSet ToolSetModel
 	result := t.DB.WithContext(ctx).Model(&ToolSetModel{}).Where("id = ?", toolSet.Id).Updates(updates).Scan(&updSet)
-	if err := result.Error; err != nil {
+	if err := toolSetDuplicate(result); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
 	if result.RowsAffected == 0 {
-		return nil, e.Wrap(op, e.ErrTransactionNotFound)
+		return nil, e.Wrap(op, e.ErrToolSetNotFound)
 	}
 
 	return toDomainToolSet(&updSet), nil
@@ -88,7 +91,7 @@ func (t *ToolSetRepository) GetByIdWithTools(ctx context.Context, id int64) (*do
 	const op = "ToolSetRepository.GetByIdWithTools"
 
 	var model ToolSetModel
-	result := t.DB.WithContext(ctx).Preload("Tools").First(&model, "id = ?", id)
+	result := t.DB.WithContext(ctx).Preload("Tools.References").Preload("Items").Preload("Thresholds").Preload("Slots").First(&model, "id = ?", id)
 	if err := checkGetQueryResult(result, e.ErrToolSetNotFound); err != nil {
 		return nil, e.Wrap(op, err)
 	}
@@ -99,26 +102,129 @@ func (t *ToolSetRepository) GetByIdWithTools(ctx context.Context, id int64) (*do
 func (t *ToolSetRepository) CreateWithTools(ctx context.Context, toolSet *domain.ToolSet, toolsIds []int64) (*domain.ToolSet, error) {
 	const op = "ToolSetRepository.CreateWithTools"
 
+	items := domain.ItemsFromToolIds(toolsIds)
+	uniqueIds := make([]int64, len(items))
+	for i, item := range items {
+		uniqueIds[i] = item.ToolTypeId
+	}
+
 	var tools []*ToolTypeModel
-	if err := t.DB.WithContext(ctx).Where("id IN ?", toolsIds).Find(&tools).Error; err != nil {
+	if err := t.DB.WithContext(ctx).Where("id IN ?", uniqueIds).Find(&tools).Error; err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
+	if len(tools) != len(uniqueIds) {
+		return nil, e.Wrap(op, e.ErrToolTypeNotFound)
+	}
+
 	model := toToolSetModel(toolSet)
+	model.IsLatest = true
+
+	err := t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// новая версия становится последней; название проверяется уникальным индексом по последним версиям
+		if model.FamilyId != 0 {
+			if err := tx.Model(&ToolSetModel{}).Where("family_id = ? AND is_latest", model.FamilyId).Update("is_latest", false).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Omit("Tools", "Items").Create(&model)
+		if err := toolSetDuplicate(result); err != nil {
+			return err
+		}
+
+		// первая версия набора открывает новое семейство с family_id = id
+		if model.FamilyId == 0 {
+			model.FamilyId = model.Id
+			if err := tx.Model(&ToolSetModel{}).Where("id = ?", model.Id).Update("family_id", model.FamilyId).Error; err != nil {
+				return err
+			}
+		}
+
+		// позиции вставляются явно, чтобы сохранить количество экземпляров каждого типа
+		itemModels := toArrToolSetItemModel(model.Id, items)
+		if err := tx.Create(&itemModels).Error; err != nil {
+			return err
+		}
+		model.Items = itemModels
+
+		return nil
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
 	model.Tools = tools
 
-	result := t.DB.WithContext(ctx).Create(&model)
-	if err := postgresDuplicate(result, e.ErrToolSetExists); err != nil {
+	return toDomainToolSet(model), nil
+}
+
+func (t *ToolSetRepository) GetLatestByFamilyIdWithTools(ctx context.Context, familyId int64) (*domain.ToolSet, error) {
+	const op = "ToolSetRepository.GetLatestByFamilyIdWithTools"
+
+	var model ToolSetModel
+	result := t.DB.WithContext(ctx).Preload("Tools.References").Preload("Items").Preload("Thresholds").Preload("Slots").Where("family_id = ?", familyId).Order("version DESC").First(&model)
+	if err := checkGetQueryResult(result, e.ErrToolSetNotFound); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	return toDomainToolSet(model), nil
+	return toDomainToolSet(&model), nil
+}
+
+func (t *ToolSetRepository) GetAllByFamilyId(ctx context.Context, familyId int64) ([]*domain.ToolSet, error) {
+	const op = "ToolSetRepository.GetAllByFamilyId"
+
+	var models []*ToolSetModel
+	result := t.DB.WithContext(ctx).Where("family_id = ?", familyId).Order("version DESC").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if len(models) == 0 {
+		return nil, e.Wrap(op, e.ErrToolSetNotFound)
+	}
+
+	return toArrDomainToolSet(models), nil
+}
+
+func (t *ToolSetRepository) GetAllLatest(ctx context.Context) ([]*domain.ToolSet, error) {
+	const op = "ToolSetRepository.GetAllLatest"
+
+	latestIds := t.DB.Model(&ToolSetModel{}).Select("DISTINCT ON (family_id) id").Order("family_id, version DESC")
+
+	var models []*ToolSetModel
+	result := t.DB.WithContext(ctx).Where("id IN (?)", latestIds).Order("family_id").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolSet(models), nil
+}
+
+// toolSetDuplicate различает нарушенные уникальные ограничения наборов: занятое название последней версии — ErrToolSetExists,
+// версия семейства, уже созданная параллельным редактированием, — ErrToolSetConcurrentEdit
+func toolSetDuplicate(result *gorm.DB) error {
+	constraint, ok := postgresDuplicateConstraint(result.Error)
+	if !ok {
+		return result.Error
+	}
+
+	switch constraint {
+	case "tool_sets_family_id_version_key", "tool_sets_latest_family_id_key":
+		return e.ErrToolSetConcurrentEdit
+	default:
+		return e.ErrToolSetExists
+	}
 }
 
 func toToolSetModel(t *domain.ToolSet) *ToolSetModel {
 	model := &ToolSetModel{
-		Id:   t.Id,
-		Name: t.Name,
+		Id:        t.Id,
+		FamilyId:  t.FamilyId,
+		Version:   t.Version,
+		Name:      t.Name,
+		RetiredAt: t.RetiredAt,
+		CreatedAt: t.CreatedAt,
 	}
 
 	if t.Tools != nil {
@@ -128,19 +234,114 @@ func toToolSetModel(t *domain.ToolSet) *ToolSetModel {
 	return model
 }
 
+// UpsertThreshold задаёт пороги типа инструмента для семейства набора, заменяя ранее заданные
+func (t *ToolSetRepository) UpsertThreshold(ctx context.Context, threshold *domain.ToolSetThreshold) error {
+	const op = "ToolSetRepository.UpsertThreshold"
+
+	model := &ToolSetThresholdModel{
+		FamilyId:            threshold.FamilyId,
+		ToolTypeId:          threshold.ToolTypeId,
+		ConfidenceThreshold: threshold.Confidence,
+		CosineSimThreshold:  threshold.CosineSim,
+	}
+
+	result := t.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "family_id"}, {Name: "tool_type_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"confidence_threshold", "cosine_sim_threshold"}),
+	}).Create(model)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// DeleteThreshold удаляет пороги типа инструмента для семейства набора; отсутствие порогов ошибкой не считается
+func (t *ToolSetRepository) DeleteThreshold(ctx context.Context, familyId, toolTypeId int64) error {
+	const op = "ToolSetRepository.DeleteThreshold"
+
+	result := t.DB.WithContext(ctx).Where("family_id = ? AND tool_type_id = ?", familyId, toolTypeId).Delete(&ToolSetThresholdModel{})
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ReplaceSlots заменяет раскладку ложемента семейства набора; пустой список удаляет раскладку
+func (t *ToolSetRepository) ReplaceSlots(ctx context.Context, familyId int64, slots []*domain.BoardSlot) ([]*domain.BoardSlot, error) {
+	const op = "ToolSetRepository.ReplaceSlots"
+
+	models := toArrBoardSlotModel(slots)
+	err := t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("family_id = ?", familyId).Delete(&BoardSlotModel{}).Error; err != nil {
+			return err
+		}
+
+		if len(models) == 0 {
+			return nil
+		}
+
+		return tx.Create(&models).Error
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainBoardSlot(models), nil
+}
+
 func toDomainToolSet(t *ToolSetModel) *domain.ToolSet {
 	set := &domain.ToolSet{
-		Id:   t.Id,
-		Name: t.Name,
+		Id:        t.Id,
+		FamilyId:  t.FamilyId,
+		Version:   t.Version,
+		Name:      t.Name,
+		RetiredAt: t.RetiredAt,
+		CreatedAt: t.CreatedAt,
 	}
 
 	if t.Tools != nil {
 		set.Tools = toArrDomainToolType(t.Tools)
 	}
 
+	if t.Items != nil {
+		set.Items = toArrDomainToolSetItem(t.Items)
+	}
+
+	if t.Thresholds != nil {
+		set.Thresholds = toArrDomainToolSetThreshold(t.Thresholds)
+	}
+
+	if t.Slots != nil {
+		set.Slots = toArrDomainBoardSlot(t.Slots)
+	}
+
 	return set
 }
 
+func toArrToolSetItemModel(toolSetId int64, items []*domain.ToolSetItem) []*ToolSetItemModel {
+	models := make([]*ToolSetItemModel, len(items))
+	for i, item := range items {
+		models[i] = &ToolSetItemModel{
+			ToolSetId:  toolSetId,
+			ToolTypeId: item.ToolTypeId,
+			Quantity:   item.Quantity,
+		}
+	}
+
+	return models
+}
+
+func toArrDomainToolSetItem(models []*ToolSetItemModel) []*domain.ToolSetItem {
+	items := make([]*domain.ToolSetItem, len(models))
+	for i, model := range models {
+		items[i] = domain.NewToolSetItem(model.ToolTypeId, model.Quantity)
+	}
+
+	return items
+}
+
 func toArrDomainToolSet(models []*ToolSetModel) []*domain.ToolSet {
 	sets := make([]*domain.ToolSet, len(models))
 	for i, model := range models {
@@ -149,3 +350,57 @@ func toArrDomainToolSet(models []*ToolSetModel) []*domain.ToolSet {
 
 	return sets
 }
+
+func toArrDomainToolSetThreshold(models []*ToolSetThresholdModel) []*domain.ToolSetThreshold {
+	thresholds := make([]*domain.ToolSetThreshold, len(models))
+	for i, model := range models {
+		thresholds[i] = &domain.ToolSetThreshold{
+			FamilyId:   model.FamilyId,
+			ToolTypeId: model.ToolTypeId,
+			ThresholdsOverride: domain.ThresholdsOverride{
+				Confidence: model.ConfidenceThreshold,
+				CosineSim:  model.CosineSimThreshold,
+			},
+		}
+	}
+
+	return thresholds
+}
+
+func toArrBoardSlotModel(slots []*domain.BoardSlot) []*BoardSlotModel {
+	models := make([]*BoardSlotModel, len(slots))
+	for i, slot := range slots {
+		polygon := make(pq.Float64Array, 0, len(slot.Polygon)*2)
+		for _, point := range slot.Polygon {
+			polygon = append(polygon, float64(point.X), float64(point.Y))
+		}
+
+		models[i] = &BoardSlotModel{
+			Id:         slot.Id,
+			FamilyId:   slot.FamilyId,
+			ToolTypeId: slot.ToolTypeId,
+			Polygon:    polygon,
+		}
+	}
+
+	return models
+}
+
+func toArrDomainBoardSlot(models []*BoardSlotModel) []*domain.BoardSlot {
+	slots := make([]*domain.BoardSlot, len(models))
+	for i, model := range models {
+		polygon := make([]domain.Point, len(model.Polygon)/2)
+		for j := range polygon {
+			polygon[j] = domain.Point{X: float32(model.Polygon[2*j]), Y: float32(model.Polygon[2*j+1])}
+		}
+
+		slots[i] = &domain.BoardSlot{
+			Id:         model.Id,
+			FamilyId:   model.FamilyId,
+			ToolTypeId: model.ToolTypeId,
+			Polygon:    polygon,
+		}
+	}
+
+	return slots
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+type ToolTypeReferenceRepository struct {
+	DB *gorm.DB
+}
+
+func NewToolTypeReferenceRepository(db *gorm.DB) *ToolTypeReferenceRepository {
+	return &ToolTypeReferenceRepository{
+		DB: db,
+	}
+}
+
+func (t *ToolTypeReferenceRepository) Create(ctx context.Context, reference *domain.ToolTypeReference) (*domain.ToolTypeReference, error) {
+	const op = "ToolTypeReferenceRepository.Create"
+
+	model := toToolTypeReferenceModel(reference)
+	result := t.DB.WithContext(ctx).Create(model)
+	if err := postgresForeignKeyViolation(result, e.ErrToolTypeNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainToolTypeReference(model), nil
+}
+
+func (t *ToolTypeReferenceRepository) GetAllByToolTypeId(ctx context.Context, toolTypeId int64) ([]*domain.ToolTypeReference, error) {
+	const op = "ToolTypeReferenceRepository.GetAllByToolTypeId"
+
+	var models []*ToolTypeReferenceModel
+	result := t.DB.WithContext(ctx).Where("tool_type_id = ?", toolTypeId).Order("id").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolTypeReference(models), nil
+}
+
+func (t *ToolTypeReferenceRepository) Delete(ctx context.Context, toolTypeId, id int64) error {
+	const op = "ToolTypeReferenceRepository.Delete"
+
+	result := t.DB.WithContext(ctx).Where("id = ? AND tool_type_id = ?", id, toolTypeId).Delete(&ToolTypeReferenceModel{})
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrToolTypeReferenceNotFound)
+	}
+
+	return nil
+}
+
+func toToolTypeReferenceModel(r *domain.ToolTypeReference) *ToolTypeReferenceModel {
+	return &ToolTypeReferenceModel{
+		Id:         r.Id,
+		ToolTypeId: r.ToolTypeId,
+		Label:      r.Label,
+		Embedding:  pgvector.NewVector(r.Embedding),
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func toDomainToolTypeReference(m *ToolTypeReferenceModel) *domain.ToolTypeReference {
+	return &domain.ToolTypeReference{
+		Id:         m.Id,
+		ToolTypeId: m.ToolTypeId,
+		Label:      m.Label,
+		Embedding:  m.Embedding.Slice(),
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+func toArrDomainToolTypeReference(models []*ToolTypeReferenceModel) []*domain.ToolTypeReference {
+	references := make([]*domain.ToolTypeReference, len(models))
+	for i, m := range models {
+		references[i] = toDomainToolTypeReference(m)
+	}
+
+	return references
+}
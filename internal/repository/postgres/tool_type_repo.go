@@ -69,6 +69,10 @@ func (t *ToolTypeRepository) Delete(ctx context.Context, id int64) error {
 		return e.Wrap(op, err)
 	}
 
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrToolTypeNotFound)
+	}
+
 	return nil
 }
 
@@ -76,7 +80,10 @@ func (t *ToolTypeRepository) Update(ctx context.Context, toolType *domain.ToolTy
 	const op = "ToolTypeRepository.Update"
 
 	updates := map[string]interface{}{
-		"name": toolType.Name,
+		"name":                      toolType.Name,
+		"calibration_interval_days": toolType.CalibrationIntervalDays,
+		"confidence_threshold":      toolType.Thresholds.Confidence,
+		"cosine_sim_threshold":      toolType.Thresholds.CosineSim,
 	}
 
 	var updToolType ToolTypeModel
@@ -92,12 +99,36 @@ func (t *ToolTypeRepository) Update(ctx context.Context, toolType *domain.ToolTy
 	return toDomainToolType(&updToolType), nil
 }
 
+func (t *ToolTypeRepository) UpdateReferenceEmbedding(ctx context.Context, id int64, embedding []float32) (*domain.ToolType, error) {
+	const op = "ToolTypeRepository.UpdateReferenceEmbedding"
+
+	updates := map[string]interface{}{
+		"reference_embedding": pgvector.NewVector(embedding),
+	}
+
+	var updToolType ToolTypeModel
+	result := t.DB.WithContext(ctx).Model(&ToolTypeModel{}).Where("id = ?", id).Updates(updates).Scan(&updToolType)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, e.Wrap(op, e.ErrToolTypeNotFound)
+	}
+
+	return toDomainToolType(&updToolType), nil
+}
+
 func toToolTypeModel(t *domain.ToolType) *ToolTypeModel {
 	return &ToolTypeModel{
 		Id:                 t.Id,
 		PartNumber:         t.PartNumber,
 		Name:               t.Name,
 		ReferenceEmbedding: pgvector.NewVector(t.ReferenceEmbedding),
+
+		CalibrationIntervalDays: t.CalibrationIntervalDays,
+		ConfidenceThreshold:     t.Thresholds.Confidence,
+		CosineSimThreshold:      t.Thresholds.CosineSim,
 	}
 }
 
@@ -107,6 +138,13 @@ func toDomainToolType(t *ToolTypeModel) *domain.ToolType {
 		PartNumber:         t.PartNumber,
 		Name:               t.Name,
 		ReferenceEmbedding: t.ReferenceEmbedding.Slice(),
+		References:         toArrDomainToolTypeReference(t.References),
+
+		CalibrationIntervalDays: t.CalibrationIntervalDays,
+		Thresholds: domain.ThresholdsOverride{
+			Confidence: t.ConfidenceThreshold,
+			CosineSim:  t.CosineSimThreshold,
+		},
 	}
 }
 
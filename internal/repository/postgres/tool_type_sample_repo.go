@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/pkg/e"
+	"context"
+
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+type ToolTypeSampleRepository struct {
+	DB *gorm.DB
+}
+
+func NewToolTypeSampleRepository(db *gorm.DB) *ToolTypeSampleRepository {
+	return &ToolTypeSampleRepository{
+		DB: db,
+	}
+}
+
+func (t *ToolTypeSampleRepository) Create(ctx context.Context, sample *domain.ToolTypeSample) (*domain.ToolTypeSample, error) {
+	const op = "ToolTypeSampleRepository.Create"
+
+	model := toToolTypeSampleModel(sample)
+	result := t.DB.WithContext(ctx).Create(model)
+	if err := postgresForeignKeyViolation(result, e.ErrToolTypeNotFound); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toDomainToolTypeSample(model), nil
+}
+
+func (t *ToolTypeSampleRepository) GetAllByToolTypeId(ctx context.Context, toolTypeId int64) ([]*domain.ToolTypeSample, error) {
+	const op = "ToolTypeSampleRepository.GetAllByToolTypeId"
+
+	var models []*ToolTypeSampleModel
+	result := t.DB.WithContext(ctx).Where("tool_type_id = ?", toolTypeId).Order("id").Find(&models)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrDomainToolTypeSample(models), nil
+}
+
+func toToolTypeSampleModel(s *domain.ToolTypeSample) *ToolTypeSampleModel {
+	return &ToolTypeSampleModel{
+		Id:         s.Id,
+		ToolTypeId: s.ToolTypeId,
+		ImageUrl:   s.ImageUrl,
+		Confidence: s.Confidence,
+		Embedding:  pgvector.NewVector(s.Embedding),
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func toDomainToolTypeSample(m *ToolTypeSampleModel) *domain.ToolTypeSample {
+	return &domain.ToolTypeSample{
+		Id:         m.Id,
+		ToolTypeId: m.ToolTypeId,
+		ImageUrl:   m.ImageUrl,
+		Confidence: m.Confidence,
+		Embedding:  m.Embedding.Slice(),
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+func toArrDomainToolTypeSample(models []*ToolTypeSampleModel) []*domain.ToolTypeSample {
+	samples := make([]*domain.ToolTypeSample, len(models))
+	for i, m := range models {
+		samples[i] = toDomainToolTypeSample(m)
+	}
+
+	return samples
+}
@@ -24,7 +24,7 @@ func (t *TransactionRepository) Create(ctx context.Context, transaction *domain.
 
 	model := toTransactionModel(transaction)
 	result := t.DB.WithContext(ctx).Create(model)
-	if err := result.Error; err != nil {
+	if err := postgresDuplicate(result, e.ErrTransactionConcurrentCheck); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
@@ -202,13 +202,16 @@ func (t *TransactionRepository) Update(ctx context.Context, transaction *domain.
 
 	updates := map[string]interface{}{
 		"status":          transaction.Status,
+		"tool_set_id":     transaction.ToolSetId,
 		"updated_at":      time.Now().UTC(),
 		"count_of_checks": transaction.CountOfChecks,
+		"badge_id":        transaction.BadgeId,
+		"kiosk_id":        transaction.KioskId,
 	}
 
 	var updTransaction TransactionModel
 	result := t.DB.WithContext(ctx).Model(&TransactionModel{}).Where("id = ?", transaction.Id).Updates(updates).Scan(&updTransaction)
-	if err := result.Error; err != nil {
+	if err := postgresDuplicate(result, e.ErrTransactionConcurrentCheck); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
@@ -219,12 +222,26 @@ func (t *TransactionRepository) Update(ctx context.Context, transaction *domain.
 	return toDomainTransaction(&updTransaction), nil
 }
 
+// LockUser берёт транзакционную advisory-блокировку по пользователю: параллельная проверка того же пользователя
+// ждёт фиксации или отката текущей транзакции БД
+func (t *TransactionRepository) LockUser(ctx context.Context, userId int64) error {
+	const op = "TransactionRepository.LockUser"
+
+	if err := t.DB.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", userId).Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
 func toTransactionModel(t *domain.Transaction) *TransactionModel {
 	model := &TransactionModel{
 		Id:            t.Id,
 		UserId:        t.UserId,
 		ToolSetId:     t.ToolSetId,
 		CountOfChecks: t.CountOfChecks,
+		BadgeId:       t.BadgeId,
+		KioskId:       t.KioskId,
 		Status:        t.Status,
 		CreatedAt:     t.CreatedAt,
 		UpdatedAt:     t.UpdatedAt,
@@ -251,6 +268,8 @@ func toDomainTransaction(t *TransactionModel) *domain.Transaction {
 		UserId:        t.UserId,
 		ToolSetId:     t.ToolSetId,
 		CountOfChecks: t.CountOfChecks,
+		BadgeId:       t.BadgeId,
+		KioskId:       t.KioskId,
 		Status:        t.Status,
 		CreatedAt:     t.CreatedAt,
 		UpdatedAt:     t.UpdatedAt,
@@ -9,6 +9,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// resolutionModelVersionJoin присоединяет к решению QA (tr) версию модели (sv.model_version) последнего скана сдачи
+// по транзакции: именно его результат QA разбирает при проверке
+const resolutionModelVersionJoin = `LEFT JOIN LATERAL (
+	SELECT cs.model_version
+	FROM cv_scans cs
+	WHERE cs.transaction_id = tr.transaction_id AND cs.scan_type = 'checkin'
+	ORDER BY cs.created_at DESC, cs.id DESC
+	LIMIT 1
+) sv ON TRUE`
+
 type TransactionResolutionsRepo struct {
 	DB *gorm.DB
 }
@@ -143,28 +153,42 @@ func (t *TransactionResolutionsRepo) GetMlErrorTransactions(ctx context.Context)
 	return toDomainArrTransactionResolution(models), nil
 }
 
-func (t *TransactionResolutionsRepo) GetMlErrorTools(ctx context.Context) ([]*repository.ToolSetWithErrors, error) {
+func (t *TransactionResolutionsRepo) GetMlErrorTools(ctx context.Context, filter repository.MlErrorFilter) ([]*repository.ToolSetWithErrors, error) {
 	const op = "TransactionResolutionsRepo.GetMlErrorTools"
 
 	type toolErrorCount struct {
 		ToolTypeId   int64
+		ModelVersion *string
 		MLErrorCount int64
 	}
 
-	// Считаем ML-ошибки сразу для всех инструментов
+	// Считаем ML-ошибки сразу для всех инструментов в разрезе версий модели
+	query := t.DB.WithContext(ctx).
+		Table("model_err_items AS mei").
+		Select("mei.tool_type_id, sv.model_version, COUNT(*) AS ml_error_count").
+		Joins("JOIN transaction_resolutions tr ON tr.id = mei.resolution_id").
+		Joins(resolutionModelVersionJoin)
+	if filter.ModelVersion != nil {
+		query = query.Where("sv.model_version = ?", *filter.ModelVersion)
+	}
+
 	var counts []toolErrorCount
-	if err := t.DB.WithContext(ctx).
-		Model(&ModelErrItemModel{}).
-		Select("tool_type_id, COUNT(*) AS ml_error_count").
-		Group("tool_type_id").
+	if err := query.
+		Group("mei.tool_type_id, sv.model_version").
+		Order("mei.tool_type_id, sv.model_version NULLS LAST").
 		Scan(&counts).Error; err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	// Создаем мапу tool_id -> ml_error_count
+	// Создаем мапы tool_id -> ml_error_count и tool_id -> разбивка по версиям
 	countMap := make(map[int64]int64, len(counts))
+	versionMap := make(map[int64][]repository.ModelVersionErrorCount, len(counts))
 	for _, c := range counts {
-		countMap[c.ToolTypeId] = c.MLErrorCount
+		countMap[c.ToolTypeId] += c.MLErrorCount
+		versionMap[c.ToolTypeId] = append(versionMap[c.ToolTypeId], repository.ModelVersionErrorCount{
+			ModelVersion: c.ModelVersion,
+			MLErrorCount: c.MLErrorCount,
+		})
 	}
 
 	// Загружаем все сеты с инструментами
@@ -182,10 +206,16 @@ func (t *TransactionResolutionsRepo) GetMlErrorTools(ctx context.Context) ([]*re
 		}
 
 		for _, tool := range ts.Tools {
+			byModelVersion := versionMap[tool.Id]
+			if byModelVersion == nil {
+				byModelVersion = []repository.ModelVersionErrorCount{}
+			}
+
 			tsWithErrors.Tools = append(tsWithErrors.Tools, repository.ToolWithErrorCount{
-				ID:           tool.Id,
-				Name:         tool.Name,
-				MLErrorCount: countMap[tool.Id],
+				ID:             tool.Id,
+				Name:           tool.Name,
+				MLErrorCount:   countMap[tool.Id],
+				ByModelVersion: byModelVersion,
 			})
 		}
 
@@ -195,6 +225,25 @@ func (t *TransactionResolutionsRepo) GetMlErrorTools(ctx context.Context) ([]*re
 	return result, nil
 }
 
+// CountByReasonAndModelVersion считает решения QA по причине и версии модели последнего скана сдачи
+func (t *TransactionResolutionsRepo) CountByReasonAndModelVersion(ctx context.Context) ([]*repository.ReasonModelVersionCount, error) {
+	const op = "TransactionResolutionsRepo.CountByReasonAndModelVersion"
+
+	var res []*repository.ReasonModelVersionCount
+	result := t.DB.WithContext(ctx).
+		Table("transaction_resolutions AS tr").
+		Select("tr.reason, sv.model_version, COUNT(*) AS count").
+		Joins(resolutionModelVersionJoin).
+		Group("tr.reason, sv.model_version").
+		Order("sv.model_version NULLS LAST").
+		Scan(&res)
+	if err := result.Error; err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
 func toTransactionResolutionModel(transaction *domain.TransactionResolution) *TransactionResolutionModel {
 	model := &TransactionResolutionModel{
 		Id:            transaction.Id,
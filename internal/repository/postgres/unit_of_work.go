@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/pkg/e"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type UnitOfWork struct {
+	DB *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{
+		DB: db,
+	}
+}
+
+// Do открывает транзакцию и передаёт в fn репозитории, работающие через неё.
+// Транзакция фиксируется, только если fn завершилась без ошибки
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos *repository.Repositories) error) error {
+	const op = "UnitOfWork.Do"
+
+	err := u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(newRepositories(tx))
+	})
+	if err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func newRepositories(tx *gorm.DB) *repository.Repositories {
+	return &repository.Repositories{
+		Transactions:    NewTransactionRepository(tx),
+		CvScans:         NewCvScanRepository(tx),
+		CvScanDetails:   NewCvScanDetailRepository(tx),
+		ToolInstances:   NewToolInstanceRepository(tx),
+		Resolutions:     NewTransactionResolutionsRepo(tx),
+		QAQueue:         NewQAQueueRepository(tx),
+		ToolTypes:       NewToolTypeRepository(tx),
+		ToolTypeSamples: NewToolTypeSampleRepository(tx),
+		ScanJobs:        NewScanJobRepository(tx),
+	}
+}
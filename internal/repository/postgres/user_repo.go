@@ -4,6 +4,7 @@ import (
 	"airport-tools-backend/internal/domain"
 	"airport-tools-backend/pkg/e"
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -34,7 +35,7 @@ func (u *UserRepository) GetById(ctx context.Context, id int64) (*domain.User, e
 	const op = "UserRepository.GetById"
 
 	var model UserModel
-	result := u.DB.WithContext(ctx).First(&model, "id = ?", id)
+	result := u.DB.WithContext(ctx).Preload("Role").First(&model, "id = ?", id)
 	if err := checkGetQueryResult(result, e.ErrUserNotFound); err != nil {
 		return nil, e.Wrap(op, err)
 	}
@@ -178,6 +179,61 @@ func (u *UserRepository) Update(ctx context.Context, user *domain.User) (*domain
 	return toDomainUser(&updUser), nil
 }
 
+// UpdatePin сохраняет PIN пользователя вместе с признаком обязательной смены, версией токенов и сброшенной блокировкой входа
+func (u *UserRepository) UpdatePin(ctx context.Context, user *domain.User) error {
+	const op = "UserRepository.UpdatePin"
+
+	updates := map[string]interface{}{
+		"password_hash":         user.PasswordHash,
+		"must_change_pin":       user.MustChangePin,
+		"failed_login_attempts": user.FailedLoginAttempts,
+		"login_locked_until":    user.LoginLockedUntil,
+		"token_version":         user.TokenVersion,
+	}
+
+	result := u.DB.WithContext(ctx).Model(&UserModel{}).Where("id = ?", user.Id).Updates(updates)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	if result.RowsAffected == 0 {
+		return e.Wrap(op, e.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// RegisterFailedLogin учитывает неверный PIN. Счётчик увеличивается одним запросом, чтобы параллельные попытки
+// не терялись; на maxAttempts-й попытке вход блокируется до now + lockout, а счётчик начинается заново
+func (u *UserRepository) RegisterFailedLogin(ctx context.Context, id int64, maxAttempts int, lockout time.Duration, now time.Time) error {
+	const op = "UserRepository.RegisterFailedLogin"
+
+	result := u.DB.WithContext(ctx).Exec(`
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= ? THEN 0 ELSE failed_login_attempts + 1 END,
+			login_locked_until = CASE WHEN failed_login_attempts + 1 >= ? THEN ? ELSE login_locked_until END
+		WHERE id = ?`, maxAttempts, maxAttempts, now.Add(lockout), id)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ResetFailedLogins обнуляет счётчик неверных PIN-кодов после успешного входа
+func (u *UserRepository) ResetFailedLogins(ctx context.Context, id int64) error {
+	const op = "UserRepository.ResetFailedLogins"
+
+	result := u.DB.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ? AND failed_login_attempts > 0", id).
+		Update("failed_login_attempts", 0)
+	if err := result.Error; err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
 func toArrUserModel(models []*domain.User) []*UserModel {
 	result := make([]*UserModel, len(models))
 	for i, model := range models {
@@ -189,10 +245,16 @@ func toArrUserModel(models []*domain.User) []*UserModel {
 
 func toUserModel(u *domain.User) *UserModel {
 	model := &UserModel{
-		Id:         u.Id,
-		EmployeeId: u.EmployeeId,
-		FullName:   u.FullName,
-		RoleId:     u.RoleId,
+		Id:           u.Id,
+		EmployeeId:   u.EmployeeId,
+		FullName:     u.FullName,
+		RoleId:       u.RoleId,
+		PasswordHash: u.PasswordHash,
+
+		MustChangePin:       u.MustChangePin,
+		FailedLoginAttempts: u.FailedLoginAttempts,
+		LoginLockedUntil:    u.LoginLockedUntil,
+		TokenVersion:        u.TokenVersion,
 	}
 
 	if u.Transactions != nil {
@@ -208,10 +270,16 @@ func toUserModel(u *domain.User) *UserModel {
 
 func toDomainUser(u *UserModel) *domain.User {
 	user := &domain.User{
-		Id:         u.Id,
-		EmployeeId: u.EmployeeId,
-		FullName:   u.FullName,
-		RoleId:     u.RoleId,
+		Id:           u.Id,
+		EmployeeId:   u.EmployeeId,
+		FullName:     u.FullName,
+		RoleId:       u.RoleId,
+		PasswordHash: u.PasswordHash,
+
+		MustChangePin:       u.MustChangePin,
+		FailedLoginAttempts: u.FailedLoginAttempts,
+		LoginLockedUntil:    u.LoginLockedUntil,
+		TokenVersion:        u.TokenVersion,
 	}
 
 	if u.Role != nil {
@@ -13,6 +13,7 @@ type ToolTypeRepository interface {
 	GetAll(ctx context.Context) ([]*domain.ToolType, error)
 	Delete(ctx context.Context, id int64) error
 	Update(ctx context.Context, toolType *domain.ToolType) (*domain.ToolType, error)
+	UpdateReferenceEmbedding(ctx context.Context, id int64, embedding []float32) (*domain.ToolType, error)
 }
 
 // ToolSetRepository интерфейс для работы с наборами инструментов в базе данных
@@ -24,6 +25,12 @@ type ToolSetRepository interface {
 	Update(ctx context.Context, toolSet *domain.ToolSet) (*domain.ToolSet, error)
 	GetByIdWithTools(ctx context.Context, id int64) (*domain.ToolSet, error)
 	CreateWithTools(ctx context.Context, toolSet *domain.ToolSet, toolsIds []int64) (*domain.ToolSet, error)
+	GetLatestByFamilyIdWithTools(ctx context.Context, familyId int64) (*domain.ToolSet, error)
+	GetAllByFamilyId(ctx context.Context, familyId int64) ([]*domain.ToolSet, error)
+	GetAllLatest(ctx context.Context) ([]*domain.ToolSet, error)
+	UpsertThreshold(ctx context.Context, threshold *domain.ToolSetThreshold) error
+	DeleteThreshold(ctx context.Context, familyId, toolTypeId int64) error
+	ReplaceSlots(ctx context.Context, familyId int64, slots []*domain.BoardSlot) ([]*domain.BoardSlot, error)
 }
 
 // UserRepository интерфейс для работы с пользователями в базе данных
@@ -38,6 +45,9 @@ type UserRepository interface {
 	GetByEmployeeIdWithTransactionResolutions(ctx context.Context, employeeId string) (*domain.User, error)
 	GetAllQa(ctx context.Context) ([]*domain.User, error)
 	GetAllEngineersWithTransactions(ctx context.Context) ([]*domain.User, error)
+	UpdatePin(ctx context.Context, user *domain.User) error
+	RegisterFailedLogin(ctx context.Context, id int64, maxAttempts int, lockout time.Duration, now time.Time) error
+	ResetFailedLogins(ctx context.Context, id int64) error
 }
 
 // TransactionRepository интерфейс для работы с транзакциями инструментов в базе данных
@@ -56,6 +66,8 @@ type TransactionRepository interface {
 	GetLastFailedByUserId(ctx context.Context, userId int64) (*domain.Transaction, error)
 	GetAllByUserId(ctx context.Context, userId int64, startDate, endDate *time.Time, limit *int) ([]*domain.Transaction, error)
 	GetAllWithStatus(ctx context.Context, status domain.Status) ([]*domain.Transaction, error)
+	// LockUser сериализует проверки пользователя; блокировка действует до конца транзакции БД, поэтому вызывается внутри UnitOfWork
+	LockUser(ctx context.Context, userId int64) error
 }
 
 // CvScanRepository интерфейс для работы со сканами инструментов в базе данных
@@ -65,11 +77,15 @@ type CvScanRepository interface {
 	GetByTransactionId(ctx context.Context, transactionId int64) (*domain.CvScan, error)
 	GetByIdWithTransaction(ctx context.Context, id int64) (*domain.CvScan, error)
 	GetByTransactionIdWithDetectedToolsAndTransaction(ctx context.Context, transactionId int64) (*domain.CvScan, error)
+	GetLastByTransactionIdAndTypeWithDetectedTools(ctx context.Context, transactionId int64, scanType domain.ScanType) (*domain.CvScan, error)
+	GetModelVersionStats(ctx context.Context) ([]*ModelVersionStats, error)
+	GetCheckinHistory(ctx context.Context, filter ScanHistoryFilter, limit int) ([]*CheckinScanHistory, error)
 }
 
 // CvScanDetailRepository интерфейс для работы с детализацией сканов в базе данных
 type CvScanDetailRepository interface {
 	Create(ctx context.Context, cvScanDetail *domain.CvScanDetail) (*domain.CvScanDetail, error)
+	CreateBatch(ctx context.Context, cvScanDetails []*domain.CvScanDetail) error
 	GetById(ctx context.Context, id int64) (*domain.CvScanDetail, error)
 	GetByCvScanId(ctx context.Context, cvScanId int64) ([]*domain.CvScanDetail, error)
 }
@@ -77,6 +93,7 @@ type CvScanDetailRepository interface {
 // ImageRepository интерфейс для работы с хранением изображений
 type ImageRepository interface {
 	Save(ctx context.Context, img *domain.Image) (*domain.UploadImage, error)
+	Delete(ctx context.Context, key string) error
 }
 
 // TransactionResolutionsRepository интерфейс для работы с QA проверками
@@ -89,7 +106,51 @@ type TransactionResolutionsRepository interface {
 	GetAllHumanError(ctx context.Context) ([]*domain.TransactionResolution, error)
 	GetTopHumanErrorUsers(ctx context.Context) ([]HumanErrorStats, error)
 	GetMlErrorTransactions(ctx context.Context) ([]*domain.TransactionResolution, error)
-	GetMlErrorTools(ctx context.Context) ([]*ToolSetWithErrors, error)
+	GetMlErrorTools(ctx context.Context, filter MlErrorFilter) ([]*ToolSetWithErrors, error)
+	CountByReasonAndModelVersion(ctx context.Context) ([]*ReasonModelVersionCount, error)
+}
+
+// BadgeRepository интерфейс для работы с реестром пропусков сотрудников
+type BadgeRepository interface {
+	Create(ctx context.Context, badge *domain.Badge) (*domain.Badge, error)
+	GetById(ctx context.Context, id int64) (*domain.Badge, error)
+	GetByUidWithUser(ctx context.Context, uid string) (*domain.Badge, error)
+	GetAllByUserId(ctx context.Context, userId int64) ([]*domain.Badge, error)
+	Update(ctx context.Context, badge *domain.Badge) (*domain.Badge, error)
+}
+
+// ToolTypeSampleRepository интерфейс для работы с эталонными фотографиями типов инструментов
+type ToolTypeSampleRepository interface {
+	Create(ctx context.Context, sample *domain.ToolTypeSample) (*domain.ToolTypeSample, error)
+	GetAllByToolTypeId(ctx context.Context, toolTypeId int64) ([]*domain.ToolTypeSample, error)
+}
+
+// ToolTypeReferenceRepository интерфейс для работы с дополнительными эталонами типов инструментов
+type ToolTypeReferenceRepository interface {
+	Create(ctx context.Context, reference *domain.ToolTypeReference) (*domain.ToolTypeReference, error)
+	GetAllByToolTypeId(ctx context.Context, toolTypeId int64) ([]*domain.ToolTypeReference, error)
+	Delete(ctx context.Context, toolTypeId, id int64) error
+}
+
+// ToolInstanceRepository интерфейс для работы с экземплярами инструментов и их выдачей по транзакциям
+type ToolInstanceRepository interface {
+	Create(ctx context.Context, instance *domain.ToolInstance) (*domain.ToolInstance, error)
+	GetById(ctx context.Context, id int64) (*domain.ToolInstance, error)
+	GetAll(ctx context.Context, filter ToolInstanceFilter) ([]*domain.ToolInstance, error)
+	Update(ctx context.Context, instance *domain.ToolInstance) (*domain.ToolInstance, error)
+	GetAllByHomeToolSet(ctx context.Context, homeToolSetId int64) ([]*domain.ToolInstance, error)
+	GetAllByHomeToolSetForUpdate(ctx context.Context, homeToolSetId int64) ([]*domain.ToolInstance, error)
+	GetIssuedIds(ctx context.Context, homeToolSetId, excludeTransactionId int64) (map[int64]bool, error)
+	AttachToTransaction(ctx context.Context, transactionId int64, instanceIds []int64) error
+	GetAllByTransactionId(ctx context.Context, transactionId int64) ([]*domain.ToolInstance, error)
+	GetMlErrorInstances(ctx context.Context, filter MlErrorFilter) ([]*ToolInstanceWithErrorCount, error)
+	GetCalibrationDue(ctx context.Context, dueBefore time.Time) ([]*domain.ToolInstance, error)
+}
+
+// CalibrationEventRepository интерфейс для работы с журналом поверок экземпляров
+type CalibrationEventRepository interface {
+	Create(ctx context.Context, event *domain.CalibrationEvent) (*domain.CalibrationEvent, error)
+	GetAllByToolInstanceId(ctx context.Context, toolInstanceId int64) ([]*domain.CalibrationEvent, error)
 }
 
 type RoleRepository interface {
@@ -98,3 +159,78 @@ type RoleRepository interface {
 	GetById(ctx context.Context, id int64) (*domain.Role, error)
 	GetByName(ctx context.Context, name string) (*domain.Role, error)
 }
+
+// IdempotencyKeyRepository интерфейс для хранения ответов на запросы с заголовком Idempotency-Key
+type IdempotencyKeyRepository interface {
+	// Reserve сохраняет ключ, если его ещё нет; false — ключ уже занят другим запросом
+	Reserve(ctx context.Context, key *domain.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, scope, key string) (*domain.IdempotencyKey, error)
+	Complete(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error
+	Delete(ctx context.Context, scope, key string) error
+	// DeleteCreatedBefore удаляет ключи, созданные раньше before, и возвращает их число
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ScanJobRepository интерфейс для очереди заданий асинхронной проверки
+type ScanJobRepository interface {
+	Create(ctx context.Context, job *domain.ScanJob) (*domain.ScanJob, error)
+	GetById(ctx context.Context, id string) (*domain.ScanJob, error)
+	// ClaimNext забирает самое старое ожидающее задание; параллельные воркеры получают разные задания
+	ClaimNext(ctx context.Context) (*domain.ScanJob, error)
+	// RecordScan связывает выполняемое задание с сохранённой проверкой; вызывается в транзакции проверки
+	RecordScan(ctx context.Context, job *domain.ScanJob) error
+	// Finish завершает попытку; попытка, которую уже сменила другая, получает ErrScanJobNotRunning
+	Finish(ctx context.Context, job *domain.ScanJob) error
+	// RequeueStale возвращает в очередь задания, зависшие в обработке дольше startedBefore,
+	// а исчерпавшие maxAttempts попыток завершает с ошибкой
+	RequeueStale(ctx context.Context, startedBefore time.Time, maxAttempts int) (int64, error)
+}
+
+// MLClassMappingRepository интерфейс для работы с сопоставлениями классов модели и типов инструментов
+type MLClassMappingRepository interface {
+	Create(ctx context.Context, mapping *domain.MLClassMapping) (*domain.MLClassMapping, error)
+	GetById(ctx context.Context, id int64) (*domain.MLClassMapping, error)
+	// GetAll возвращает все сопоставления; если modelVersion задан — только сопоставления этой версии
+	GetAll(ctx context.Context, modelVersion *string) ([]*domain.MLClassMapping, error)
+	// GetForModelVersion возвращает сопоставления версии modelVersion вместе с сопоставлениями domain.AnyModelVersion
+	GetForModelVersion(ctx context.Context, modelVersion *string) ([]*domain.MLClassMapping, error)
+	Update(ctx context.Context, mapping *domain.MLClassMapping) (*domain.MLClassMapping, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// ShadowScanRepository интерфейс для хранения результатов теневой модели и их сравнения с основной
+type ShadowScanRepository interface {
+	// Create сохраняет теневой скан вместе с детекциями
+	Create(ctx context.Context, scan *domain.ShadowScan) (*domain.ShadowScan, error)
+	GetComparison(ctx context.Context, filter ShadowReportFilter) (*ShadowComparison, error)
+}
+
+// QAQueueRepository интерфейс для очереди QA-проверки
+type QAQueueRepository interface {
+	Enqueue(ctx context.Context, item *domain.QAQueueItem) error
+	Remove(ctx context.Context, transactionId int64) error
+	// GetByTransactionIdForUpdate блокирует элемент до конца транзакции БД, поэтому вызывается внутри UnitOfWork
+	GetByTransactionIdForUpdate(ctx context.Context, transactionId int64) (*domain.QAQueueItem, error)
+	Update(ctx context.Context, item *domain.QAQueueItem) error
+	List(ctx context.Context, filter QAQueueFilter) ([]*domain.QAQueueItem, error)
+	// EscalateOverdue отмечает эскалацию элементов, поставленных в очередь раньше enqueuedBefore и ещё не эскалированных
+	EscalateOverdue(ctx context.Context, enqueuedBefore, now time.Time) (int64, error)
+}
+
+// Repositories репозитории, разделяющие одну транзакцию БД в рамках UnitOfWork
+type Repositories struct {
+	Transactions    TransactionRepository
+	CvScans         CvScanRepository
+	CvScanDetails   CvScanDetailRepository
+	ToolInstances   ToolInstanceRepository
+	Resolutions     TransactionResolutionsRepository
+	QAQueue         QAQueueRepository
+	ToolTypes       ToolTypeRepository
+	ToolTypeSamples ToolTypeSampleRepository
+	ScanJobs        ScanJobRepository
+}
+
+// UnitOfWork выполняет fn в одной транзакции БД: если fn вернула ошибку, все изменения откатываются
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos *Repositories) error) error
+}
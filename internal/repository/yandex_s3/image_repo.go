@@ -43,3 +43,16 @@ func (i *ImageRepository) Save(ctx context.Context, img *domain.Image) (*domain.
 
 	return domain.NewUploadImage(key, url), nil
 }
+
+func (i *ImageRepository) Delete(ctx context.Context, key string) error {
+	const op = "ImageRepository.Delete"
+
+	if _, err := i.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(i.Bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
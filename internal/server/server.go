@@ -20,7 +20,9 @@ func NewServer(handler http.Handler, httpServer config.HttpServer) *Server {
 
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{frontURL},
-		AllowedMethods:   []string{"GET", "POST"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Idempotent-Replayed"},
 		AllowCredentials: true,
 	}).Handler(handler)
 
@@ -0,0 +1,162 @@
+package usecase_test
+
+import (
+	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository/postgres"
+	"airport-tools-backend/internal/usecase"
+	"airport-tools-backend/pkg/e"
+	"airport-tools-backend/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	pg "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// barrierML отвечает на распознавание только после того, как все parallel запросов дошли до сканирования,
+// чтобы каждый из них прошёл проверку пользователя до того, как первый сохранит выдачу
+type barrierML struct {
+	arrived sync.WaitGroup
+	timeout time.Duration
+}
+
+func newBarrierML(parallel int, timeout time.Duration) *barrierML {
+	m := &barrierML{timeout: timeout}
+	m.arrived.Add(parallel)
+	return m
+}
+
+func (m *barrierML) ScanTools(ctx context.Context, req *usecase.ScanRequest) (*usecase.ScanResult, error) {
+	m.arrived.Done()
+
+	done := make(chan struct{})
+	go func() {
+		m.arrived.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(m.timeout):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &usecase.ScanResult{Tools: []*domain.RecognizedTool{}}, nil
+}
+
+func (m *barrierML) Health() *usecase.MLHealth {
+	return &usecase.MLHealth{Healthy: true}
+}
+
+type stubImageStorage struct{}
+
+func (stubImageStorage) UploadImage(ctx context.Context, req *usecase.UploadImageReq) (*usecase.UploadImageRes, error) {
+	return &usecase.UploadImageRes{Key: "test", ImageUrl: "http://storage.test/test.jpg"}, nil
+}
+
+func (stubImageStorage) DeleteImage(ctx context.Context, key string) error {
+	return nil
+}
+
+// TestCheckConcurrentCheckout проверяет, что параллельные выдачи одному инженеру создают ровно одну открытую транзакцию.
+// Нужна база PostgreSQL: адрес берётся из TEST_DB_URL, без него тест пропускается
+func TestCheckConcurrentCheckout(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	const parallel = 8
+	ctx := context.Background()
+
+	db, err := gorm.Open(pg.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	database := &postgres.PgDatabase{Db: db}
+	t.Cleanup(func() { _ = database.Close() })
+
+	// миграции ищутся относительно корня репозитория
+	t.Chdir("../..")
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	role, err := postgres.NewRoleRepo(db).GetByName(ctx, domain.Engineer)
+	if err != nil {
+		t.Fatalf("engineer role: %v", err)
+	}
+
+	userRepo := postgres.NewUserRepository(db)
+	engineer, err := userRepo.Create(ctx, domain.NewUser("Concurrent Check", "concurrent-"+suffix, role.Id, "-"))
+	if err != nil {
+		t.Fatalf("create engineer: %v", err)
+	}
+
+	// набор без позиций: пустой результат распознавания даёт успешную выдачу
+	toolSet := &postgres.ToolSetModel{Name: "concurrent-" + suffix, Version: 1, IsLatest: true}
+	if err := db.Omit("Tools", "Items", "Thresholds", "Slots").Create(toolSet).Error; err != nil {
+		t.Fatalf("create tool set: %v", err)
+	}
+	if err := db.Model(toolSet).Update("family_id", toolSet.Id).Error; err != nil {
+		t.Fatalf("set tool set family: %v", err)
+	}
+
+	service := usecase.NewService(
+		userRepo, postgres.NewCvScanRepository(db), postgres.NewCvScanDetailRepository(db),
+		postgres.NewToolTypeRepository(db), postgres.NewTransactionRepository(db), newBarrierML(parallel, 10*time.Second), stubImageStorage{},
+		postgres.NewToolSetRepository(db), 0.5, 0.5, 0.5, 0.5, postgres.NewTransactionResolutionsRepo(db),
+		logger.NewSlogLogger(), postgres.NewRoleRepo(db), nil, nil,
+		postgres.NewBadgeRepository(db), postgres.NewToolTypeSampleRepository(db),
+		postgres.NewToolTypeReferenceRepository(db), postgres.NewToolInstanceRepository(db),
+		postgres.NewCalibrationEventRepository(db), postgres.NewUnitOfWork(db),
+		postgres.NewIdempotencyKeyRepository(db), postgres.NewScanJobRepository(db),
+		postgres.NewMLClassMappingRepository(db), nil,
+		postgres.NewShadowScanRepository(db), 0,
+		postgres.NewQAQueueRepository(db), time.Minute, time.Hour,
+		5, time.Minute,
+	)
+
+	errs := make([]error, parallel)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.Check(ctx, usecase.NewCheckReq(engineer.EmployeeId, "image", toolSet.Id, nil, nil))
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, e.ErrTransactionConcurrentCheck):
+			t.Errorf("check %d: want ErrTransactionConcurrentCheck, got %v", i, err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("want exactly one successful check, got %d", succeeded)
+	}
+
+	var open int64
+	if err := db.Model(&postgres.TransactionModel{}).Where("user_id = ? AND status = ?", engineer.Id, domain.OPEN).Count(&open).Error; err != nil {
+		t.Fatalf("count transactions: %v", err)
+	}
+
+	if open != 1 {
+		t.Errorf("want exactly one OPEN transaction, got %d", open)
+	}
+}
@@ -1,13 +1,32 @@
 package usecase
 
-import "context"
+import (
+	"airport-tools-backend/internal/domain"
+	"context"
+)
 
 // MLGateway интерфейс для взаимодействия с ML-сервисом, который распознаёт инструменты на фото.
 type MLGateway interface {
 	ScanTools(ctx context.Context, req *ScanRequest) (*ScanResult, error)
+	// Health возвращает последнее известное состояние ML-сервиса без обращения к нему
+	Health() *MLHealth
 }
 
 // ImageStorage интерфейс для загрузки изображение в хранилище
 type ImageStorage interface {
 	UploadImage(ctx context.Context, req *UploadImageReq) (*UploadImageRes, error)
+	DeleteImage(ctx context.Context, key string) error
+}
+
+// TokenManager интерфейс для выпуска и проверки токенов доступа
+type TokenManager interface {
+	NewTokenPair(user *domain.User) (*TokenPair, error)
+	ParseAccessToken(token string) (*TokenClaims, error)
+	ParseRefreshToken(token string) (*TokenClaims, error)
+}
+
+// PasswordHasher интерфейс для хеширования и проверки PIN-кодов/паролей пользователей
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
 }
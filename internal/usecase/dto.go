@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
+	"math"
 	"time"
 )
 
@@ -9,6 +11,28 @@ type AddToolSetReq struct {
 	ToolSetName string
 	ToolsIds    []int64
 }
+type EditToolSetReq struct {
+	ToolSetId int64
+	Name      string
+	ToolsIds  []int64
+}
+
+type ToolSetDTO struct {
+	Id        int64
+	FamilyId  int64
+	Version   int
+	Name      string
+	RetiredAt *time.Time
+	CreatedAt time.Time
+	Tools     []*ToolTypeDTO
+	Items     []*ToolSetItemDTO
+}
+
+type ToolSetItemDTO struct {
+	ToolTypeId int64
+	Quantity   int
+}
+
 type AddToolSetRes struct {
 	Id    int64
 	Name  string
@@ -29,6 +53,7 @@ type MlErrorTransaction struct {
 	TransactionID  int64
 	SourceImageUrl string
 	DebugImageUrl  string
+	ModelVersion   *string
 }
 
 type GetAvgWorkDurationRes struct {
@@ -65,8 +90,9 @@ type HumanErrorStats struct {
 }
 
 type ModelOrHumanStatsRes struct {
-	MlErrors    int
-	HumanErrors int
+	MlErrors               int
+	HumanErrors            int
+	MlErrorsByModelVersion []repository.ModelVersionErrorCount
 }
 
 type QaTransactionsRes struct {
@@ -98,17 +124,21 @@ type GetQAVerificationRes struct {
 	ProblematicTools *ProblematicTools
 	ImageUrl         string
 	Status           string
+	Instances        []*ToolInstanceDTO
 }
 
 type ProblematicTools struct {
 	ManualCheckTools []*domain.RecognizedTool
 	UnknownTools     []*domain.RecognizedTool
 	MissingTools     []*ToolTypeDTO
+	MisplacedTools   []*domain.RecognizedTool
+
+	PossiblySubstitutedTools []*domain.RecognizedTool
 }
 
 type Verification struct {
 	TransactionID int64
-	QAEmployeeId  string
+	QAUserId      int64
 	Reason        domain.Reason
 	Notes         string
 	ToolsIds      []int64
@@ -125,6 +155,7 @@ type RegisterReq struct {
 	EmployeeId string
 	FullName   string
 	Role       string
+	Password   string
 }
 
 type RegisterRes struct {
@@ -146,10 +177,39 @@ type UserDto struct {
 
 type LoginReq struct {
 	EmployeeId string
+	Password   string
+	// NewPin новый PIN; обязателен, если PIN задан администратором и должен быть заменён
+	NewPin string
+}
+
+type SetUserPinReq struct {
+	UserId int64
+	Pin    string
 }
 
 type LoginRes struct {
-	Role string
+	Role   string
+	Tokens *TokenPair
+}
+
+type RefreshReq struct {
+	RefreshToken string
+}
+
+// TokenPair пара токенов, выдаваемая пользователю при входе
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// TokenClaims данные о пользователе, извлечённые из подписанного токена
+type TokenClaims struct {
+	UserId       int64
+	EmployeeId   string
+	Role         string
+	TokenVersion int
 }
 
 type GetRolesRes struct {
@@ -161,13 +221,45 @@ type TransactionProcess struct {
 	UserId    int64
 	Data      string
 	ToolSetId int64
+	BadgeId   *int64
+	KioskId   *int64
+	// ScanJob задание, в рамках которого выполняется проверка; nil — синхронная проверка
+	ScanJob *domain.ScanJob
 }
 
-// CheckReq представляет запрос на выдачу/сдачу инструментов
+// CheckReq представляет запрос на выдачу/сдачу инструментов. KioskId заполнен, если проверка выполняется через киоск
 type CheckReq struct {
 	EmployeeId string
 	Data       string
 	ToolSetId  int64
+	BadgeId    *int64
+	KioskId    *int64
+	// ScanJob задание асинхронной проверки; его связь с проверкой сохраняется вместе с ней
+	ScanJob *domain.ScanJob
+}
+
+// CheckByBadgeReq представляет запрос на выдачу/сдачу инструментов с идентификацией по пропуску,
+// предъявленному на киоске KioskId
+type CheckByBadgeReq struct {
+	BadgeUid  string
+	Data      string
+	ToolSetId int64
+	KioskId   int64
+}
+
+type CreateBadgeReq struct {
+	Uid         string
+	EmployeeId  string
+	ActivatedAt *time.Time
+}
+
+type BadgeDTO struct {
+	Id          int64
+	Uid         string
+	User        UserDto
+	ActivatedAt time.Time
+	RevokedAt   *time.Time
+	Active      bool
 }
 
 // CheckRes содержит результат проверки инструментов после сканирования.
@@ -176,10 +268,190 @@ type CheckRes struct {
 	DebugImageUrl    string
 	AccessTools      []*domain.RecognizedTool
 	ProblematicTools *ProblematicTools
+	ToolCounts       []*ToolCountDTO
+	Instances        []*ToolInstanceDTO
 	TransactionType  string
 	Status           string
 }
 
+type CreateToolInstanceReq struct {
+	SerialNumber  string
+	ToolTypeId    int64
+	HomeToolSetId *int64
+}
+
+type ListToolInstancesReq struct {
+	ToolTypeId    *int64
+	HomeToolSetId *int64
+	Status        *string
+}
+
+type ToolInstanceDTO struct {
+	Id            int64
+	SerialNumber  string
+	ToolType      *ToolTypeDTO
+	ToolTypeId    int64
+	HomeToolSetId *int64
+	Status        string
+	UpdatedAt     time.Time
+
+	LastCalibratedAt *time.Time
+	CalibrationDueAt *time.Time
+}
+
+type RecordCalibrationReq struct {
+	ToolInstanceId int64
+	PerformedBy    int64
+	CalibratedAt   *time.Time
+	NextDueAt      *time.Time
+	Notes          string
+}
+
+type CalibrationEventDTO struct {
+	Id           int64
+	Instance     *ToolInstanceDTO
+	CalibratedAt time.Time
+	NextDueAt    time.Time
+	PerformedBy  int64
+	Notes        string
+	CreatedAt    time.Time
+}
+
+// CalibrationDueDTO экземпляр из отчёта о поверках. DaysLeft отрицателен для просроченных, nil — экземпляр ни разу не поверялся
+type CalibrationDueDTO struct {
+	Instance *ToolInstanceDTO
+	Overdue  bool
+	DaysLeft *int
+}
+
+// ScanJobDTO состояние задания асинхронной проверки; Result заполнен для выполненных заданий
+type ScanJobDTO struct {
+	Id            string
+	Status        string
+	TransactionId *int64
+	Result        *CheckRes
+	Error         *string
+	CreatedAt     time.Time
+	FinishedAt    *time.Time
+}
+
+// QAQueueItemDTO элемент очереди QA-проверки. TimeInQueue и Overdue рассчитаны на момент запроса;
+// ClaimedBy заполнен, только пока блокировка не истекла
+type QAQueueItemDTO struct {
+	TransactionId  int64
+	ToolSetId      int64
+	User           UserDto
+	EnqueuedAt     time.Time
+	TimeInQueue    time.Duration
+	SLA            time.Duration
+	Overdue        bool
+	EscalatedAt    *time.Time
+	Assignee       *UserDto
+	AssignedAt     *time.Time
+	ClaimedBy      *UserDto
+	ClaimExpiresAt *time.Time
+}
+
+type CreateMLClassMappingReq struct {
+	ModelVersion string
+	ClassIndex   int64
+	ToolTypeId   int64
+}
+
+type MLClassMappingDTO struct {
+	Id           int64
+	ModelVersion string
+	ClassIndex   int64
+	ToolTypeId   int64
+	ToolType     *ToolTypeDTO
+	CreatedAt    time.Time
+}
+
+// shadowScanTask распознавание, которое нужно повторить на теневой модели. ReferenceSet — набор, с которым
+// сравнивался основной скан, CountOfChecks — число сдач по транзакции до этого скана
+type shadowScanTask struct {
+	Scan          *domain.CvScan
+	ScanReq       *ScanRequest
+	ReferenceSet  *domain.ToolSet
+	PrimaryStatus domain.Status
+	CountOfChecks int64
+}
+
+// ShadowComparisonDTO отчёт сравнения основной и теневой моделей; доли считаются от успешных теневых сканов
+type ShadowComparisonDTO struct {
+	ScansCount          int64
+	FailedCount         int64
+	AgreementRate       float64
+	StatusAgreementRate float64
+	Statuses            []*repository.ShadowStatusCount
+	ToolTypes           []*repository.ShadowToolTypeDiff
+}
+
+// ThresholdRange диапазон перебора порога: значения Min, Min+Step, ... не больше Max
+type ThresholdRange struct {
+	Min  float32
+	Max  float32
+	Step float32
+}
+
+// ThresholdSweepReq запрос калибровки порогов. ToolTypeId ограничивает перебор одним типом инструмента:
+// перебираемые пороги применяются только к нему, остальные типы сохраняют действующие пороги
+type ThresholdSweepReq struct {
+	Filter     repository.ScanHistoryFilter
+	ToolTypeId *int64
+	Confidence ThresholdRange
+	CosineSim  ThresholdRange
+}
+
+// ThresholdPairStats результат повторного прогона сканов с одной парой порогов. Положительный исход — детекция
+// прошла автоматическую проверку; верной считается детекция, тип которой аудитор не отметил как ошибку модели.
+// Precision — доля верных среди прошедших, Recall — доля прошедших среди верных,
+// Escalations — число сдач, которые ушли бы на QA
+type ThresholdPairStats struct {
+	Thresholds     domain.Thresholds
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	TrueNegatives  int
+	Precision      float64
+	Recall         float64
+	Escalations    int
+}
+
+// ThresholdSweepRes отчёт калибровки порогов. Размеченные детекции берутся из последних сканов транзакций
+// с QA-решением, эскалации считаются по всем сканам сдачи; CurrentEscalations — эскалации при действующих порогах.
+// Truncated — в периоде больше сканов, чем берётся в отчёт, и прогнаны только последние из них
+type ThresholdSweepRes struct {
+	ScansCount         int
+	Truncated          bool
+	ResolvedScansCount int
+	LabeledDetections  int
+	CurrentEscalations int
+	Pairs              []*ThresholdPairStats
+}
+
+// calibrationScan скан сдачи, подготовленный к перебору порогов: детекции типов набора с вычисленной
+// близостью к эталонам. Unknown и missing от порогов не зависят и считаются один раз, Misplaced — детекции
+// вне своих гнёзд ложемента, CurrentManualCheck — число детекций на ручной проверке при действующих порогах
+type calibrationScan struct {
+	CheckNumber        int64
+	Resolved           bool
+	ModelErr           map[int64]bool
+	Current            map[int64]domain.Thresholds
+	Expected           map[int64]int
+	Detected           map[int64][]*domain.RecognizedTool
+	Misplaced          map[*domain.RecognizedTool]bool
+	Unknown            int
+	Missing            int
+	CurrentManualCheck int
+}
+
+// IdempotentResponse сохранённый ответ на запрос с заголовком Idempotency-Key
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
 type UploadImageReq struct {
 	Data string
 	Mode string
@@ -189,12 +461,74 @@ type ToolTypeDTO struct {
 	Id         int64
 	PartNumber string
 	Name       string
+
+	CalibrationIntervalDays *int
+	Thresholds              domain.ThresholdsOverride
+}
+
+// ToolSetThresholdDTO пороги типа инструмента в наборе: Effective — применяемые при проверке,
+// SetOverride — заданные для набора (пороги типа — в ToolType.Thresholds)
+type ToolSetThresholdDTO struct {
+	ToolType    *ToolTypeDTO
+	Effective   domain.Thresholds
+	SetOverride domain.ThresholdsOverride
+}
+
+// BoardSlotReq гнездо ложемента под экземпляр типа инструмента: вершины многоугольника в пикселях изображения
+type BoardSlotReq struct {
+	ToolTypeId int64
+	Polygon    []domain.Point
+}
+
+// SetBoardLayoutReq раскладка ложемента набора, заменяющая ранее заданную
+type SetBoardLayoutReq struct {
+	ToolSetId int64
+	Slots     []*BoardSlotReq
+}
+
+type AddToolTypeSamplesReq struct {
+	ToolTypeId int64
+	Images     []string
+}
+
+type ToolTypeSampleDTO struct {
+	Id         int64
+	ImageUrl   string
+	Confidence float32
+	CreatedAt  time.Time
+}
+
+// ToolTypeSamplesRes результат пересчёта эталонного эмбеддинга; RejectedImages — индексы фото, на которых инструмент не найден
+type ToolTypeSamplesRes struct {
+	ToolType       *ToolTypeDTO
+	Samples        []*ToolTypeSampleDTO
+	RejectedImages []int
+}
+
+type AddToolTypeReferenceReq struct {
+	ToolTypeId int64
+	Label      string
+	Embedding  []float32
+}
+
+type ToolTypeReferenceDTO struct {
+	Id        int64
+	Label     string
+	CreatedAt time.Time
+}
+
+type CreateToolTypeReq struct {
+	PartNumber         string
+	Name               string
+	ReferenceEmbedding []float32
 }
 
 // ScanRequest используется для передачи изображения в ML-сервис.
+// ImageData содержит изображение в base64 для режима передачи, в котором ML-сервис не скачивает его по ImageUrl
 type ScanRequest struct {
 	ImageId   string
 	ImageUrl  string
+	ImageData string
 	Threshold float32
 }
 
@@ -202,6 +536,27 @@ type ScanRequest struct {
 type ScanResult struct {
 	Tools         []*domain.RecognizedTool
 	DebugImageUrl string
+	// ModelVersion идентификатор модели, выполнившей распознавание; nil, если сервис его не сообщил
+	ModelVersion *string
+}
+
+// MLCircuitOpen состояние circuit breaker'а ML-шлюза, при котором вызовы отклоняются без обращения к сервису
+const MLCircuitOpen = "OPEN"
+
+// MLHealth состояние ML-сервиса по данным фоновой проверки доступности
+type MLHealth struct {
+	Healthy       bool
+	CircuitState  string
+	LastCheckedAt *time.Time
+	LastError     *string
+	// ModelVersion последняя известная версия модели ML-сервиса
+	ModelVersion *string
+}
+
+// ReadinessRes готовность сервиса принимать проверки
+type ReadinessRes struct {
+	Ready bool
+	ML    *MLHealth
 }
 
 type CreateScanReq struct {
@@ -209,21 +564,42 @@ type CreateScanReq struct {
 	ScanType      domain.ScanType
 	ImageUrl      string
 	DebugImageUrl string
+	ModelVersion  *string
 	Tools         []*domain.RecognizedTool
 }
 
+// FilterReq запрос фильтрации; Thresholds — пороги автоматической проверки по каждому типу набора,
+// DuplicateIoU — IoU, начиная с которого детекции одного класса считаются одним инструментом,
+// Slots — раскладка ложемента набора
 type FilterReq struct {
-	ConfidenceCompare float32
-	CosineSimCompare  float32
-	Tools             []*domain.RecognizedTool
-	ReferenceTools    []*domain.ToolType
+	Thresholds     map[int64]domain.Thresholds
+	DuplicateIoU   float32
+	Tools          []*domain.RecognizedTool
+	ReferenceTools []*domain.ToolType
+	Quantities     map[int64]int
+	Slots          []*domain.BoardSlot
 }
 
+// FilterRes результат фильтрации; MisplacedTools — засчитанные инструменты, лежащие не в своём гнезде ложемента
 type FilterRes struct {
 	AccessTools      []*domain.RecognizedTool
 	ManualCheckTools []*domain.RecognizedTool
 	UnknownTools     []*domain.RecognizedTool
 	MissingTools     []*ToolTypeDTO
+	MisplacedTools   []*domain.RecognizedTool
+	ToolCounts       []*ToolCountDTO
+	// PossiblySubstitutedTools засчитанные при сдаче инструменты, непохожие на выданные по этой транзакции
+	PossiblySubstitutedTools []*domain.RecognizedTool
+}
+
+// ToolCountDTO сравнение ожидаемого и распознанного количества экземпляров одного типа
+type ToolCountDTO struct {
+	ToolType   *ToolTypeDTO
+	Expected   int
+	Detected   int
+	Surplus    int
+	Shortfall  int
+	Thresholds domain.Thresholds
 }
 
 type UploadImageRes struct {
@@ -240,11 +616,80 @@ func toArrToolTypeDTO(tools []*domain.ToolType) []*ToolTypeDTO {
 	return res
 }
 
+func NewAddToolTypeSamplesReq(toolTypeId int64, images []string) *AddToolTypeSamplesReq {
+	return &AddToolTypeSamplesReq{
+		ToolTypeId: toolTypeId,
+		Images:     images,
+	}
+}
+
+func NewToolTypeSamplesRes(toolType *ToolTypeDTO, samples []*ToolTypeSampleDTO, rejectedImages []int) *ToolTypeSamplesRes {
+	return &ToolTypeSamplesRes{
+		ToolType:       toolType,
+		Samples:        samples,
+		RejectedImages: rejectedImages,
+	}
+}
+
+func ToToolTypeSampleDTO(sample *domain.ToolTypeSample) *ToolTypeSampleDTO {
+	return &ToolTypeSampleDTO{
+		Id:         sample.Id,
+		ImageUrl:   sample.ImageUrl,
+		Confidence: sample.Confidence,
+		CreatedAt:  sample.CreatedAt,
+	}
+}
+
+func toArrToolTypeSampleDTO(samples []*domain.ToolTypeSample) []*ToolTypeSampleDTO {
+	res := make([]*ToolTypeSampleDTO, len(samples))
+	for i, sample := range samples {
+		res[i] = ToToolTypeSampleDTO(sample)
+	}
+
+	return res
+}
+
+func NewAddToolTypeReferenceReq(toolTypeId int64, label string, embedding []float32) *AddToolTypeReferenceReq {
+	return &AddToolTypeReferenceReq{
+		ToolTypeId: toolTypeId,
+		Label:      label,
+		Embedding:  embedding,
+	}
+}
+
+func ToToolTypeReferenceDTO(reference *domain.ToolTypeReference) *ToolTypeReferenceDTO {
+	return &ToolTypeReferenceDTO{
+		Id:        reference.Id,
+		Label:     reference.Label,
+		CreatedAt: reference.CreatedAt,
+	}
+}
+
+func toArrToolTypeReferenceDTO(references []*domain.ToolTypeReference) []*ToolTypeReferenceDTO {
+	res := make([]*ToolTypeReferenceDTO, len(references))
+	for i, reference := range references {
+		res[i] = ToToolTypeReferenceDTO(reference)
+	}
+
+	return res
+}
+
+func NewCreateToolTypeReq(partNumber, name string, referenceEmbedding []float32) *CreateToolTypeReq {
+	return &CreateToolTypeReq{
+		PartNumber:         partNumber,
+		Name:               name,
+		ReferenceEmbedding: referenceEmbedding,
+	}
+}
+
 func ToToolTypeDTO(tool *domain.ToolType) *ToolTypeDTO {
 	return &ToolTypeDTO{
 		Id:         tool.Id,
 		PartNumber: tool.PartNumber,
 		Name:       tool.Name,
+
+		CalibrationIntervalDays: tool.CalibrationIntervalDays,
+		Thresholds:              tool.Thresholds,
 	}
 }
 
@@ -255,49 +700,84 @@ func NewUploadImageRes(key string, imageUrl string) *UploadImageRes {
 	}
 }
 
-func NewCheckinRes(imageUrl, debugImageUrl string, accessTools, manualCheckTools, unknownTools []*domain.RecognizedTool, missingTools []*ToolTypeDTO, transactionType, status string) *CheckRes {
+func NewCheckinRes(imageUrl, debugImageUrl string, filterRes *FilterRes, transactionType, status string) *CheckRes {
 	return &CheckRes{
 		ImageUrl:         imageUrl,
 		DebugImageUrl:    debugImageUrl,
-		AccessTools:      accessTools,
-		ProblematicTools: NewProblematicTools(manualCheckTools, unknownTools, missingTools),
+		AccessTools:      filterRes.AccessTools,
+		ProblematicTools: NewProblematicTools(filterRes),
+		ToolCounts:       filterRes.ToolCounts,
 		TransactionType:  transactionType,
 		Status:           status,
 	}
 }
 
-func NewFilterRes(accessTools, manualCheckTools, unknownTools []*domain.RecognizedTool, missingTools []*ToolTypeDTO) *FilterRes {
+func NewFilterRes(accessTools, manualCheckTools, unknownTools []*domain.RecognizedTool, missingTools []*ToolTypeDTO, misplacedTools []*domain.RecognizedTool, toolCounts []*ToolCountDTO) *FilterRes {
 	return &FilterRes{
 		AccessTools:      accessTools,
 		ManualCheckTools: manualCheckTools,
 		UnknownTools:     unknownTools,
 		MissingTools:     missingTools,
+		MisplacedTools:   misplacedTools,
+		ToolCounts:       toolCounts,
 	}
 }
 
-func NewFilterReq(confidenceCompare, cosineSimCompare float32, Tools []*domain.RecognizedTool, referenceTools []*domain.ToolType) *FilterReq {
+// ManualCheckCount число засчитанных инструментов, которые нужно проверить вручную:
+// не прошедшие автоматическую проверку и лежащие не в своём гнезде
+func (f *FilterRes) ManualCheckCount() int {
+	return len(f.ManualCheckTools) + len(f.MisplacedTools)
+}
+
+func NewToolCountDTO(toolType *ToolTypeDTO, expected, detected int, thresholds domain.Thresholds) *ToolCountDTO {
+	res := &ToolCountDTO{
+		ToolType:   toolType,
+		Expected:   expected,
+		Detected:   detected,
+		Thresholds: thresholds,
+	}
+
+	if detected > expected {
+		res.Surplus = detected - expected
+	} else {
+		res.Shortfall = expected - detected
+	}
+
+	return res
+}
+
+func NewFilterReq(defaults domain.Thresholds, duplicateIoU float32, Tools []*domain.RecognizedTool, referenceSet *domain.ToolSet) *FilterReq {
+	thresholds := make(map[int64]domain.Thresholds, len(referenceSet.Tools))
+	for _, tool := range referenceSet.Tools {
+		thresholds[tool.Id] = referenceSet.ThresholdsFor(tool, defaults)
+	}
+
 	return &FilterReq{
-		ConfidenceCompare: confidenceCompare,
-		CosineSimCompare:  cosineSimCompare,
-		Tools:             Tools,
-		ReferenceTools:    referenceTools,
+		Thresholds:     thresholds,
+		DuplicateIoU:   duplicateIoU,
+		Tools:          Tools,
+		ReferenceTools: referenceSet.Tools,
+		Quantities:     referenceSet.Quantities(),
+		Slots:          referenceSet.Slots,
 	}
 }
 
-func NewCreateScanReq(transactionId int64, scanType domain.ScanType, imageUrl, debugImageUrl string, tools []*domain.RecognizedTool) *CreateScanReq {
+func NewCreateScanReq(transactionId int64, scanType domain.ScanType, imageUrl, debugImageUrl string, modelVersion *string, tools []*domain.RecognizedTool) *CreateScanReq {
 	return &CreateScanReq{
 		TransactionId: transactionId,
 		ScanType:      scanType,
 		ImageUrl:      imageUrl,
 		DebugImageUrl: debugImageUrl,
+		ModelVersion:  modelVersion,
 		Tools:         tools,
 	}
 }
 
-func NewScanReq(imageId, imageUrl string, threshold float32) *ScanRequest {
+func NewScanReq(imageId, imageUrl, imageData string, threshold float32) *ScanRequest {
 	return &ScanRequest{
 		ImageId:   imageId,
 		ImageUrl:  imageUrl,
+		ImageData: imageData,
 		Threshold: threshold,
 	}
 }
@@ -309,23 +789,82 @@ func NewUploadImageReq(data, mode string) *UploadImageReq {
 	}
 }
 
-func NewTransactionProcess(userId int64, data string, toolSetId int64) *TransactionProcess {
+func NewTransactionProcess(userId int64, data string, toolSetId int64, badgeId, kioskId *int64) *TransactionProcess {
 	return &TransactionProcess{
 		UserId:    userId,
 		Data:      data,
 		ToolSetId: toolSetId,
+		BadgeId:   badgeId,
+		KioskId:   kioskId,
+	}
+}
+
+func NewCheckReq(employeeId, data string, toolSetId int64, badgeId, kioskId *int64) *CheckReq {
+	return &CheckReq{
+		EmployeeId: employeeId,
+		Data:       data,
+		ToolSetId:  toolSetId,
+		BadgeId:    badgeId,
+		KioskId:    kioskId,
+	}
+}
+
+func NewCreateBadgeReq(uid, employeeId string, activatedAt *time.Time) *CreateBadgeReq {
+	return &CreateBadgeReq{
+		Uid:         uid,
+		EmployeeId:  employeeId,
+		ActivatedAt: activatedAt,
 	}
 }
 
+func ToBadgeDTO(badge *domain.Badge, user UserDto) *BadgeDTO {
+	return &BadgeDTO{
+		Id:          badge.Id,
+		Uid:         badge.Uid,
+		User:        user,
+		ActivatedAt: badge.ActivatedAt,
+		RevokedAt:   badge.RevokedAt,
+		Active:      badge.IsActive(time.Now()) == nil,
+	}
+}
+
+func toArrBadgeDTO(badges []*domain.Badge, user UserDto) []*BadgeDTO {
+	res := make([]*BadgeDTO, len(badges))
+	for i, badge := range badges {
+		res[i] = ToBadgeDTO(badge, user)
+	}
+
+	return res
+}
+
 func NewGetRolesRes(roles []string) *GetRolesRes {
 	return &GetRolesRes{
 		Roles: roles,
 	}
 }
 
-func NewLoginRes(role string) *LoginRes {
+func NewLoginRes(role string, tokens *TokenPair) *LoginRes {
 	return &LoginRes{
-		Role: role,
+		Role:   role,
+		Tokens: tokens,
+	}
+}
+
+func NewTokenPair(accessToken, refreshToken string, accessExpiresAt, refreshExpiresAt time.Time) *TokenPair {
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}
+}
+
+func NewTokenClaims(userId int64, employeeId, role string, tokenVersion int) *TokenClaims {
+	return &TokenClaims{
+		UserId:       userId,
+		EmployeeId:   employeeId,
+		Role:         role,
+		TokenVersion: tokenVersion,
 	}
 }
 
@@ -372,10 +911,10 @@ func NewRegisterRes(id int64) *RegisterRes {
 	}
 }
 
-func NewVerification(transactionID int64, qaEmployeeId string, reason domain.Reason, notes string, toolIds []int64) *Verification {
+func NewVerification(transactionID int64, qaUserId int64, reason domain.Reason, notes string, toolIds []int64) *Verification {
 	return &Verification{
 		TransactionID: transactionID,
-		QAEmployeeId:  qaEmployeeId,
+		QAUserId:      qaUserId,
 		Reason:        reason,
 		Notes:         notes,
 		ToolsIds:      toolIds,
@@ -401,11 +940,12 @@ func NewGetAvgWorkDurationRes(transactions []GetAvgWorkDuration) *GetAvgWorkDura
 	}
 }
 
-func NewMlErrorTransaction(id int64, sUrl, dUrl string) *MlErrorTransaction {
+func NewMlErrorTransaction(id int64, sUrl, dUrl string, modelVersion *string) *MlErrorTransaction {
 	return &MlErrorTransaction{
 		TransactionID:  id,
 		SourceImageUrl: sUrl,
 		DebugImageUrl:  dUrl,
+		ModelVersion:   modelVersion,
 	}
 }
 
@@ -492,11 +1032,14 @@ func NewGetQAVerificationRes(id, toolSetId int64, createdAt time.Time, user User
 	}
 }
 
-func NewProblematicTools(manualCheckTools, unknownTools []*domain.RecognizedTool, missingTools []*ToolTypeDTO) *ProblematicTools {
+func NewProblematicTools(filterRes *FilterRes) *ProblematicTools {
 	return &ProblematicTools{
-		ManualCheckTools: manualCheckTools,
-		UnknownTools:     unknownTools,
-		MissingTools:     missingTools,
+		ManualCheckTools: filterRes.ManualCheckTools,
+		UnknownTools:     filterRes.UnknownTools,
+		MissingTools:     filterRes.MissingTools,
+		MisplacedTools:   filterRes.MisplacedTools,
+
+		PossiblySubstitutedTools: filterRes.PossiblySubstitutedTools,
 	}
 }
 
@@ -508,10 +1051,11 @@ func NewVerificationRes(id int64, status string, verifiedBy string, createdAt ti
 		CreatedAt:     createdAt,
 	}
 }
-func NewModelOrHumanStatsRes(ml int, humans int) *ModelOrHumanStatsRes {
+func NewModelOrHumanStatsRes(ml int, humans int, mlByModelVersion []repository.ModelVersionErrorCount) *ModelOrHumanStatsRes {
 	return &ModelOrHumanStatsRes{
-		MlErrors:    ml,
-		HumanErrors: humans,
+		MlErrors:               ml,
+		HumanErrors:            humans,
+		MlErrorsByModelVersion: mlByModelVersion,
 	}
 }
 
@@ -525,6 +1069,93 @@ func NewGetTransactionStatisticsRes(transactions, opened, closed, qa, failed int
 	}
 }
 
+func NewCreateToolInstanceReq(serialNumber string, toolTypeId int64, homeToolSetId *int64) *CreateToolInstanceReq {
+	return &CreateToolInstanceReq{
+		SerialNumber:  serialNumber,
+		ToolTypeId:    toolTypeId,
+		HomeToolSetId: homeToolSetId,
+	}
+}
+
+func NewListToolInstancesReq(toolTypeId, homeToolSetId *int64, status *string) *ListToolInstancesReq {
+	return &ListToolInstancesReq{
+		ToolTypeId:    toolTypeId,
+		HomeToolSetId: homeToolSetId,
+		Status:        status,
+	}
+}
+
+func ToToolInstanceDTO(instance *domain.ToolInstance) *ToolInstanceDTO {
+	res := &ToolInstanceDTO{
+		Id:            instance.Id,
+		SerialNumber:  instance.SerialNumber,
+		ToolTypeId:    instance.ToolTypeId,
+		HomeToolSetId: instance.HomeToolSetId,
+		Status:        string(instance.Status),
+		UpdatedAt:     instance.UpdatedAt,
+
+		LastCalibratedAt: instance.LastCalibratedAt,
+		CalibrationDueAt: instance.CalibrationDueAt,
+	}
+
+	if instance.ToolType != nil {
+		res.ToolType = ToToolTypeDTO(instance.ToolType)
+	}
+
+	return res
+}
+
+func toArrToolInstanceDTO(instances []*domain.ToolInstance) []*ToolInstanceDTO {
+	res := make([]*ToolInstanceDTO, len(instances))
+	for i, instance := range instances {
+		res[i] = ToToolInstanceDTO(instance)
+	}
+
+	return res
+}
+
+func NewEditToolSetReq(toolSetId int64, name string, toolsIds []int64) *EditToolSetReq {
+	return &EditToolSetReq{
+		ToolSetId: toolSetId,
+		Name:      name,
+		ToolsIds:  toolsIds,
+	}
+}
+
+func ToToolSetDTO(set *domain.ToolSet) *ToolSetDTO {
+	return &ToolSetDTO{
+		Id:        set.Id,
+		FamilyId:  set.FamilyId,
+		Version:   set.Version,
+		Name:      set.Name,
+		RetiredAt: set.RetiredAt,
+		CreatedAt: set.CreatedAt,
+		Tools:     toArrToolTypeDTO(set.Tools),
+		Items:     toArrToolSetItemDTO(set.Items),
+	}
+}
+
+func toArrToolSetItemDTO(items []*domain.ToolSetItem) []*ToolSetItemDTO {
+	res := make([]*ToolSetItemDTO, len(items))
+	for i, item := range items {
+		res[i] = &ToolSetItemDTO{
+			ToolTypeId: item.ToolTypeId,
+			Quantity:   item.Quantity,
+		}
+	}
+
+	return res
+}
+
+func toArrToolSetDTO(sets []*domain.ToolSet) []*ToolSetDTO {
+	res := make([]*ToolSetDTO, len(sets))
+	for i, set := range sets {
+		res[i] = ToToolSetDTO(set)
+	}
+
+	return res
+}
+
 func NewAddToolSetRes(id int64, name string, tools []*ToolTypeDTO) *AddToolSetRes {
 	return &AddToolSetRes{
 		Id:    id,
@@ -540,3 +1171,177 @@ func NewHumanErrorStats(fullName, employeeId string, QAHitsCount int64) HumanErr
 		QAHitsCount: QAHitsCount,
 	}
 }
+
+func NewRecordCalibrationReq(toolInstanceId, performedBy int64, calibratedAt, nextDueAt *time.Time, notes string) *RecordCalibrationReq {
+	return &RecordCalibrationReq{
+		ToolInstanceId: toolInstanceId,
+		PerformedBy:    performedBy,
+		CalibratedAt:   calibratedAt,
+		NextDueAt:      nextDueAt,
+		Notes:          notes,
+	}
+}
+
+func NewCalibrationEventDTO(event *domain.CalibrationEvent, instance *ToolInstanceDTO) *CalibrationEventDTO {
+	return &CalibrationEventDTO{
+		Id:           event.Id,
+		Instance:     instance,
+		CalibratedAt: event.CalibratedAt,
+		NextDueAt:    event.NextDueAt,
+		PerformedBy:  event.PerformedBy,
+		Notes:        event.Notes,
+		CreatedAt:    event.CreatedAt,
+	}
+}
+
+func toArrCalibrationEventDTO(events []*domain.CalibrationEvent) []*CalibrationEventDTO {
+	res := make([]*CalibrationEventDTO, len(events))
+	for i, event := range events {
+		res[i] = NewCalibrationEventDTO(event, nil)
+	}
+
+	return res
+}
+
+func NewCalibrationDueDTO(instance *domain.ToolInstance, now time.Time) *CalibrationDueDTO {
+	res := &CalibrationDueDTO{
+		Instance: ToToolInstanceDTO(instance),
+		Overdue:  instance.IsCalibrationOverdue(now),
+	}
+
+	if instance.CalibrationDueAt != nil {
+		daysLeft := int(math.Floor(instance.CalibrationDueAt.Sub(now).Hours() / 24))
+		res.DaysLeft = &daysLeft
+	}
+
+	return res
+}
+
+func NewIdempotentResponse(statusCode int, body []byte) *IdempotentResponse {
+	return &IdempotentResponse{
+		StatusCode: statusCode,
+		Body:       body,
+	}
+}
+
+func ToScanJobDTO(job *domain.ScanJob, result *CheckRes) *ScanJobDTO {
+	return &ScanJobDTO{
+		Id:            job.Id,
+		Status:        string(job.Status),
+		TransactionId: job.TransactionId,
+		Result:        result,
+		Error:         job.Error,
+		CreatedAt:     job.CreatedAt,
+		FinishedAt:    job.FinishedAt,
+	}
+}
+
+func ToQAQueueItemDTO(item *domain.QAQueueItem, now time.Time, sla time.Duration) *QAQueueItemDTO {
+	res := &QAQueueItemDTO{
+		TransactionId: item.TransactionId,
+		EnqueuedAt:    item.EnqueuedAt,
+		TimeInQueue:   item.TimeInQueue(now),
+		SLA:           sla,
+		Overdue:       item.IsOverdue(now, sla),
+		EscalatedAt:   item.EscalatedAt,
+		AssignedAt:    item.AssignedAt,
+	}
+
+	if item.Transaction != nil {
+		res.ToolSetId = item.Transaction.ToolSetId
+		if item.Transaction.User != nil {
+			res.User = NewUserDto(item.Transaction.User.FullName, item.Transaction.User.EmployeeId)
+		}
+	}
+
+	if item.Assignee != nil {
+		assignee := NewUserDto(item.Assignee.FullName, item.Assignee.EmployeeId)
+		res.Assignee = &assignee
+	}
+
+	if item.IsClaimed(now) && item.Claimer != nil {
+		claimer := NewUserDto(item.Claimer.FullName, item.Claimer.EmployeeId)
+		res.ClaimedBy = &claimer
+		res.ClaimExpiresAt = item.ClaimExpiresAt
+	}
+
+	return res
+}
+
+func NewCreateMLClassMappingReq(modelVersion string, classIndex, toolTypeId int64) *CreateMLClassMappingReq {
+	return &CreateMLClassMappingReq{
+		ModelVersion: modelVersion,
+		ClassIndex:   classIndex,
+		ToolTypeId:   toolTypeId,
+	}
+}
+
+func ToMLClassMappingDTO(mapping *domain.MLClassMapping) *MLClassMappingDTO {
+	res := &MLClassMappingDTO{
+		Id:           mapping.Id,
+		ModelVersion: mapping.ModelVersion,
+		ClassIndex:   mapping.ClassIndex,
+		ToolTypeId:   mapping.ToolTypeId,
+		CreatedAt:    mapping.CreatedAt,
+	}
+
+	if mapping.ToolType != nil {
+		res.ToolType = ToToolTypeDTO(mapping.ToolType)
+	}
+
+	return res
+}
+
+func toArrMLClassMappingDTO(mappings []*domain.MLClassMapping) []*MLClassMappingDTO {
+	res := make([]*MLClassMappingDTO, len(mappings))
+	for i, mapping := range mappings {
+		res[i] = ToMLClassMappingDTO(mapping)
+	}
+
+	return res
+}
+
+func NewShadowComparisonDTO(comparison *repository.ShadowComparison) *ShadowComparisonDTO {
+	res := &ShadowComparisonDTO{
+		ScansCount:  comparison.Summary.ScansCount,
+		FailedCount: comparison.Summary.FailedCount,
+		Statuses:    comparison.Statuses,
+		ToolTypes:   comparison.ToolTypes,
+	}
+
+	if completed := comparison.Summary.ScansCount - comparison.Summary.FailedCount; completed > 0 {
+		res.AgreementRate = float64(comparison.Summary.AgreedScans) / float64(completed)
+		res.StatusAgreementRate = float64(comparison.Summary.StatusAgreedScans) / float64(completed)
+	}
+
+	return res
+}
+
+func NewThresholdSweepReq(filter repository.ScanHistoryFilter, toolTypeId *int64, confidence, cosineSim ThresholdRange) *ThresholdSweepReq {
+	return &ThresholdSweepReq{
+		Filter:     filter,
+		ToolTypeId: toolTypeId,
+		Confidence: confidence,
+		CosineSim:  cosineSim,
+	}
+}
+
+// DefaultThresholdRange диапазон перебора по умолчанию: от 0.50 до 0.95 с шагом 0.05
+func DefaultThresholdRange() ThresholdRange {
+	return ThresholdRange{Min: 0.5, Max: 0.95, Step: 0.05}
+}
+
+func NewToolSetThresholdDTO(set *domain.ToolSet, toolType *domain.ToolType, defaults domain.Thresholds) *ToolSetThresholdDTO {
+	res := &ToolSetThresholdDTO{
+		ToolType:  ToToolTypeDTO(toolType),
+		Effective: set.ThresholdsFor(toolType, defaults),
+	}
+
+	for _, override := range set.Thresholds {
+		if override.ToolTypeId == toolType.Id {
+			res.SetOverride = override.ThresholdsOverride
+		}
+	}
+
+	return res
+}
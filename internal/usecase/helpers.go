@@ -2,13 +2,22 @@ package usecase
 
 import (
 	"airport-tools-backend/internal/domain"
+	"airport-tools-backend/internal/repository"
+	"airport-tools-backend/pkg/e"
+	"errors"
 	"math"
+	"sort"
 )
 
 // cosineSimilarity вычисляет косинусное сходство между двумя векторами
 func cosineSimilarity(reference, recognized []float32) float32 {
 	if len(recognized) == 0 {
-		recognized = make([]float32, 1280)
+		recognized = make([]float32, domain.EmbeddingSize)
+	}
+
+	// эмбеддинг другой размерности с эталоном несравним
+	if len(recognized) != len(reference) {
+		return 0
 	}
 
 	var dot, normReference, normRecognized float64
@@ -20,41 +29,480 @@ func cosineSimilarity(reference, recognized []float32) float32 {
 	return float32(dot / (math.Sqrt(normReference) * math.Sqrt(normRecognized)))
 }
 
-// filterRecognizedTools разделяет инструменты на категории
+// bestReferenceMatch возвращает максимальное косинусное сходство по основному и дополнительным эталонам типа
+// и идентификатор дополнительного эталона, давшего это сходство (nil — основной эмбеддинг)
+func bestReferenceMatch(toolType *domain.ToolType, embedding []float32) (float32, *int64) {
+	best := cosineSimilarity(toolType.ReferenceEmbedding, embedding)
+	var bestReferenceId *int64
+
+	for _, reference := range toolType.References {
+		cosSim := cosineSimilarity(reference.Embedding, embedding)
+		if cosSim > best {
+			best = cosSim
+			referenceId := reference.Id
+			bestReferenceId = &referenceId
+		}
+	}
+
+	return best, bestReferenceId
+}
+
+// filterRecognizedTools разделяет инструменты на категории.
+// Повторные детекции одного инструмента отбрасываются по IoU рамок.
+// Детекции считаются по каждому типу: сверх требуемого количества попадают в unknown,
+// недостающие экземпляры — в missing (по одной записи на экземпляр).
+// Если для набора задана раскладка ложемента, инструменты не в своих гнёздах попадают в misplaced
 func filterRecognizedTools(req *FilterReq) (*FilterRes, error) {
 	accessTools := make([]*domain.RecognizedTool, 0, len(req.Tools))
 	manualCheckTools := make([]*domain.RecognizedTool, 0, len(req.Tools))
 	unknownTools := make([]*domain.RecognizedTool, 0, len(req.Tools))
 	missingTools := make([]*ToolTypeDTO, 0)
+	toolCounts := make([]*ToolCountDTO, 0, len(req.ReferenceTools))
 
 	refMap := make(map[int64]*domain.ToolType)
 	for _, r := range req.ReferenceTools {
 		refMap[r.Id] = r
 	}
 
-	recognizedMap := make(map[int64]*domain.RecognizedTool)
-	for _, recognized := range req.Tools {
-		ref, exists := refMap[recognized.ToolTypeId]
-		if !exists {
+	recognizedByType := make(map[int64][]*domain.RecognizedTool)
+	for _, recognized := range suppressDuplicates(req.Tools, req.DuplicateIoU) {
+		// классы без сопоставления с типом инструмента не отбрасываются, а показываются как неизвестные
+		if recognized.ToolTypeId == nil {
 			unknownTools = append(unknownTools, recognized)
 			continue
 		}
 
-		cosSim := cosineSimilarity(ref.ReferenceEmbedding, recognized.Embedding)
-		if recognized.Confidence < req.ConfidenceCompare || cosSim < req.CosineSimCompare {
-			manualCheckTools = append(manualCheckTools, recognized)
-		} else {
-			accessTools = append(accessTools, recognized)
+		ref, exists := refMap[*recognized.ToolTypeId]
+		if !exists {
+			unknownTools = append(unknownTools, recognized)
+			continue
 		}
 
-		recognizedMap[recognized.ToolTypeId] = recognized
+		cosSim, referenceId := bestReferenceMatch(ref, recognized.Embedding)
+		recognized.CosineSimilarity = &cosSim
+		recognized.ReferenceId = referenceId
+		thresholds := req.Thresholds[ref.Id]
+		recognized.Thresholds = &thresholds
+
+		recognizedByType[ref.Id] = append(recognizedByType[ref.Id], recognized)
+	}
+
+	passes := func(tool *domain.RecognizedTool) bool {
+		return tool.Passes(*tool.Thresholds)
 	}
 
 	for _, ref := range req.ReferenceTools {
-		if _, ok := recognizedMap[ref.Id]; !ok {
+		expected := 1
+		if quantity, ok := req.Quantities[ref.Id]; ok {
+			expected = quantity
+		}
+
+		// в зачёт идут лучшие детекции: сначала прошедшие автоматическую проверку, затем по уверенности
+		detected := recognizedByType[ref.Id]
+		sort.SliceStable(detected, func(i, j int) bool {
+			if passes(detected[i]) != passes(detected[j]) {
+				return passes(detected[i])
+			}
+			return detected[i].Confidence > detected[j].Confidence
+		})
+
+		for i, recognized := range detected {
+			switch {
+			case i >= expected:
+				unknownTools = append(unknownTools, recognized)
+			case passes(recognized):
+				accessTools = append(accessTools, recognized)
+			default:
+				manualCheckTools = append(manualCheckTools, recognized)
+			}
+		}
+
+		for i := len(detected); i < expected; i++ {
 			missingTools = append(missingTools, ToToolTypeDTO(ref))
 		}
+
+		toolCounts = append(toolCounts, NewToolCountDTO(ToToolTypeDTO(ref), expected, len(detected), req.Thresholds[ref.Id]))
+	}
+
+	// расположение проверяется только у засчитанных инструментов, гнёзда сначала занимают прошедшие проверку
+	var misplacedTools []*domain.RecognizedTool
+	if len(req.Slots) > 0 {
+		occupied := make(map[int64]bool, len(req.Slots))
+		var misplacedAccess, misplacedManual []*domain.RecognizedTool
+		accessTools, misplacedAccess = placeTools(accessTools, req.Slots, occupied)
+		manualCheckTools, misplacedManual = placeTools(manualCheckTools, req.Slots, occupied)
+		misplacedTools = append(misplacedAccess, misplacedManual...)
+	}
+
+	return NewFilterRes(accessTools, manualCheckTools, unknownTools, missingTools, misplacedTools, toolCounts), nil
+}
+
+// suppressDuplicates отбрасывает повторные детекции одного инструмента: из детекций одного класса, рамки которых
+// пересекаются с IoU не ниже iou, остаётся детекция с наибольшей уверенностью. iou <= 0 отключает подавление
+func suppressDuplicates(tools []*domain.RecognizedTool, iou float32) []*domain.RecognizedTool {
+	if iou <= 0 {
+		return tools
+	}
+
+	sorted := append([]*domain.RecognizedTool{}, tools...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Confidence > sorted[j].Confidence
+	})
+
+	kept := make([]*domain.RecognizedTool, 0, len(sorted))
+	for _, tool := range sorted {
+		duplicate := false
+		for _, other := range kept {
+			if sameClass(tool, other) && tool.IoU(other) >= iou {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			kept = append(kept, tool)
+		}
+	}
+
+	return kept
+}
+
+// sameClass сообщает, что детекции относятся к одному типу инструмента, а без сопоставления — к одному классу модели
+func sameClass(a, b *domain.RecognizedTool) bool {
+	if a.ToolTypeId != nil && b.ToolTypeId != nil {
+		return *a.ToolTypeId == *b.ToolTypeId
+	}
+
+	return a.ToolTypeId == nil && b.ToolTypeId == nil && a.ClassIndex == b.ClassIndex
+}
+
+// placeTools раскладывает инструменты по свободным гнёздам их типа, занимая гнёзда в occupied. Инструмент,
+// центр рамки которого не попал ни в одно свободное гнездо своего типа, считается лежащим не на месте; ему
+// проставляется чужое гнездо, в котором он лежит. Типы без гнёзд в раскладке не проверяются
+func placeTools(tools []*domain.RecognizedTool, slots []*domain.BoardSlot, occupied map[int64]bool) (placed, misplaced []*domain.RecognizedTool) {
+	placed = make([]*domain.RecognizedTool, 0, len(tools))
+	for _, tool := range tools {
+		hasSlots := false
+		for _, slot := range slots {
+			if tool.IsOfType(slot.ToolTypeId) {
+				hasSlots = true
+				break
+			}
+		}
+
+		if !hasSlots {
+			placed = append(placed, tool)
+			continue
+		}
+
+		// без рамки расположение подтвердить нельзя
+		if !tool.HasBbox() {
+			misplaced = append(misplaced, tool)
+			continue
+		}
+
+		center := tool.Center()
+		var own, foreign *domain.BoardSlot
+		for _, slot := range slots {
+			if !slot.Contains(center) {
+				continue
+			}
+
+			if tool.IsOfType(slot.ToolTypeId) && !occupied[slot.Id] {
+				own = slot
+				break
+			}
+			if foreign == nil {
+				foreign = slot
+			}
+		}
+
+		if own != nil {
+			occupied[own.Id] = true
+			tool.SlotId = &own.Id
+			placed = append(placed, tool)
+			continue
+		}
+
+		if foreign != nil {
+			tool.SlotId = &foreign.Id
+		}
+		misplaced = append(misplaced, tool)
+	}
+
+	return placed, misplaced
+}
+
+// matchCheckoutTools сопоставляет сданные инструменты с детекциями того же типа на скане выдачи: пары
+// составляются по убыванию косинусной близости эмбеддингов, каждая детекция выдачи используется один раз.
+// Инструменты, близость которых к своей паре ниже minSim, возвращаются как возможно подменённые; инструменты
+// без пары (тип не был распознан при выдаче) не проверяются
+func matchCheckoutTools(returned []*domain.RecognizedTool, issued []*domain.CvScanDetail, minSim float32) []*domain.RecognizedTool {
+	type candidate struct {
+		returned *domain.RecognizedTool
+		issued   *domain.CvScanDetail
+		sim      float32
+	}
+
+	candidates := make([]candidate, 0, len(returned))
+	for _, tool := range returned {
+		if len(tool.Embedding) != domain.EmbeddingSize {
+			continue
+		}
+
+		for _, detail := range issued {
+			if detail.DetectedToolTypeId == nil || !tool.IsOfType(*detail.DetectedToolTypeId) || len(detail.Embedding) != domain.EmbeddingSize {
+				continue
+			}
+			candidates = append(candidates, candidate{tool, detail, cosineSimilarity(detail.Embedding, tool.Embedding)})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].sim > candidates[j].sim
+	})
+
+	paired := make(map[*domain.RecognizedTool]bool, len(returned))
+	used := make(map[*domain.CvScanDetail]bool, len(issued))
+	substituted := make([]*domain.RecognizedTool, 0)
+	for _, c := range candidates {
+		if paired[c.returned] || used[c.issued] {
+			continue
+		}
+		paired[c.returned] = true
+		used[c.issued] = true
+
+		sim := c.sim
+		c.returned.CheckoutSimilarity = &sim
+		if sim < minSim {
+			substituted = append(substituted, c.returned)
+		}
+	}
+
+	return substituted
+}
+
+// checkoutStatus статус выдачи по результату фильтрации: выдача проходит, только если все инструменты набора
+// найдены и прошли автоматическую проверку с порогами своего типа. Пороги каждой детекции возвращаются в CheckRes
+func checkoutStatus(filterRes *FilterRes, referenceSet *domain.ToolSet) domain.Status {
+	if len(filterRes.MissingTools) > 0 || len(filterRes.UnknownTools) > 0 || filterRes.ManualCheckCount() > 0 ||
+		len(filterRes.AccessTools) != referenceSet.TotalQuantity() {
+		return domain.FAILED
+	}
+
+	return domain.OPEN
+}
+
+// checkinStatus статус транзакции после сдачи с результатом фильтрации filterRes, если до неё было countOfChecks проверок
+func checkinStatus(filterRes *FilterRes, countOfChecks int64) domain.Status {
+	transaction := &domain.Transaction{CountOfChecks: countOfChecks + 1}
+	transaction.EvaluateStatus(filterRes.ManualCheckCount(), len(filterRes.UnknownTools), len(filterRes.MissingTools))
+
+	return transaction.Status
+}
+
+// maxThresholdSweepPairs ограничение на число пар порогов в одном отчёте калибровки
+const maxThresholdSweepPairs = 2500
+
+// maxThresholdSweepScans ограничение на число сканов сдачи в одном отчёте калибровки: сканы загружаются в память
+// вместе с эмбеддингами детекций, поэтому из более длинного периода берутся только последние сканы
+const maxThresholdSweepScans = 2000
+
+// thresholdGrid значения порога из диапазона r, который должен лежать в [low, high]. Значения округляются
+// до 4 знаков, чтобы накопленная ошибка шага не выводила последнее значение за Max
+func thresholdGrid(r ThresholdRange, low, high float32) ([]float32, error) {
+	if r.Step <= 0 || r.Min > r.Max || r.Min < low || r.Max > high {
+		return nil, e.ErrThresholdSweepInvalid
+	}
+
+	count := int(math.Floor(float64((r.Max-r.Min)/r.Step)+1e-6)) + 1
+	if count > maxThresholdSweepPairs {
+		return nil, e.ErrThresholdSweepInvalid
+	}
+
+	values := make([]float32, count)
+	for i := range values {
+		values[i] = float32(math.Round(float64(r.Min+float32(i)*r.Step)*1e4) / 1e4)
+	}
+
+	return values, nil
+}
+
+// newCalibrationScan прогоняет сохранённые детекции скана через фильтр с действующими порогами набора,
+// вычисляя близость к эталонам типов, и группирует детекции типов набора для перебора порогов.
+// Близость считается здесь один раз на детекцию, перебор порогов только сравнивает её с порогом,
+// поэтому эмбеддинги после фильтрации не нужны и отпускаются
+func newCalibrationScan(history *repository.CheckinScanHistory, set *domain.ToolSet, defaults domain.Thresholds, duplicateIoU float32) (*calibrationScan, error) {
+	tools := make([]*domain.RecognizedTool, len(history.Details))
+	for i, detail := range history.Details {
+		tools[i] = domain.NewRecognizedTool(detail.ClassIndex, detail.Confidence, detail.Embedding, detail.Bbox)
+		tools[i].ToolTypeId = detail.DetectedToolTypeId
+	}
+
+	filterReq := NewFilterReq(defaults, duplicateIoU, tools, set)
+	filterRes, err := filterRecognizedTools(filterReq)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &calibrationScan{
+		CheckNumber: history.CheckNumber,
+		Resolved:    history.Resolved,
+		ModelErr:    make(map[int64]bool, len(history.ModelErrToolTypeIds)),
+		Current:     filterReq.Thresholds,
+		Expected:    make(map[int64]int, len(set.Tools)),
+		Detected:    make(map[int64][]*domain.RecognizedTool),
+		Misplaced:   make(map[*domain.RecognizedTool]bool, len(filterRes.MisplacedTools)),
+		Unknown:     len(filterRes.UnknownTools),
+		Missing:     len(filterRes.MissingTools),
+
+		CurrentManualCheck: filterRes.ManualCheckCount(),
+	}
+
+	for _, toolTypeId := range history.ModelErrToolTypeIds {
+		scan.ModelErr[toolTypeId] = true
+	}
+
+	for _, count := range filterRes.ToolCounts {
+		scan.Expected[count.ToolType.Id] = count.Expected
+	}
+
+	for _, tool := range filterRes.MisplacedTools {
+		scan.Misplaced[tool] = true
+	}
+
+	// детекции без типа из набора и сверх требуемого количества неизвестны при любых порогах, поэтому unknown
+	// считается один раз, а в переборе участвуют все оставшиеся после подавления повторов детекции типов набора
+	detected := append(append(append([]*domain.RecognizedTool{}, filterRes.AccessTools...), filterRes.ManualCheckTools...), filterRes.MisplacedTools...)
+	for _, tool := range filterRes.UnknownTools {
+		if tool.CosineSimilarity != nil {
+			detected = append(detected, tool)
+		}
+	}
+	for _, tool := range detected {
+		scan.Detected[*tool.ToolTypeId] = append(scan.Detected[*tool.ToolTypeId], tool)
 	}
 
-	return NewFilterRes(accessTools, manualCheckTools, unknownTools, missingTools), nil
+	for _, tool := range tools {
+		tool.Embedding = nil
+	}
+
+	return scan, nil
+}
+
+// replayThresholds повторяет автоматическую проверку сканов с парой порогов pair. Если toolTypeId задан,
+// pair применяется только к этому типу и только его детекции входят в precision/recall
+func replayThresholds(scans []*calibrationScan, pair domain.Thresholds, toolTypeId *int64) *ThresholdPairStats {
+	stats := &ThresholdPairStats{Thresholds: pair}
+
+	for _, scan := range scans {
+		manualCheck := 0
+		for typeId, detected := range scan.Detected {
+			thresholds := scan.Current[typeId]
+			swept := toolTypeId == nil || *toolTypeId == typeId
+			if swept {
+				thresholds = pair
+			}
+
+			passed := 0
+			for _, tool := range detected {
+				passes := tool.Passes(thresholds)
+				// инструмент не на своём месте уходит на ручную проверку при любых порогах
+				if passes && !scan.Misplaced[tool] {
+					passed++
+				}
+
+				if !swept || !scan.Resolved {
+					continue
+				}
+
+				correct := !scan.ModelErr[typeId]
+				switch {
+				case passes && correct:
+					stats.TruePositives++
+				case passes:
+					stats.FalsePositives++
+				case correct:
+					stats.FalseNegatives++
+				default:
+					stats.TrueNegatives++
+				}
+			}
+
+			// в зачёт идут прошедшие проверку детекции, остальные в пределах количества требуют ручной проверки
+			expected := scan.Expected[typeId]
+			manualCheck += min(len(detected), expected) - min(passed, expected)
+		}
+
+		if scan.escalates(manualCheck) {
+			stats.Escalations++
+		}
+	}
+
+	if positives := stats.TruePositives + stats.FalsePositives; positives > 0 {
+		stats.Precision = float64(stats.TruePositives) / float64(positives)
+	}
+	if correct := stats.TruePositives + stats.FalseNegatives; correct > 0 {
+		stats.Recall = float64(stats.TruePositives) / float64(correct)
+	}
+
+	return stats
+}
+
+// escalates сообщает, ушла бы сдача на QA, если бы manualCheck детекций не прошли автоматическую проверку
+func (c *calibrationScan) escalates(manualCheck int) bool {
+	transaction := &domain.Transaction{CountOfChecks: c.CheckNumber}
+	transaction.EvaluateStatus(manualCheck, c.Unknown, c.Missing)
+
+	return transaction.Status == domain.QA
+}
+
+// bestDetectionOfType возвращает детекцию ожидаемого типа с наибольшей уверенностью
+func bestDetectionOfType(tools []*domain.RecognizedTool, toolTypeId int64) *domain.RecognizedTool {
+	var best *domain.RecognizedTool
+	for _, tool := range tools {
+		if !tool.IsOfType(toolTypeId) || len(tool.Embedding) != domain.EmbeddingSize {
+			continue
+		}
+
+		if best == nil || tool.Confidence > best.Confidence {
+			best = tool
+		}
+	}
+
+	return best
+}
+
+func toolInstanceIds(instances []*domain.ToolInstance) []int64 {
+	ids := make([]int64, len(instances))
+	for i, instance := range instances {
+		ids[i] = instance.Id
+	}
+
+	return ids
+}
+
+// rootCause возвращает исходную ошибку без обёрток e.Wrap
+func rootCause(err error) error {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return err
+		}
+		err = unwrapped
+	}
+}
+
+// stripEmbeddings убирает эмбеддинги из результата проверки перед сохранением: клиенту они не отдаются
+func stripEmbeddings(res *CheckRes) {
+	tools := append([]*domain.RecognizedTool{}, res.AccessTools...)
+	if res.ProblematicTools != nil {
+		tools = append(tools, res.ProblematicTools.ManualCheckTools...)
+		tools = append(tools, res.ProblematicTools.MisplacedTools...)
+		tools = append(tools, res.ProblematicTools.UnknownTools...)
+	}
+
+	for _, tool := range tools {
+		tool.Embedding = nil
+	}
 }
@@ -6,15 +6,25 @@ import (
 	"airport-tools-backend/pkg/e"
 	"airport-tools-backend/pkg/logger"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// scanJobMaxAttempts сколько раз задание может быть взято в обработку, прежде чем будет завершено с ошибкой
+const scanJobMaxAttempts = 3
+
 // TODO: заменить на реальные данные
 const (
 	SourceImages string = "source_images"
+	SampleImages string = "tool_type_samples"
 	DefaultSetId int64  = 1
 	Checkin      string = "Checkin"
 	Checkout     string = "Checkout"
@@ -31,17 +41,58 @@ type Service struct {
 	imageStorage      ImageStorage
 	ConfidenceCompare float32
 	CosineSimCompare  float32
-	trResolution      repository.TransactionResolutionsRepository
-	logger            logger.Logger
-	roleRepo          repository.RoleRepository
+	// DuplicateIoU IoU рамок, начиная с которого детекции одного класса считаются одним инструментом
+	DuplicateIoU float32
+	// SubstitutionSim косинусная близость к детекции на скане выдачи, ниже которой сданный инструмент
+	// считается возможно подменённым
+	SubstitutionSim  float32
+	trResolution     repository.TransactionResolutionsRepository
+	logger           logger.Logger
+	roleRepo         repository.RoleRepository
+	tokenManager     TokenManager
+	passwordHasher   PasswordHasher
+	badgeRepo        repository.BadgeRepository
+	sampleRepo       repository.ToolTypeSampleRepository
+	referenceRepo    repository.ToolTypeReferenceRepository
+	instanceRepo     repository.ToolInstanceRepository
+	calibrationRepo  repository.CalibrationEventRepository
+	uow              repository.UnitOfWork
+	idempotencyRepo  repository.IdempotencyKeyRepository
+	scanJobRepo      repository.ScanJobRepository
+	classMappingRepo repository.MLClassMappingRepository
+	// shadowGateway теневая модель; nil, если сравнение моделей не настроено
+	shadowGateway  MLGateway
+	shadowScanRepo repository.ShadowScanRepository
+	shadowTasks    chan *shadowScanTask
+	qaQueueRepo    repository.QAQueueRepository
+	// QAClaimTTL срок, на который аудитор берёт транзакцию из очереди QA в работу
+	QAClaimTTL time.Duration
+	// QASLA допустимое время ожидания транзакции в очереди QA; просроченные элементы эскалируются
+	QASLA time.Duration
+	// LoginMaxAttempts число неверных PIN-кодов подряд, после которого вход блокируется на LoginLockout
+	LoginMaxAttempts int
+	LoginLockout     time.Duration
 }
 
 func NewService(
 	u repository.UserRepository, c repository.CvScanRepository, cd repository.CvScanDetailRepository,
 	tt repository.ToolTypeRepository, t repository.TransactionRepository, ml MLGateway, s3 ImageStorage,
-	ts repository.ToolSetRepository, condfidence, cosineSim float32, tr repository.TransactionResolutionsRepository,
-	logger logger.Logger, roleRepo repository.RoleRepository,
+	ts repository.ToolSetRepository, condfidence, cosineSim, duplicateIoU, substitutionSim float32, tr repository.TransactionResolutionsRepository,
+	logger logger.Logger, roleRepo repository.RoleRepository, tokenManager TokenManager, passwordHasher PasswordHasher,
+	badgeRepo repository.BadgeRepository, sampleRepo repository.ToolTypeSampleRepository,
+	referenceRepo repository.ToolTypeReferenceRepository, instanceRepo repository.ToolInstanceRepository,
+	calibrationRepo repository.CalibrationEventRepository, uow repository.UnitOfWork,
+	idempotencyRepo repository.IdempotencyKeyRepository, scanJobRepo repository.ScanJobRepository,
+	classMappingRepo repository.MLClassMappingRepository, shadowGateway MLGateway,
+	shadowScanRepo repository.ShadowScanRepository, shadowQueueSize int,
+	qaQueueRepo repository.QAQueueRepository, qaClaimTTL, qaSLA time.Duration,
+	loginMaxAttempts int, loginLockout time.Duration,
 ) *Service {
+	var shadowTasks chan *shadowScanTask
+	if shadowGateway != nil {
+		shadowTasks = make(chan *shadowScanTask, shadowQueueSize)
+	}
+
 	return &Service{
 		userRepo:          u,
 		cvScanRepo:        c,
@@ -53,9 +104,30 @@ func NewService(
 		toolSetRepo:       ts,
 		ConfidenceCompare: condfidence,
 		CosineSimCompare:  cosineSim,
+		DuplicateIoU:      duplicateIoU,
+		SubstitutionSim:   substitutionSim,
 		trResolution:      tr,
 		logger:            logger,
 		roleRepo:          roleRepo,
+		tokenManager:      tokenManager,
+		passwordHasher:    passwordHasher,
+		badgeRepo:         badgeRepo,
+		sampleRepo:        sampleRepo,
+		referenceRepo:     referenceRepo,
+		instanceRepo:      instanceRepo,
+		calibrationRepo:   calibrationRepo,
+		uow:               uow,
+		idempotencyRepo:   idempotencyRepo,
+		scanJobRepo:       scanJobRepo,
+		classMappingRepo:  classMappingRepo,
+		shadowGateway:     shadowGateway,
+		shadowScanRepo:    shadowScanRepo,
+		shadowTasks:       shadowTasks,
+		qaQueueRepo:       qaQueueRepo,
+		QAClaimTTL:        qaClaimTTL,
+		QASLA:             qaSLA,
+		LoginMaxAttempts:  loginMaxAttempts,
+		LoginLockout:      loginLockout,
 	}
 }
 
@@ -68,7 +140,8 @@ func (s *Service) Check(ctx context.Context, req *CheckReq) (*CheckRes, error) {
 		return nil, e.Wrap(op, err)
 	}
 
-	transactionProcess := NewTransactionProcess(user.Id, req.Data, req.ToolSetId)
+	transactionProcess := NewTransactionProcess(user.Id, req.Data, req.ToolSetId, req.BadgeId, req.KioskId)
+	transactionProcess.ScanJob = req.ScanJob
 
 	if err := user.CanCheckout(); err != nil {
 		if err := user.CanCheckin(); err != nil {
@@ -98,6 +171,181 @@ func (s *Service) Check(ctx context.Context, req *CheckReq) (*CheckRes, error) {
 	return res, nil
 }
 
+// CheckByBadge выполняет выдачу/сдачу инструментов, определяя инженера по UID пропуска
+func (s *Service) CheckByBadge(ctx context.Context, req *CheckByBadgeReq) (*CheckRes, error) {
+	const op = "usecase.CheckByBadge"
+
+	checkReq, err := s.checkReqByBadge(ctx, req)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res, err := s.Check(ctx, checkReq)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// checkReqByBadge определяет инженера по пропуску и формирует запрос на проверку
+func (s *Service) checkReqByBadge(ctx context.Context, req *CheckByBadgeReq) (*CheckReq, error) {
+	badge, err := s.badgeRepo.GetByUidWithUser(ctx, domain.NormalizeBadgeUid(req.BadgeUid))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := badge.IsActive(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if !badge.User.HasRole(domain.Engineer) {
+		return nil, e.ErrForbidden
+	}
+
+	return NewCheckReq(badge.User.EmployeeId, req.Data, req.ToolSetId, &badge.Id, &req.KioskId), nil
+}
+
+// EnqueueCheck ставит проверку в очередь и сразу возвращает задание; результат формирует воркер.
+// Пользователь и возможность выдачи/сдачи проверяются до постановки в очередь
+func (s *Service) EnqueueCheck(ctx context.Context, req *CheckReq) (*ScanJobDTO, error) {
+	const op = "usecase.EnqueueCheck"
+
+	user, err := s.userRepo.GetByEmployeeIdWithTransactions(ctx, req.EmployeeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := user.CanCheckout(); err != nil {
+		if err := user.CanCheckin(); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	job, err := s.scanJobRepo.Create(ctx, domain.NewScanJob(user.Id, req.Data, req.ToolSetId, req.BadgeId, req.KioskId))
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToScanJobDTO(job, nil), nil
+}
+
+// EnqueueCheckByBadge ставит в очередь проверку, определяя инженера по UID пропуска
+func (s *Service) EnqueueCheckByBadge(ctx context.Context, req *CheckByBadgeReq) (*ScanJobDTO, error) {
+	const op = "usecase.EnqueueCheckByBadge"
+
+	checkReq, err := s.checkReqByBadge(ctx, req)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	job, err := s.EnqueueCheck(ctx, checkReq)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return job, nil
+}
+
+// GetScanJob возвращает состояние задания и, если оно выполнено, результат проверки.
+// Чужое задание для requesterId не отличается от несуществующего
+func (s *Service) GetScanJob(ctx context.Context, id string, requesterId int64) (*ScanJobDTO, error) {
+	const op = "usecase.GetScanJob"
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, e.Wrap(op, e.ErrScanJobNotFound)
+	}
+
+	job, err := s.scanJobRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if !job.IsRequestedBy(requesterId) {
+		return nil, e.Wrap(op, e.ErrScanJobNotFound)
+	}
+
+	var result *CheckRes
+	if job.Status == domain.JobDone && len(job.Result) > 0 {
+		result = new(CheckRes)
+		if err := json.Unmarshal(job.Result, result); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	return ToScanJobDTO(job, result), nil
+}
+
+// ProcessNextScanJob забирает из очереди одно задание и выполняет проверку.
+// Возвращает false, если очередь пуста
+func (s *Service) ProcessNextScanJob(ctx context.Context) (bool, error) {
+	const op = "usecase.ProcessNextScanJob"
+
+	job, err := s.scanJobRepo.ClaimNext(ctx)
+	if errors.Is(err, e.ErrScanJobNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, e.Wrap(op, err)
+	}
+
+	// прошлая попытка уже сохранила проверку, но не успела завершить задание: повторная проверка
+	// приняла бы фото выдачи за сдачу, поэтому задание завершается без результата, со ссылкой на транзакцию
+	if job.IsChecked() {
+		job.Complete(nil, time.Now().UTC())
+	} else if res, err := s.runScanJob(ctx, job); err != nil {
+		job.Fail(rootCause(err).Error(), time.Now().UTC())
+	} else {
+		result, err := json.Marshal(res)
+		if err != nil {
+			return true, e.Wrap(op, err)
+		}
+		job.Complete(result, time.Now().UTC())
+	}
+
+	if err := s.scanJobRepo.Finish(ctx, job); err != nil {
+		return true, e.Wrap(op, err)
+	}
+
+	return true, nil
+}
+
+// runScanJob выполняет проверку задания. Паника при проверке завершает с ошибкой только это задание
+func (s *Service) runScanJob(ctx context.Context, job *domain.ScanJob) (res *CheckRes, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scan job %s panicked: %v\n%s", job.Id, r, debug.Stack())
+			res, err = nil, fmt.Errorf("scan job panicked: %v", r)
+		}
+	}()
+
+	user, err := s.userRepo.GetById(ctx, job.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	checkReq := NewCheckReq(user.EmployeeId, job.ImageData, job.ToolSetId, job.BadgeId, job.KioskId)
+	checkReq.ScanJob = job
+	res, err = s.Check(ctx, checkReq)
+	if err != nil {
+		return nil, err
+	}
+
+	stripEmbeddings(res)
+	return res, nil
+}
+
+// RequeueStaleScanJobs возвращает в очередь задания, обработка которых прервалась (например, при перезапуске сервиса)
+func (s *Service) RequeueStaleScanJobs(ctx context.Context, timeout time.Duration) (int64, error) {
+	const op = "usecase.RequeueStaleScanJobs"
+
+	count, err := s.scanJobRepo.RequeueStale(ctx, time.Now().UTC().Add(-timeout), scanJobMaxAttempts)
+	if err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return count, nil
+}
+
 // Checkout обрабатывает выдачу инструментов инженеру
 func (s *Service) Checkout(ctx context.Context, req *TransactionProcess) (res *CheckRes, err error) {
 	const op = "usecase.Checkout"
@@ -107,11 +355,27 @@ func (s *Service) Checkout(ctx context.Context, req *TransactionProcess) (res *C
 		toolSetId = DefaultSetId
 	}
 
-	referenceSet, err := s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	// выдача всегда идёт по последней версии набора, даже если инженер передал идентификатор старой версии
+	requestedSet, err := s.toolSetRepo.GetById(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	referenceSet, err := s.toolSetRepo.GetLatestByFamilyIdWithTools(ctx, requestedSet.FamilyId)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
+	if err := referenceSet.CanBeIssued(); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// экземпляры проверяются до сканирования, чтобы не сканировать набор, в котором не хватает исправных единиц.
+	// Окончательный подбор повторяется под блокировкой при сохранении выдачи
+	if _, err := selectInstancesForIssue(ctx, s.instanceRepo, referenceSet, false); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
 	var uploadImageRes *UploadImageRes
 	uplImageReq := NewUploadImageReq(req.Data, SourceImages)
 	err = s.logger.Track("usecase.Checkout.imageStorage.UploadImage", func() error {
@@ -123,7 +387,7 @@ func (s *Service) Checkout(ctx context.Context, req *TransactionProcess) (res *C
 	}
 
 	var scanResult *ScanResult
-	scanReq := NewScanReq(uploadImageRes.Key, uploadImageRes.ImageUrl, s.ConfidenceCompare)
+	scanReq := NewScanReq(uploadImageRes.Key, uploadImageRes.ImageUrl, req.Data, referenceSet.MinConfidence(s.defaultThresholds()))
 	err = s.logger.Track("usecase.Checkout.mlGateway.ScanTools", func() error {
 		scanResult, err = s.mlGateway.ScanTools(ctx, scanReq)
 		return err
@@ -132,53 +396,128 @@ func (s *Service) Checkout(ctx context.Context, req *TransactionProcess) (res *C
 		return nil, e.Wrap(op, err)
 	}
 
-	filterReq := NewFilterReq(s.ConfidenceCompare, s.CosineSimCompare, scanResult.Tools, referenceSet.Tools)
+	if err := s.resolveToolTypes(ctx, scanResult); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	filterReq := NewFilterReq(s.defaultThresholds(), s.DuplicateIoU, scanResult.Tools, referenceSet)
 	filterRes, err := filterRecognizedTools(filterReq)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	hasLowConfidence := false
-	for _, tool := range filterRes.ManualCheckTools {
-		if tool.Confidence <= 50 {
-			hasLowConfidence = true
-			break
+	status := checkoutStatus(filterRes, referenceSet)
+
+	// транзакция, скан с деталями и выданные экземпляры сохраняются атомарно
+	var transaction *domain.Transaction
+	var scan *domain.CvScan
+	var instances []*domain.ToolInstance
+	err = s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Transactions.LockUser(ctx, req.UserId); err != nil {
+			return err
 		}
-	}
 
-	var status domain.Status
-	if len(filterRes.MissingTools) > 0 || len(filterRes.UnknownTools) > 0 || ((len(filterRes.AccessTools) + len(filterRes.ManualCheckTools)) != len(referenceSet.Tools)) || hasLowConfidence {
-		status = domain.FAILED
-	} else {
-		status = domain.OPEN
-	}
+		// пока шло сканирование, параллельный запрос мог уже выдать инструменты этому пользователю
+		if _, err := repos.Transactions.GetByUserIdWhereStatusIsOpenOrQA(ctx, req.UserId); err == nil {
+			return e.ErrTransactionConcurrentCheck
+		} else if !errors.Is(err, e.ErrTransactionNotFound) {
+			return err
+		}
 
-	var transaction *domain.Transaction
-	existing, err := s.transactionRepo.GetLastFailedByUserId(ctx, req.UserId)
-	if err != nil && !errors.Is(err, e.ErrTransactionNotFound) {
-		return nil, e.Wrap(op, err)
-	}
+		existing, err := repos.Transactions.GetLastFailedByUserId(ctx, req.UserId)
+		if err != nil && !errors.Is(err, e.ErrTransactionNotFound) {
+			return err
+		}
+
+		if existing != nil {
+			existing.Status = status
+			existing.ToolSetId = referenceSet.Id
+			existing.BadgeId = req.BadgeId
+			existing.KioskId = req.KioskId
+			transaction, err = repos.Transactions.Update(ctx, existing)
+			if err != nil {
+				return err
+			}
+		} else {
+			newTransaction := domain.NewTransaction(req.UserId, referenceSet.Id, status)
+			newTransaction.BadgeId = req.BadgeId
+			newTransaction.KioskId = req.KioskId
+			transaction, err = repos.Transactions.Create(ctx, newTransaction)
+			if err != nil {
+				return err
+			}
+		}
 
-	if existing != nil {
-		existing.Status = status
-		transaction, err = s.transactionRepo.Update(ctx, existing)
+		createScanReq := NewCreateScanReq(transaction.Id, domain.Checkout, uploadImageRes.ImageUrl, scanResult.DebugImageUrl, scanResult.ModelVersion, scanResult.Tools)
+		scan, err = createScan(ctx, repos, createScanReq)
 		if err != nil {
-			return nil, e.Wrap(op, err)
+			return err
 		}
-	} else {
-		newTransaction := domain.NewTransaction(req.UserId, referenceSet.Id, status)
-		transaction, err = s.transactionRepo.Create(ctx, newTransaction)
+
+		if err := recordScanJob(ctx, repos, req.ScanJob, transaction.Id, scan.Id); err != nil {
+			return err
+		}
+
+		// экземпляры считаются выданными только после успешной выдачи. Строки экземпляров набора блокируются,
+		// поэтому параллельная выдача того же набора другому инженеру дождётся фиксации и увидит их выданными
+		if transaction.Status != domain.OPEN {
+			return nil
+		}
+
+		instances, err = selectInstancesForIssue(ctx, repos.ToolInstances, referenceSet, true)
 		if err != nil {
-			return nil, e.Wrap(op, err)
+			return err
 		}
-	}
 
-	createScanReq := NewCreateScanReq(transaction.Id, domain.Checkout, uploadImageRes.ImageUrl, scanResult.DebugImageUrl, scanResult.Tools)
-	if err := s.CreateScan(ctx, createScanReq); err != nil {
+		if len(instances) > 0 {
+			return repos.ToolInstances.AttachToTransaction(ctx, transaction.Id, toolInstanceIds(instances))
+		}
+
+		return nil
+	})
+	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	return NewCheckinRes(uploadImageRes.ImageUrl, scanResult.DebugImageUrl, filterRes.AccessTools, filterRes.ManualCheckTools, filterRes.UnknownTools, filterRes.MissingTools, Checkout, string(transaction.Status)), nil
+	s.enqueueShadowScan(&shadowScanTask{
+		Scan:          scan,
+		ScanReq:       scanReq,
+		ReferenceSet:  referenceSet,
+		PrimaryStatus: transaction.Status,
+	})
+
+	res = NewCheckinRes(uploadImageRes.ImageUrl, scanResult.DebugImageUrl, filterRes, Checkout, string(transaction.Status))
+	if transaction.Status == domain.OPEN && len(instances) > 0 {
+		res.Instances = toArrToolInstanceDTO(instances)
+	}
+
+	return res, nil
+}
+
+// selectInstancesForIssue подбирает исправные и не выданные экземпляры набора.
+// С forUpdate строки экземпляров блокируются до конца транзакции repo
+func selectInstancesForIssue(ctx context.Context, repo repository.ToolInstanceRepository, set *domain.ToolSet, forUpdate bool) ([]*domain.ToolInstance, error) {
+	var registered []*domain.ToolInstance
+	var err error
+	if forUpdate {
+		registered, err = repo.GetAllByHomeToolSetForUpdate(ctx, set.FamilyId)
+	} else {
+		registered, err = repo.GetAllByHomeToolSet(ctx, set.FamilyId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(registered) == 0 {
+		return nil, nil
+	}
+
+	issued, err := repo.GetIssuedIds(ctx, set.FamilyId, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.SelectInstancesForIssue(set, registered, issued, time.Now().UTC())
 }
 
 // Checkin обрабатывает возврат инструментов инженером
@@ -212,7 +551,7 @@ func (s *Service) Checkin(ctx context.Context, req *TransactionProcess) (res *Ch
 	}
 
 	var scanResult *ScanResult
-	scanReq := NewScanReq(uploadImage.Key, uploadImage.ImageUrl, s.ConfidenceCompare)
+	scanReq := NewScanReq(uploadImage.Key, uploadImage.ImageUrl, req.Data, referenceSet.MinConfidence(s.defaultThresholds()))
 	err = s.logger.Track("usecase.Checkin.mlGateway.ScanTools", func() error {
 		scanResult, err = s.mlGateway.ScanTools(ctx, scanReq)
 		return err
@@ -221,64 +560,169 @@ func (s *Service) Checkin(ctx context.Context, req *TransactionProcess) (res *Ch
 		return nil, e.Wrap(op, err)
 	}
 
-	createScanReq := NewCreateScanReq(transaction.Id, domain.Checkin, uploadImage.ImageUrl, scanResult.DebugImageUrl, scanResult.Tools)
-	if err := s.CreateScan(ctx, createScanReq); err != nil {
+	if err := s.resolveToolTypes(ctx, scanResult); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	filterReq := NewFilterReq(s.ConfidenceCompare, s.CosineSimCompare, scanResult.Tools, referenceSet.Tools)
+	// фильтрация выполняется до сохранения скана, чтобы в деталях скана был записан выбранный эталон
+	filterReq := NewFilterReq(s.defaultThresholds(), s.DuplicateIoU, scanResult.Tools, referenceSet)
 	filterRes, err := filterRecognizedTools(filterReq)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
+	if err := s.flagSubstitutedTools(ctx, transaction.Id, filterRes); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	checkedCount, checkedStatus := transaction.CountOfChecks, transaction.Status
 	transaction.CountOfChecks++
-	transaction.EvaluateStatus(len(filterRes.ManualCheckTools), len(filterRes.UnknownTools), len(filterRes.MissingTools))
+	// пропуск и киоск относятся к последней проверке: сдача с табельным номером их сбрасывает
+	transaction.BadgeId = req.BadgeId
+	transaction.KioskId = req.KioskId
+	transaction.EvaluateStatus(filterRes.ManualCheckCount(), len(filterRes.UnknownTools), len(filterRes.MissingTools))
+	transaction.EscalateSubstitution(len(filterRes.PossiblySubstitutedTools))
 	transaction.UpdatedAt = time.Now()
 
-	if _, err := s.transactionRepo.Update(ctx, transaction); err != nil {
+	// скан с деталями и новый статус транзакции сохраняются атомарно
+	var scan *domain.CvScan
+	err = s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Transactions.LockUser(ctx, req.UserId); err != nil {
+			return err
+		}
+
+		// если транзакцию успела изменить параллельная сдача, результат этого скана устарел
+		current, err := repos.Transactions.GetByUserIdWhereStatusIsOpenOrQA(ctx, req.UserId)
+		if errors.Is(err, e.ErrTransactionNotFound) {
+			return e.ErrTransactionConcurrentCheck
+		} else if err != nil {
+			return err
+		}
+
+		if current.Id != transaction.Id || current.CountOfChecks != checkedCount || current.Status != checkedStatus {
+			return e.ErrTransactionConcurrentCheck
+		}
+
+		createScanReq := NewCreateScanReq(transaction.Id, domain.Checkin, uploadImage.ImageUrl, scanResult.DebugImageUrl, scanResult.ModelVersion, scanResult.Tools)
+		scan, err = createScan(ctx, repos, createScanReq)
+		if err != nil {
+			return err
+		}
+
+		if err := recordScanJob(ctx, repos, req.ScanJob, transaction.Id, scan.Id); err != nil {
+			return err
+		}
+
+		if _, err := repos.Transactions.Update(ctx, transaction); err != nil {
+			return err
+		}
+
+		// транзакция находится в очереди QA, пока у неё статус QA VERIFICATION
+		if transaction.Status == domain.QA {
+			return repos.QAQueue.Enqueue(ctx, domain.NewQAQueueItem(transaction.Id, transaction.UpdatedAt))
+		}
+
+		return repos.QAQueue.Remove(ctx, transaction.Id)
+	})
+	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	return NewCheckinRes(uploadImage.ImageUrl, scanResult.DebugImageUrl, filterRes.AccessTools, filterRes.ManualCheckTools, filterRes.UnknownTools, filterRes.MissingTools, Checkin, string(transaction.Status)), nil
+	s.enqueueShadowScan(&shadowScanTask{
+		Scan:          scan,
+		ScanReq:       scanReq,
+		ReferenceSet:  referenceSet,
+		PrimaryStatus: transaction.Status,
+		CountOfChecks: checkedCount,
+	})
+
+	return NewCheckinRes(uploadImage.ImageUrl, scanResult.DebugImageUrl, filterRes, Checkin, string(transaction.Status)), nil
+}
+
+// flagSubstitutedTools сравнивает засчитанные при сдаче инструменты с детекциями последнего скана выдачи
+// по транзакции и записывает в filterRes возможно подменённые. Транзакции без скана выдачи не проверяются
+func (s *Service) flagSubstitutedTools(ctx context.Context, transactionId int64, filterRes *FilterRes) error {
+	const op = "usecase.flagSubstitutedTools"
+
+	checkoutScan, err := s.cvScanRepo.GetLastByTransactionIdAndTypeWithDetectedTools(ctx, transactionId, domain.Checkout)
+	if errors.Is(err, e.ErrCvScanNotFound) {
+		return nil
+	} else if err != nil {
+		return e.Wrap(op, err)
+	}
+
+	returned := append(append(append([]*domain.RecognizedTool{}, filterRes.AccessTools...), filterRes.ManualCheckTools...), filterRes.MisplacedTools...)
+	filterRes.PossiblySubstitutedTools = matchCheckoutTools(returned, checkoutScan.DetectedTools, s.SubstitutionSim)
+
+	return nil
+}
+
+// defaultThresholds глобальные пороги автоматической проверки из CONFIDENCE и COSINE_SIM
+func (s *Service) defaultThresholds() domain.Thresholds {
+	return domain.Thresholds{
+		Confidence: s.ConfidenceCompare,
+		CosineSim:  s.CosineSimCompare,
+	}
 }
 
-// CreateScan создает записи в таблицы cv_scans, cv_scan_details
-func (s *Service) CreateScan(ctx context.Context, req *CreateScanReq) error {
-	const op = "usecase.CreateScan"
+// resolveToolTypes сопоставляет классы модели с типами инструментов по ml_class_mappings для версии модели скана.
+// Детекции классов без сопоставления остаются без типа и попадают в unknown_tools
+func (s *Service) resolveToolTypes(ctx context.Context, scanResult *ScanResult) error {
+	const op = "usecase.resolveToolTypes"
 
-	tools, err := s.toolTypeRepo.GetAll(ctx)
+	mappings, err := s.classMappingRepo.GetForModelVersion(ctx, scanResult.ModelVersion)
 	if err != nil {
 		return e.Wrap(op, err)
 	}
 
-	toolMap := make(map[int64]*domain.ToolType)
-	for _, t := range tools {
-		toolMap[t.Id] = t
+	classToType := domain.ClassToToolType(mappings, scanResult.ModelVersion)
+	for _, tool := range scanResult.Tools {
+		if toolTypeId, ok := classToType[tool.ClassIndex]; ok {
+			tool.ToolTypeId = &toolTypeId
+		}
+	}
+
+	return nil
+}
+
+// recordScanJob связывает задание асинхронной проверки с сохранённым сканом в той же транзакции БД,
+// поэтому попытка, повторённая после сбоя, видит, что проверка уже выполнена. Синхронная проверка задания не имеет
+func recordScanJob(ctx context.Context, repos *repository.Repositories, job *domain.ScanJob, transactionId, scanId int64) error {
+	if job == nil {
+		return nil
 	}
 
-	newScan := domain.NewCvScan(req.TransactionId, req.ScanType, req.ImageUrl, req.DebugImageUrl)
-	scan, err := s.cvScanRepo.Create(ctx, newScan)
+	job.RecordScan(transactionId, scanId)
+	return repos.ScanJobs.RecordScan(ctx, job)
+}
+
+// createScan создает записи в таблицы cv_scans, cv_scan_details в рамках транзакции UnitOfWork.
+// Сохраняются все детекции, в том числе классы без сопоставления с типом инструмента
+func createScan(ctx context.Context, repos *repository.Repositories, req *CreateScanReq) (*domain.CvScan, error) {
+	const op = "usecase.createScan"
+
+	newScan := domain.NewCvScan(req.TransactionId, req.ScanType, req.ImageUrl, req.DebugImageUrl, req.ModelVersion)
+	scan, err := repos.CvScans.Create(ctx, newScan)
 	if err != nil {
-		return e.Wrap(op, err)
+		return nil, e.Wrap(op, err)
 	}
 
+	scanDetails := make([]*domain.CvScanDetail, 0, len(req.Tools))
 	for _, recognized := range req.Tools {
-		if _, exists := toolMap[recognized.ToolTypeId]; exists {
-			if len(recognized.Embedding) == 0 {
-				recognized.Embedding = make([]float32, 1280)
-			}
-			scanDetail := domain.NewCvScanDetail(scan.Id, recognized.ToolTypeId, recognized.Confidence, recognized.Embedding, recognized.Bbox)
-			_, err := s.cvScanDetailRepo.Create(ctx, scanDetail)
-			if err != nil {
-				return e.Wrap(op, err)
-			}
-		} else {
-			log.Printf("unknown tool type: %v", recognized.ToolTypeId)
+		if len(recognized.Embedding) == 0 {
+			recognized.Embedding = make([]float32, domain.EmbeddingSize)
 		}
+		scanDetail := domain.NewCvScanDetail(scan.Id, recognized.ClassIndex, recognized.ToolTypeId, recognized.Confidence, recognized.Embedding, recognized.Bbox)
+		scanDetail.ReferenceId = recognized.ReferenceId
+		scanDetail.CosineSimilarity = recognized.CosineSimilarity
+		scanDetails = append(scanDetails, scanDetail)
 	}
 
-	return nil
+	if err := repos.CvScanDetails.CreateBatch(ctx, scanDetails); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return scan, nil
 }
 
 // List возвращает список транзакций, возможна фильтрация по статусу
@@ -312,54 +756,188 @@ func (s *Service) List(ctx context.Context, statusStr string) (*ListTransactions
 	// return nil, e.ErrRequestNotSupported
 }
 
-// Login возвращает роль пользователя для дальнейшей работы. MVP вариант, небезопасно
+// Login проверяет табельный номер и PIN/пароль сотрудника и выдаёт пару подписанных токенов.
+// После LoginMaxAttempts неверных PIN-кодов подряд вход сотрудника блокируется на LoginLockout.
+// Если PIN задан администратором, токены выдаются только вместе с заменой PIN на req.NewPin
 func (s *Service) Login(ctx context.Context, req *LoginReq) (*LoginRes, error) {
 	const op = "usecase.Login"
 
 	user, err := s.userRepo.GetByEmployeeId(ctx, req.EmployeeId)
 	if err != nil {
+		if errors.Is(err, e.ErrUserNotFound) {
+			return nil, e.Wrap(op, e.ErrInvalidCredentials)
+		}
 		return nil, e.Wrap(op, err)
 	}
 
-	return NewLoginRes(user.Role.Name), nil
-}
-
-// GetRoles возвращает список ролей
-func (s *Service) GetRoles(ctx context.Context) (*GetRolesRes, error) {
-	const op = "usecase.GetRoles"
+	now := time.Now()
+	if user.IsLoginLocked(now) {
+		return nil, e.Wrap(op, e.ErrLoginLocked)
+	}
 
-	roles, err := s.roleRepo.GetAll(ctx)
-	if err != nil {
+	if err := s.passwordHasher.Compare(user.PasswordHash, req.Password); err != nil {
+		if errors.Is(err, e.ErrInvalidCredentials) {
+			if err := s.userRepo.RegisterFailedLogin(ctx, user.Id, s.LoginMaxAttempts, s.LoginLockout, now); err != nil {
+				return nil, e.Wrap(op, err)
+			}
+		}
 		return nil, e.Wrap(op, err)
 	}
 
-	result := make([]string, len(roles))
-	for i, role := range roles {
-		result[i] = role.Name
-	}
+	if user.MustChangePin {
+		if req.NewPin == "" {
+			return nil, e.Wrap(op, e.ErrPinChangeRequired)
+		}
 
-	return NewGetRolesRes(result), nil
-}
+		if req.NewPin == req.Password {
+			return nil, e.Wrap(op, e.ErrPinNotChanged)
+		}
 
-// Register регистрирует пользователя
-func (s *Service) Register(ctx context.Context, req *RegisterReq) (*RegisterRes, error) {
-	const op = "usecase.Register"
+		passwordHash, err := s.passwordHasher.Hash(req.NewPin)
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
 
-	role, err := s.roleRepo.GetByName(ctx, req.Role)
-	if err != nil {
-		return nil, e.Wrap(op, err)
+		user.SetPin(passwordHash, false)
+		if err := s.userRepo.UpdatePin(ctx, user); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	} else if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.Id); err != nil {
+			return nil, e.Wrap(op, err)
+		}
 	}
 
-	newUser := domain.NewUser(req.FullName, req.EmployeeId, role.Id)
-	user, err := s.userRepo.Create(ctx, newUser)
+	tokens, err := s.tokenManager.NewTokenPair(user)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	return NewRegisterRes(user.Id), nil
+	return NewLoginRes(user.Role.Name, tokens), nil
 }
 
-// Verification отвечает за QA-проверку и завершение проблемной транзакции
+// Refresh выдаёт новую пару токенов по действующему refresh токену
+func (s *Service) Refresh(ctx context.Context, req *RefreshReq) (*LoginRes, error) {
+	const op = "usecase.Refresh"
+
+	claims, err := s.tokenManager.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	user, err := s.userRepo.GetById(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, e.ErrUserNotFound) {
+			return nil, e.Wrap(op, e.ErrInvalidToken)
+		}
+		return nil, e.Wrap(op, err)
+	}
+
+	// токен выпущен до смены PIN
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, e.Wrap(op, e.ErrInvalidToken)
+	}
+
+	tokens, err := s.tokenManager.NewTokenPair(user)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return NewLoginRes(user.Role.Name, tokens), nil
+}
+
+// Authenticate определяет пользователя по access токену. Роль всегда берётся из базы,
+// поэтому смена роли применяется без перевыпуска токенов. Токены, выпущенные до смены PIN, отклоняются
+func (s *Service) Authenticate(ctx context.Context, accessToken string) (*domain.User, error) {
+	const op = "usecase.Authenticate"
+
+	claims, err := s.tokenManager.ParseAccessToken(accessToken)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	user, err := s.userRepo.GetById(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, e.ErrUserNotFound) {
+			return nil, e.Wrap(op, e.ErrInvalidToken)
+		}
+		return nil, e.Wrap(op, err)
+	}
+
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, e.Wrap(op, e.ErrInvalidToken)
+	}
+
+	return user, nil
+}
+
+// SetUserPin задаёт сотруднику новый PIN (первичная выдача или сброс забытого). Выданные ранее токены отзываются,
+// блокировка входа снимается, при следующем входе сотрудник должен заменить PIN на свой
+func (s *Service) SetUserPin(ctx context.Context, req *SetUserPinReq) error {
+	const op = "usecase.SetUserPin"
+
+	user, err := s.userRepo.GetById(ctx, req.UserId)
+	if err != nil {
+		return e.Wrap(op, err)
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(req.Pin)
+	if err != nil {
+		return e.Wrap(op, err)
+	}
+
+	user.SetPin(passwordHash, true)
+	if err := s.userRepo.UpdatePin(ctx, user); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// GetRoles возвращает список ролей
+func (s *Service) GetRoles(ctx context.Context) (*GetRolesRes, error) {
+	const op = "usecase.GetRoles"
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	result := make([]string, len(roles))
+	for i, role := range roles {
+		result[i] = role.Name
+	}
+
+	return NewGetRolesRes(result), nil
+}
+
+// Register регистрирует пользователя
+func (s *Service) Register(ctx context.Context, req *RegisterReq) (*RegisterRes, error) {
+	const op = "usecase.Register"
+
+	role, err := s.roleRepo.GetByName(ctx, req.Role)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(req.Password)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// PIN, заданный при регистрации, знает и QA, поэтому сотрудник заменяет его при первом входе
+	newUser := domain.NewUser(req.FullName, req.EmployeeId, role.Id, passwordHash)
+	newUser.MustChangePin = true
+	user, err := s.userRepo.Create(ctx, newUser)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return NewRegisterRes(user.Id), nil
+}
+
+// Verification отвечает за QA-проверку и завершение проблемной транзакции.
+// Проверяющий определяется по сессии, а не по телу запроса
 func (s *Service) Verification(ctx context.Context, req *Verification) (*VerificationRes, error) {
 	const op = "usecase.postVerification"
 
@@ -367,24 +945,61 @@ func (s *Service) Verification(ctx context.Context, req *Verification) (*Verific
 		return nil, e.Wrap(op, err)
 	}
 
-	user, err := s.userRepo.GetByEmployeeId(ctx, req.QAEmployeeId)
+	user, err := s.userRepo.GetById(ctx, req.QAUserId)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	new_resolution := domain.NewTransactionResolution(req.TransactionID, user.Id, req.Reason, req.Notes)
-	resolution, err := s.trResolution.Create(ctx, new_resolution, req.ToolsIds)
+	transaction, err := s.transactionRepo.GetById(ctx, req.TransactionID)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	transaction, err := s.transactionRepo.GetById(ctx, resolution.TransactionId)
-	if err != nil {
+	if err := transaction.CanBeVerifiedBy(user); err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	transaction.Status = domain.CLOSED
-	updTransaction, err := s.transactionRepo.Update(ctx, transaction)
+	// решение, закрытие транзакции и удаление из очереди QA выполняются атомарно под блокировкой пользователя,
+	// поэтому параллельное второе решение по той же транзакции увидит её уже закрытой
+	var resolution *domain.TransactionResolution
+	var updTransaction *domain.Transaction
+	err = s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Transactions.LockUser(ctx, transaction.UserId); err != nil {
+			return err
+		}
+
+		current, err := repos.Transactions.GetById(ctx, transaction.Id)
+		if err != nil {
+			return err
+		}
+
+		if current.Status == domain.CLOSED {
+			return e.ErrTransactionAlreadyResolved
+		}
+
+		item, err := repos.QAQueue.GetByTransactionIdForUpdate(ctx, transaction.Id)
+		if err == nil {
+			if err := item.CanBeResolvedBy(user, time.Now()); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, e.ErrQAQueueItemNotFound) {
+			return err
+		}
+
+		newResolution := domain.NewTransactionResolution(current.Id, user.Id, req.Reason, req.Notes)
+		resolution, err = repos.Resolutions.Create(ctx, newResolution, req.ToolsIds)
+		if err != nil {
+			return err
+		}
+
+		current.Status = domain.CLOSED
+		updTransaction, err = repos.Transactions.Update(ctx, current)
+		if err != nil {
+			return err
+		}
+
+		return repos.QAQueue.Remove(ctx, current.Id)
+	})
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
@@ -408,22 +1023,176 @@ func (s *Service) GetQATransaction(ctx context.Context, transactionId int64) (*G
 
 	detectedTools := make([]*domain.RecognizedTool, len(scan.DetectedTools))
 	for i, tool := range scan.DetectedTools {
-		detectedTools[i] = domain.NewRecognizedTool(tool.DetectedToolTypeId, tool.Confidence, tool.Embedding, tool.Bbox)
+		detectedTools[i] = domain.NewRecognizedTool(tool.ClassIndex, tool.Confidence, tool.Embedding, tool.Bbox)
+		detectedTools[i].ToolTypeId = tool.DetectedToolTypeId
 	}
 
-	filterReq := NewFilterReq(s.ConfidenceCompare, s.CosineSimCompare, detectedTools, toolSet.Tools)
+	filterReq := NewFilterReq(s.defaultThresholds(), s.DuplicateIoU, detectedTools, toolSet)
 	filterRes, err := filterRecognizedTools(filterReq)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	problematicTools := NewProblematicTools(filterRes.ManualCheckTools, filterRes.UnknownTools, filterRes.MissingTools)
+	if scan.ScanType == domain.Checkin {
+		if err := s.flagSubstitutedTools(ctx, transactionId, filterRes); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	problematicTools := NewProblematicTools(filterRes)
 	userDto := NewUserDto(scan.TransactionObj.User.FullName, scan.TransactionObj.User.EmployeeId)
 	res := NewGetQAVerificationRes(scan.TransactionId, toolSet.Id, scan.TransactionObj.CreatedAt, userDto, filterRes.AccessTools, problematicTools, scan.ImageUrl, string(scan.TransactionObj.Status))
 
+	instances, err := s.instanceRepo.GetAllByTransactionId(ctx, transactionId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	res.Instances = toArrToolInstanceDTO(instances)
+
+	return res, nil
+}
+
+// GetQAQueue возвращает очередь QA-проверки от самых давних транзакций к новым.
+// Если assigneeId задан — только транзакции, назначенные этому аудитору
+func (s *Service) GetQAQueue(ctx context.Context, assigneeId *int64) ([]*QAQueueItemDTO, error) {
+	const op = "usecase.GetQAQueue"
+
+	items, err := s.qaQueueRepo.List(ctx, repository.QAQueueFilter{AssigneeId: assigneeId})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	now := time.Now()
+	res := make([]*QAQueueItemDTO, len(items))
+	for i, item := range items {
+		res[i] = ToQAQueueItemDTO(item, now, s.QASLA)
+	}
+
+	return res, nil
+}
+
+// ClaimQAQueueItem берёт транзакцию из очереди в работу на QAClaimTTL; повторный вызов тем же аудитором продлевает блокировку
+func (s *Service) ClaimQAQueueItem(ctx context.Context, transactionId, qaUserId int64) (*QAQueueItemDTO, error) {
+	const op = "usecase.ClaimQAQueueItem"
+
+	user, err := s.userRepo.GetById(ctx, qaUserId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res, err := s.updateQAQueueItem(ctx, transactionId, func(item *domain.QAQueueItem, now time.Time) error {
+		if err := item.Transaction.CanBeVerifiedBy(user); err != nil {
+			return err
+		}
+
+		return item.Claim(user, now, s.QAClaimTTL)
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// ReleaseQAQueueItem возвращает взятую аудитором транзакцию в очередь до истечения блокировки
+func (s *Service) ReleaseQAQueueItem(ctx context.Context, transactionId, qaUserId int64) (*QAQueueItemDTO, error) {
+	const op = "usecase.ReleaseQAQueueItem"
+
+	user, err := s.userRepo.GetById(ctx, qaUserId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res, err := s.updateQAQueueItem(ctx, transactionId, func(item *domain.QAQueueItem, now time.Time) error {
+		return item.Release(user, now)
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// AssignQAQueueItem назначает транзакцию аудитору с табельным номером employeeId
+func (s *Service) AssignQAQueueItem(ctx context.Context, transactionId int64, employeeId string) (*QAQueueItemDTO, error) {
+	const op = "usecase.AssignQAQueueItem"
+
+	assignee, err := s.userRepo.GetByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res, err := s.updateQAQueueItem(ctx, transactionId, func(item *domain.QAQueueItem, now time.Time) error {
+		if err := item.Transaction.CanBeVerifiedBy(assignee); err != nil {
+			return err
+		}
+
+		item.Assign(assignee, now)
+		return nil
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// UnassignQAQueueItem снимает назначение, возвращая транзакцию в общую очередь
+func (s *Service) UnassignQAQueueItem(ctx context.Context, transactionId int64) (*QAQueueItemDTO, error) {
+	const op = "usecase.UnassignQAQueueItem"
+
+	res, err := s.updateQAQueueItem(ctx, transactionId, func(item *domain.QAQueueItem, now time.Time) error {
+		item.Unassign()
+		return nil
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// updateQAQueueItem применяет fn к заблокированному элементу очереди и сохраняет результат
+func (s *Service) updateQAQueueItem(ctx context.Context, transactionId int64, fn func(item *domain.QAQueueItem, now time.Time) error) (*QAQueueItemDTO, error) {
+	var res *QAQueueItemDTO
+	err := s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		item, err := repos.QAQueue.GetByTransactionIdForUpdate(ctx, transactionId)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := fn(item, now); err != nil {
+			return err
+		}
+
+		if err := repos.QAQueue.Update(ctx, item); err != nil {
+			return err
+		}
+
+		res = ToQAQueueItemDTO(item, now, s.QASLA)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return res, nil
 }
 
+// EscalateOverdueQA эскалирует транзакции, ожидающие в очереди QA дольше QASLA. Каждая транзакция эскалируется один раз
+func (s *Service) EscalateOverdueQA(ctx context.Context) (int64, error) {
+	const op = "usecase.EscalateOverdueQA"
+
+	now := time.Now()
+	count, err := s.qaQueueRepo.EscalateOverdue(ctx, now.Add(-s.QASLA), now)
+	if err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return count, nil
+}
+
 // UserTransactions возвращает список транзакций конкретного пользователя
 func (s *Service) UserTransactions(ctx context.Context, req *UserTransactionsReq) (*GetUsersListTransactionsRes, error) {
 	const op = "usecase.UserTransactions"
@@ -491,21 +1260,36 @@ func (s *Service) GetQAChecks(ctx context.Context, qaId string) (*QaTransactions
 	return res, nil
 }
 
-// GetMlVsHuman возвращает два числа "Model vs Human errors"
-func (s *Service) GetMlVsHuman(ctx context.Context) (*ModelOrHumanStatsRes, error) {
+// GetMlVsHuman возвращает два числа "Model vs Human errors" и разбивку ошибок модели по версиям.
+// С фильтром по версии учитываются только транзакции, последний скан сдачи которых выполнен этой версией
+func (s *Service) GetMlVsHuman(ctx context.Context, filter repository.MlErrorFilter) (*ModelOrHumanStatsRes, error) {
 	const op = "usecase.GetMlVsHuman"
 
-	humansErrors, err := s.trResolution.GetAllHumanError(ctx)
-	if err != nil && !errors.Is(err, e.ErrTransactionResolutionsNotFound) {
+	counts, err := s.trResolution.CountByReasonAndModelVersion(ctx)
+	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
-	modelErrors, err := s.trResolution.GetAllModelError(ctx)
-	if err != nil && !errors.Is(err, e.ErrTransactionResolutionsNotFound) {
-		return nil, e.Wrap(op, err)
+	var mlErrors, humanErrors int
+	byModelVersion := make([]repository.ModelVersionErrorCount, 0)
+	for _, count := range counts {
+		if filter.ModelVersion != nil && (count.ModelVersion == nil || *count.ModelVersion != *filter.ModelVersion) {
+			continue
+		}
+
+		switch count.Reason {
+		case domain.ModelError:
+			mlErrors += int(count.Count)
+			byModelVersion = append(byModelVersion, repository.ModelVersionErrorCount{
+				ModelVersion: count.ModelVersion,
+				MLErrorCount: count.Count,
+			})
+		case domain.HumanError:
+			humanErrors += int(count.Count)
+		}
 	}
 
-	return NewModelOrHumanStatsRes(len(modelErrors), len(humansErrors)), nil
+	return NewModelOrHumanStatsRes(mlErrors, humanErrors, byModelVersion), nil
 }
 
 // GetAllQaEmployers возваращает всех QA проверяющих
@@ -605,8 +1389,8 @@ func (s *Service) GetAvgWorkDuration(ctx context.Context) (*GetAvgWorkDurationRe
 	return NewGetAvgWorkDurationRes(result), nil
 }
 
-// GetMlErrorTransactions выводит список транзакций, в которых модель ошиблась
-func (s *Service) GetMlErrorTransactions(ctx context.Context) ([]MlErrorTransaction, error) {
+// GetMlErrorTransactions выводит список транзакций, в которых модель ошиблась, с версией модели последнего скана сдачи
+func (s *Service) GetMlErrorTransactions(ctx context.Context, filter repository.MlErrorFilter) ([]MlErrorTransaction, error) {
 	const op = "usecase.GetMlErrorTransactions"
 
 	qaTransactions, err := s.trResolution.GetMlErrorTransactions(ctx)
@@ -630,9 +1414,15 @@ func (s *Service) GetMlErrorTransactions(ctx context.Context) ([]MlErrorTransact
 				}
 			}
 		}
-		if lastCheckin != nil {
-			result = append(result, *NewMlErrorTransaction(tx.Id, lastCheckin.ImageUrl, lastCheckin.DebugImageUrl))
+		if lastCheckin == nil {
+			continue
+		}
+
+		if filter.ModelVersion != nil && (lastCheckin.ModelVersion == nil || *lastCheckin.ModelVersion != *filter.ModelVersion) {
+			continue
 		}
+
+		result = append(result, *NewMlErrorTransaction(tx.Id, lastCheckin.ImageUrl, lastCheckin.DebugImageUrl, lastCheckin.ModelVersion))
 	}
 
 	return result, nil
@@ -659,6 +1449,10 @@ func (s *Service) GetAllTransactions(ctx context.Context) ([]*GetAllTransactions
 func (s *Service) AddToolSet(ctx context.Context, req AddToolSetReq) (*AddToolSetRes, error) {
 	const op = "usecase.AddToolSet"
 
+	if err := s.checkToolSetNameIsFree(ctx, req.ToolSetName, 0); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
 	newSet := domain.NewToolSet(req.ToolSetName)
 
 	res, err := s.toolSetRepo.CreateWithTools(ctx, newSet, req.ToolsIds)
@@ -671,14 +1465,1214 @@ func (s *Service) AddToolSet(ctx context.Context, req AddToolSetReq) (*AddToolSe
 
 // GetMlErrorTools возвращает наборы инструментов вместе с инструментами,
 // где для каждого инструмента указано,
-// сколько раз на нём была зарегистрирована ошибка MODEL_ERR
-func (s *Service) GetMlErrorTools(ctx context.Context) ([]*repository.ToolSetWithErrors, error) {
+// сколько раз на нём была зарегистрирована ошибка MODEL_ERR, всего и по версиям модели
+func (s *Service) GetMlErrorTools(ctx context.Context, filter repository.MlErrorFilter) ([]*repository.ToolSetWithErrors, error) {
 	const op = "usecase.GetMlErrorTools"
 
-	res, err := s.trResolution.GetMlErrorTools(ctx)
+	res, err := s.trResolution.GetMlErrorTools(ctx, filter)
 	if err != nil {
 		return nil, e.Wrap(op, err)
 	}
 
 	return res, nil
 }
+
+// CreateBadge регистрирует пропуск за сотрудником
+func (s *Service) CreateBadge(ctx context.Context, req *CreateBadgeReq) (*BadgeDTO, error) {
+	const op = "usecase.CreateBadge"
+
+	user, err := s.userRepo.GetByEmployeeId(ctx, req.EmployeeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	activatedAt := time.Now()
+	if req.ActivatedAt != nil {
+		activatedAt = *req.ActivatedAt
+	}
+
+	badge, err := s.badgeRepo.Create(ctx, domain.NewBadge(req.Uid, user.Id, activatedAt))
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToBadgeDTO(badge, NewUserDto(user.FullName, user.EmployeeId)), nil
+}
+
+// GetUserBadges возвращает все пропуска сотрудника, включая отозванные
+func (s *Service) GetUserBadges(ctx context.Context, employeeId string) ([]*BadgeDTO, error) {
+	const op = "usecase.GetUserBadges"
+
+	user, err := s.userRepo.GetByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	badges, err := s.badgeRepo.GetAllByUserId(ctx, user.Id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrBadgeDTO(badges, NewUserDto(user.FullName, user.EmployeeId)), nil
+}
+
+// RevokeBadge отзывает утерянный пропуск, не затрагивая пользователя и его транзакции
+func (s *Service) RevokeBadge(ctx context.Context, badgeId int64) (*BadgeDTO, error) {
+	const op = "usecase.RevokeBadge"
+
+	badge, err := s.badgeRepo.GetById(ctx, badgeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := badge.Revoke(time.Now()); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	updBadge, err := s.badgeRepo.Update(ctx, badge)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	user, err := s.userRepo.GetById(ctx, updBadge.UserId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToBadgeDTO(updBadge, NewUserDto(user.FullName, user.EmployeeId)), nil
+}
+
+// GetToolTypes возвращает справочник типов инструментов
+func (s *Service) GetToolTypes(ctx context.Context) ([]*ToolTypeDTO, error) {
+	const op = "usecase.GetToolTypes"
+
+	toolTypes, err := s.toolTypeRepo.GetAll(ctx)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrToolTypeDTO(toolTypes), nil
+}
+
+// CreateToolType добавляет новый тип инструмента вместе с эталонным эмбеддингом
+func (s *Service) CreateToolType(ctx context.Context, req *CreateToolTypeReq) (*ToolTypeDTO, error) {
+	const op = "usecase.CreateToolType"
+
+	if err := domain.ValidateReferenceEmbedding(req.ReferenceEmbedding); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType, err := s.toolTypeRepo.Create(ctx, domain.NewToolType(req.PartNumber, req.Name, req.ReferenceEmbedding))
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeDTO(toolType), nil
+}
+
+// RenameToolType изменяет название типа инструмента
+func (s *Service) RenameToolType(ctx context.Context, id int64, name string) (*ToolTypeDTO, error) {
+	const op = "usecase.RenameToolType"
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := toolType.ValidateName(name); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType.Name = name
+	updToolType, err := s.toolTypeRepo.Update(ctx, toolType)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeDTO(updToolType), nil
+}
+
+// SetToolTypeCalibrationInterval задаёт периодичность поверки типа инструмента; nil отключает контроль поверки
+func (s *Service) SetToolTypeCalibrationInterval(ctx context.Context, id int64, days *int) (*ToolTypeDTO, error) {
+	const op = "usecase.SetToolTypeCalibrationInterval"
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := toolType.SetCalibrationInterval(days); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	updToolType, err := s.toolTypeRepo.Update(ctx, toolType)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeDTO(updToolType), nil
+}
+
+// SetToolTypeThresholds задаёт пороги автоматической проверки типа инструмента; nil возвращает глобальный порог
+func (s *Service) SetToolTypeThresholds(ctx context.Context, id int64, confidence, cosineSim *float32) (*ToolTypeDTO, error) {
+	const op = "usecase.SetToolTypeThresholds"
+
+	override, err := domain.NewThresholdsOverride(confidence, cosineSim)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType.SetThresholds(override)
+	updToolType, err := s.toolTypeRepo.Update(ctx, toolType)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeDTO(updToolType), nil
+}
+
+// DeleteToolType удаляет тип инструмента, если он не используется в наборах и сканах
+func (s *Service) DeleteToolType(ctx context.Context, id int64) error {
+	const op = "usecase.DeleteToolType"
+
+	if err := s.toolTypeRepo.Delete(ctx, id); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// UpdateToolTypeEmbedding заменяет эталонный эмбеддинг типа инструмента
+func (s *Service) UpdateToolTypeEmbedding(ctx context.Context, id int64, embedding []float32) (*ToolTypeDTO, error) {
+	const op = "usecase.UpdateToolTypeEmbedding"
+
+	if err := domain.ValidateReferenceEmbedding(embedding); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType, err := s.toolTypeRepo.UpdateReferenceEmbedding(ctx, id, embedding)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeDTO(toolType), nil
+}
+
+// AddToolTypeSamples распознаёт эталонные фотографии инструмента, сохраняет эмбеддинги найденных
+// на них инструментов ожидаемого типа и пересчитывает эталонный эмбеддинг по всем образцам
+func (s *Service) AddToolTypeSamples(ctx context.Context, req *AddToolTypeSamplesReq) (*ToolTypeSamplesRes, error) {
+	const op = "usecase.AddToolTypeSamples"
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, req.ToolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// сначала распознаются все фотографии: при ошибке на любой из них ни один образец не сохраняется,
+	// а загруженные фотографии удаляются из хранилища. Отклонённые фотографии удаляются и при успехе
+	rejected := make([]int, 0)
+	samples := make([]*domain.ToolTypeSample, 0, len(req.Images))
+	sampleKeys := make([]string, 0, len(req.Images))
+	rejectedKeys := make([]string, 0)
+	fail := func(err error) (*ToolTypeSamplesRes, error) {
+		s.deleteImages(ctx, append(sampleKeys, rejectedKeys...))
+		return nil, e.Wrap(op, err)
+	}
+
+	for i, data := range req.Images {
+		uploadImage, err := s.imageStorage.UploadImage(ctx, NewUploadImageReq(data, SampleImages))
+		if err != nil {
+			return fail(err)
+		}
+
+		scanResult, err := s.mlGateway.ScanTools(ctx, NewScanReq(uploadImage.Key, uploadImage.ImageUrl, data, toolType.Thresholds.Apply(s.defaultThresholds()).Confidence))
+		if err != nil {
+			rejectedKeys = append(rejectedKeys, uploadImage.Key)
+			return fail(err)
+		}
+
+		if err := s.resolveToolTypes(ctx, scanResult); err != nil {
+			rejectedKeys = append(rejectedKeys, uploadImage.Key)
+			return fail(err)
+		}
+
+		best := bestDetectionOfType(scanResult.Tools, toolType.Id)
+		if best == nil {
+			rejected = append(rejected, i)
+			rejectedKeys = append(rejectedKeys, uploadImage.Key)
+			continue
+		}
+
+		samples = append(samples, domain.NewToolTypeSample(toolType.Id, uploadImage.ImageUrl, best.Confidence, best.Embedding))
+		sampleKeys = append(sampleKeys, uploadImage.Key)
+	}
+
+	// образцы и пересчитанный по ним эталон сохраняются атомарно
+	var res *ToolTypeSamplesRes
+	err = s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		for _, sample := range samples {
+			if _, err := repos.ToolTypeSamples.Create(ctx, sample); err != nil {
+				return err
+			}
+		}
+
+		res, err = recomputeToolTypeEmbedding(ctx, repos, toolType)
+		return err
+	})
+	if err != nil {
+		return fail(err)
+	}
+	s.deleteImages(ctx, rejectedKeys)
+	res.RejectedImages = rejected
+
+	return res, nil
+}
+
+// deleteImages удаляет из хранилища фотографии, которые не попали в базу. Удаление не должно прерываться
+// вместе с запросом, а его ошибка не меняет результат запроса, поэтому она только логируется
+func (s *Service) deleteImages(ctx context.Context, keys []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		if err := s.imageStorage.DeleteImage(ctx, key); err != nil {
+			s.logger.Error(err, "failed to delete orphaned image", "key", key)
+		}
+	}
+}
+
+// GetToolTypeSamples возвращает эталонные фотографии типа инструмента
+func (s *Service) GetToolTypeSamples(ctx context.Context, toolTypeId int64) ([]*ToolTypeSampleDTO, error) {
+	const op = "usecase.GetToolTypeSamples"
+
+	if _, err := s.toolTypeRepo.GetById(ctx, toolTypeId); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	samples, err := s.sampleRepo.GetAllByToolTypeId(ctx, toolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrToolTypeSampleDTO(samples), nil
+}
+
+// RecomputeToolTypeEmbedding пересчитывает эталонный эмбеддинг по сохранённым образцам без повторного обращения к ML-сервису
+func (s *Service) RecomputeToolTypeEmbedding(ctx context.Context, toolTypeId int64) (*ToolTypeSamplesRes, error) {
+	const op = "usecase.RecomputeToolTypeEmbedding"
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, toolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	var res *ToolTypeSamplesRes
+	err = s.uow.Do(ctx, func(repos *repository.Repositories) error {
+		res, err = recomputeToolTypeEmbedding(ctx, repos, toolType)
+		return err
+	})
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// recomputeToolTypeEmbedding усредняет эмбеддинги сохранённых образцов типа и записывает результат эталоном
+func recomputeToolTypeEmbedding(ctx context.Context, repos *repository.Repositories, toolType *domain.ToolType) (*ToolTypeSamplesRes, error) {
+	samples, err := repos.ToolTypeSamples.GetAllByToolTypeId(ctx, toolType.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	embedding, err := domain.AverageEmbedding(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	updToolType, err := repos.ToolTypes.UpdateReferenceEmbedding(ctx, toolType.Id, embedding)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewToolTypeSamplesRes(ToToolTypeDTO(updToolType), toArrToolTypeSampleDTO(samples), make([]int, 0)), nil
+}
+
+// GetToolTypeReferences возвращает дополнительные эталоны типа инструмента
+func (s *Service) GetToolTypeReferences(ctx context.Context, toolTypeId int64) ([]*ToolTypeReferenceDTO, error) {
+	const op = "usecase.GetToolTypeReferences"
+
+	if _, err := s.toolTypeRepo.GetById(ctx, toolTypeId); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	references, err := s.referenceRepo.GetAllByToolTypeId(ctx, toolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrToolTypeReferenceDTO(references), nil
+}
+
+// AddToolTypeReference добавляет типу инструмента дополнительный эталонный эмбеддинг
+func (s *Service) AddToolTypeReference(ctx context.Context, req *AddToolTypeReferenceReq) (*ToolTypeReferenceDTO, error) {
+	const op = "usecase.AddToolTypeReference"
+
+	newReference, err := domain.NewToolTypeReference(req.ToolTypeId, req.Label, req.Embedding)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	reference, err := s.referenceRepo.Create(ctx, newReference)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolTypeReferenceDTO(reference), nil
+}
+
+// DeleteToolTypeReference удаляет дополнительный эталон; в уже сохранённых сканах ссылка на него обнуляется
+func (s *Service) DeleteToolTypeReference(ctx context.Context, toolTypeId, referenceId int64) error {
+	const op = "usecase.DeleteToolTypeReference"
+
+	if err := s.referenceRepo.Delete(ctx, toolTypeId, referenceId); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ListToolSets возвращает последние версии наборов инструментов
+func (s *Service) ListToolSets(ctx context.Context, includeRetired bool) ([]*ToolSetDTO, error) {
+	const op = "usecase.ListToolSets"
+
+	sets, err := s.toolSetRepo.GetAllLatest(ctx)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res := make([]*ToolSetDTO, 0, len(sets))
+	for _, set := range sets {
+		if set.IsRetired() && !includeRetired {
+			continue
+		}
+		res = append(res, ToToolSetDTO(set))
+	}
+
+	return res, nil
+}
+
+// GetToolSet возвращает конкретную версию набора вместе с инструментами, в том числе выведенную из оборота
+func (s *Service) GetToolSet(ctx context.Context, toolSetId int64) (*ToolSetDTO, error) {
+	const op = "usecase.GetToolSet"
+
+	set, err := s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolSetDTO(set), nil
+}
+
+// GetToolSetThresholds возвращает пороги автоматической проверки каждого типа инструмента версии набора
+func (s *Service) GetToolSetThresholds(ctx context.Context, toolSetId int64) ([]*ToolSetThresholdDTO, error) {
+	const op = "usecase.GetToolSetThresholds"
+
+	set, err := s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res := make([]*ToolSetThresholdDTO, len(set.Tools))
+	for i, tool := range set.Tools {
+		res[i] = NewToolSetThresholdDTO(set, tool, s.defaultThresholds())
+	}
+
+	return res, nil
+}
+
+// SetToolSetThresholds переопределяет пороги типа инструмента для набора. Пороги действуют для всех версий набора;
+// если оба порога nil, переопределение удаляется и тип проверяется с порогами типа
+func (s *Service) SetToolSetThresholds(ctx context.Context, toolSetId, toolTypeId int64, confidence, cosineSim *float32) (*ToolSetThresholdDTO, error) {
+	const op = "usecase.SetToolSetThresholds"
+
+	override, err := domain.NewThresholdsOverride(confidence, cosineSim)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	set, err := s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if !set.HasToolType(toolTypeId) {
+		return nil, e.Wrap(op, e.ErrToolTypeNotInToolSet)
+	}
+
+	if override.IsEmpty() {
+		err = s.toolSetRepo.DeleteThreshold(ctx, set.FamilyId, toolTypeId)
+	} else {
+		err = s.toolSetRepo.UpsertThreshold(ctx, domain.NewToolSetThreshold(set.FamilyId, toolTypeId, override))
+	}
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	set, err = s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	for _, tool := range set.Tools {
+		if tool.Id == toolTypeId {
+			return NewToolSetThresholdDTO(set, tool, s.defaultThresholds()), nil
+		}
+	}
+
+	return nil, e.Wrap(op, e.ErrToolTypeNotInToolSet)
+}
+
+// GetBoardLayout возвращает раскладку ложемента набора; пустой список — расположение инструментов не проверяется
+func (s *Service) GetBoardLayout(ctx context.Context, toolSetId int64) ([]*domain.BoardSlot, error) {
+	const op = "usecase.GetBoardLayout"
+
+	set, err := s.toolSetRepo.GetByIdWithTools(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return set.Slots, nil
+}
+
+// SetBoardLayout заменяет раскладку ложемента набора. Раскладка действует для всех версий набора;
+// пустой список гнёзд отключает проверку расположения
+func (s *Service) SetBoardLayout(ctx context.Context, req *SetBoardLayoutReq) ([]*domain.BoardSlot, error) {
+	const op = "usecase.SetBoardLayout"
+
+	set, err := s.toolSetRepo.GetByIdWithTools(ctx, req.ToolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	slots := make([]*domain.BoardSlot, len(req.Slots))
+	for i, slotReq := range req.Slots {
+		if !set.HasToolType(slotReq.ToolTypeId) {
+			return nil, e.Wrap(op, e.ErrToolTypeNotInToolSet)
+		}
+
+		slots[i], err = domain.NewBoardSlot(set.FamilyId, slotReq.ToolTypeId, slotReq.Polygon)
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	slots, err = s.toolSetRepo.ReplaceSlots(ctx, set.FamilyId, slots)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return slots, nil
+}
+
+// GetToolSetVersions возвращает все версии набора, к которому относится переданная версия
+func (s *Service) GetToolSetVersions(ctx context.Context, toolSetId int64) ([]*ToolSetDTO, error) {
+	const op = "usecase.GetToolSetVersions"
+
+	set, err := s.toolSetRepo.GetById(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	versions, err := s.toolSetRepo.GetAllByFamilyId(ctx, set.FamilyId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrToolSetDTO(versions), nil
+}
+
+// EditToolSet создаёт новую версию набора. Уже выданные по старой версии инструменты сдаются по ней же.
+// Набор, последняя версия которого выведена из оборота, не редактируется: новая версия вернула бы его в оборот
+func (s *Service) EditToolSet(ctx context.Context, req *EditToolSetReq) (*ToolSetDTO, error) {
+	const op = "usecase.EditToolSet"
+
+	set, err := s.toolSetRepo.GetById(ctx, req.ToolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	latest, err := s.toolSetRepo.GetLatestByFamilyIdWithTools(ctx, set.FamilyId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := latest.CanBeIssued(); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := s.checkToolSetNameIsFree(ctx, req.Name, latest.FamilyId); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// новая версия не должна требовать меньше экземпляров, чем уже числится в наборе
+	draft := latest.NewVersion(req.Name)
+	draft.Items = domain.ItemsFromToolIds(req.ToolsIds)
+	registered, err := s.instanceRepo.GetAllByHomeToolSet(ctx, latest.FamilyId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := domain.CheckInstancesFitSet(draft, registered); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	newVersion, err := s.toolSetRepo.CreateWithTools(ctx, draft, req.ToolsIds)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolSetDTO(newVersion), nil
+}
+
+// RetireToolSet выводит версию набора из оборота
+func (s *Service) RetireToolSet(ctx context.Context, toolSetId int64) (*ToolSetDTO, error) {
+	const op = "usecase.RetireToolSet"
+
+	set, err := s.toolSetRepo.GetById(ctx, toolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := set.Retire(time.Now()); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	updSet, err := s.toolSetRepo.Update(ctx, set)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolSetDTO(updSet), nil
+}
+
+// checkToolSetNameIsFree проверяет, что название не занято последней версией другого набора.
+// Проверка даёт понятную ошибку заранее, гонку параллельных запросов закрывает уникальный индекс tool_sets_latest_name_key
+func (s *Service) checkToolSetNameIsFree(ctx context.Context, name string, familyId int64) error {
+	sets, err := s.toolSetRepo.GetAllLatest(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		if set.FamilyId != familyId && strings.EqualFold(set.Name, name) {
+			return e.ErrToolSetExists
+		}
+	}
+
+	return nil
+}
+
+// CreateToolInstance регистрирует экземпляр инструмента с серийным номером
+func (s *Service) CreateToolInstance(ctx context.Context, req *CreateToolInstanceReq) (*ToolInstanceDTO, error) {
+	const op = "usecase.CreateToolInstance"
+
+	if _, err := s.toolTypeRepo.GetById(ctx, req.ToolTypeId); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	homeToolSetId, err := s.resolveToolSetFamily(ctx, req.HomeToolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	newInstance := domain.NewToolInstance(req.SerialNumber, req.ToolTypeId, homeToolSetId)
+	if err := s.checkInstanceFitsHomeSet(ctx, newInstance); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	instance, err := s.instanceRepo.Create(ctx, newInstance)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolInstanceDTO(instance), nil
+}
+
+// ListToolInstances возвращает экземпляры инструментов с фильтрацией по типу, набору и статусу
+func (s *Service) ListToolInstances(ctx context.Context, req *ListToolInstancesReq) ([]*ToolInstanceDTO, error) {
+	const op = "usecase.ListToolInstances"
+
+	filter := repository.ToolInstanceFilter{
+		ToolTypeId: req.ToolTypeId,
+	}
+
+	if req.Status != nil {
+		status, err := domain.ValidateToolInstanceStatus(strings.ToUpper(*req.Status))
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+		filter.Status = &status
+	}
+
+	homeToolSetId, err := s.resolveToolSetFamily(ctx, req.HomeToolSetId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	filter.HomeToolSetId = homeToolSetId
+
+	instances, err := s.instanceRepo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrToolInstanceDTO(instances), nil
+}
+
+// GetToolInstance возвращает экземпляр инструмента
+func (s *Service) GetToolInstance(ctx context.Context, id int64) (*ToolInstanceDTO, error) {
+	const op = "usecase.GetToolInstance"
+
+	instance, err := s.instanceRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return ToToolInstanceDTO(instance), nil
+}
+
+// ChangeToolInstanceStatus переводит экземпляр в карантин, утерянные, списанные или обратно в эксплуатацию
+func (s *Service) ChangeToolInstanceStatus(ctx context.Context, id int64, statusStr string) (*ToolInstanceDTO, error) {
+	const op = "usecase.ChangeToolInstanceStatus"
+
+	status, err := domain.ValidateToolInstanceStatus(strings.ToUpper(statusStr))
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	instance, err := s.instanceRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	wasRegistered := instance.IsRegistered()
+	if err := instance.ChangeStatus(status); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// найденный экземпляр снова занимает место в наборе
+	if !wasRegistered {
+		if err := s.checkInstanceFitsHomeSet(ctx, instance); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	updInstance, err := s.instanceRepo.Update(ctx, instance)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	updInstance.ToolType = instance.ToolType
+
+	return ToToolInstanceDTO(updInstance), nil
+}
+
+// checkInstanceFitsHomeSet проверяет, что с экземпляром instance в его наборе не окажется больше экземпляров типа, чем требует набор
+func (s *Service) checkInstanceFitsHomeSet(ctx context.Context, instance *domain.ToolInstance) error {
+	if instance.HomeToolSetId == nil || !instance.IsRegistered() {
+		return nil
+	}
+
+	set, err := s.toolSetRepo.GetLatestByFamilyIdWithTools(ctx, *instance.HomeToolSetId)
+	if err != nil {
+		return err
+	}
+
+	registered, err := s.instanceRepo.GetAllByHomeToolSet(ctx, *instance.HomeToolSetId)
+	if err != nil {
+		return err
+	}
+
+	// сохранённая копия экземпляра заменяется новым состоянием
+	instances := make([]*domain.ToolInstance, 0, len(registered)+1)
+	for _, registeredInstance := range registered {
+		if registeredInstance.Id != instance.Id {
+			instances = append(instances, registeredInstance)
+		}
+	}
+	instances = append(instances, instance)
+
+	return domain.CheckInstancesFitSet(set, instances)
+}
+
+// GetMlErrorInstances возвращает экземпляры инструментов, на которых QA фиксировал ошибки модели
+func (s *Service) GetMlErrorInstances(ctx context.Context, filter repository.MlErrorFilter) ([]*repository.ToolInstanceWithErrorCount, error) {
+	const op = "usecase.GetMlErrorInstances"
+
+	res, err := s.instanceRepo.GetMlErrorInstances(ctx, filter)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// GetModelVersionStats возвращает сводку по версиям модели, которыми выполнялись сканы
+func (s *Service) GetModelVersionStats(ctx context.Context) ([]*repository.ModelVersionStats, error) {
+	const op = "usecase.GetModelVersionStats"
+
+	res, err := s.cvScanRepo.GetModelVersionStats(ctx)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return res, nil
+}
+
+// RecordCalibration регистрирует поверку экземпляра и переносит срок следующей поверки
+func (s *Service) RecordCalibration(ctx context.Context, req *RecordCalibrationReq) (*CalibrationEventDTO, error) {
+	const op = "usecase.RecordCalibration"
+
+	instance, err := s.instanceRepo.GetById(ctx, req.ToolInstanceId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	now := time.Now().UTC()
+	calibratedAt := now
+	if req.CalibratedAt != nil {
+		calibratedAt = req.CalibratedAt.UTC()
+	}
+
+	event, err := domain.NewCalibrationEvent(instance, calibratedAt, req.NextDueAt, req.PerformedBy, req.Notes, now)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if err := instance.ApplyCalibration(event); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	createdEvent, err := s.calibrationRepo.Create(ctx, event)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return NewCalibrationEventDTO(createdEvent, ToToolInstanceDTO(instance)), nil
+}
+
+// GetCalibrationHistory возвращает журнал поверок экземпляра, начиная с последней
+func (s *Service) GetCalibrationHistory(ctx context.Context, toolInstanceId int64) ([]*CalibrationEventDTO, error) {
+	const op = "usecase.GetCalibrationHistory"
+
+	if _, err := s.instanceRepo.GetById(ctx, toolInstanceId); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	events, err := s.calibrationRepo.GetAllByToolInstanceId(ctx, toolInstanceId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrCalibrationEventDTO(events), nil
+}
+
+// GetCalibrationDue возвращает экземпляры, поверка которых просрочена или наступает в ближайшие days дней
+func (s *Service) GetCalibrationDue(ctx context.Context, days int) ([]*CalibrationDueDTO, error) {
+	const op = "usecase.GetCalibrationDue"
+
+	if days < 0 {
+		return nil, e.Wrap(op, e.ErrInvalidRequestBody)
+	}
+
+	now := time.Now().UTC()
+	instances, err := s.instanceRepo.GetCalibrationDue(ctx, now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res := make([]*CalibrationDueDTO, len(instances))
+	for i, instance := range instances {
+		res[i] = NewCalibrationDueDTO(instance, now)
+	}
+
+	return res, nil
+}
+
+// resolveToolSetFamily переводит идентификатор любой версии набора в идентификатор его семейства
+func (s *Service) resolveToolSetFamily(ctx context.Context, toolSetId *int64) (*int64, error) {
+	if toolSetId == nil {
+		return nil, nil
+	}
+
+	set, err := s.toolSetRepo.GetById(ctx, *toolSetId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &set.FamilyId, nil
+}
+
+const (
+	// idempotencyKeyTTL срок, в течение которого повтор запроса получает сохранённый ответ
+	idempotencyKeyTTL = 24 * time.Hour
+	// idempotencyLockTimeout время, после которого незавершённый запрос считается брошенным и ключ можно занять заново
+	idempotencyLockTimeout = 5 * time.Minute
+)
+
+// BeginIdempotentRequest занимает ключ идемпотентности под запрос.
+// Если запрос с этим ключом и телом уже выполнен, возвращает сохранённый ответ; nil — запрос нужно выполнить
+func (s *Service) BeginIdempotentRequest(ctx context.Context, scope, key, requestHash string) (*IdempotentResponse, error) {
+	const op = "usecase.BeginIdempotentRequest"
+
+	if err := domain.ValidateIdempotencyKey(key); err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	// вторая попытка нужна, если ключ был устаревшим или удалён между Reserve и Get
+	for attempt := 0; attempt < 2; attempt++ {
+		newKey := domain.NewIdempotencyKey(scope, key, requestHash)
+		newKey.CreatedAt = time.Now().UTC()
+
+		reserved, err := s.idempotencyRepo.Reserve(ctx, newKey)
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := s.idempotencyRepo.Get(ctx, scope, key)
+		if errors.Is(err, e.ErrIdempotencyKeyNotFound) {
+			continue
+		} else if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+
+		if existing.IsStale(time.Now().UTC(), idempotencyKeyTTL, idempotencyLockTimeout) {
+			if err := s.idempotencyRepo.Delete(ctx, scope, key); err != nil {
+				return nil, e.Wrap(op, err)
+			}
+			continue
+		}
+
+		if err := existing.CanReplay(requestHash); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+
+		return NewIdempotentResponse(*existing.StatusCode, existing.ResponseBody), nil
+	}
+
+	return nil, e.Wrap(op, e.ErrIdempotencyKeyInProgress)
+}
+
+// PurgeIdempotencyKeys удаляет ключи идемпотентности старше idempotencyKeyTTL: повтор по ним уже не воспроизводится,
+// а незавершённые запросы к этому времени давно считаются брошенными
+func (s *Service) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	const op = "usecase.PurgeIdempotencyKeys"
+
+	count, err := s.idempotencyRepo.DeleteCreatedBefore(ctx, time.Now().UTC().Add(-idempotencyKeyTTL))
+	if err != nil {
+		return 0, e.Wrap(op, err)
+	}
+
+	return count, nil
+}
+
+// CompleteIdempotentRequest сохраняет ответ, который будет отдаваться на повторы запроса
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, scope, key string, statusCode int, responseBody []byte) error {
+	const op = "usecase.CompleteIdempotentRequest"
+
+	if err := s.idempotencyRepo.Complete(ctx, scope, key, statusCode, responseBody); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotentRequest освобождает ключ, если запрос завершился ошибкой сервера и его можно повторить
+func (s *Service) ReleaseIdempotentRequest(ctx context.Context, scope, key string) error {
+	const op = "usecase.ReleaseIdempotentRequest"
+
+	if err := s.idempotencyRepo.Delete(ctx, scope, key); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// CreateMLClassMapping сопоставляет класс модели версии ModelVersion с типом инструмента.
+// Версия domain.AnyModelVersion задаёт сопоставление для всех версий без собственного
+func (s *Service) CreateMLClassMapping(ctx context.Context, req *CreateMLClassMappingReq) (*MLClassMappingDTO, error) {
+	const op = "usecase.CreateMLClassMapping"
+
+	mapping, err := domain.NewMLClassMapping(req.ModelVersion, req.ClassIndex, req.ToolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, req.ToolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	created, err := s.classMappingRepo.Create(ctx, mapping)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	created.ToolType = toolType
+
+	return ToMLClassMappingDTO(created), nil
+}
+
+// GetMLClassMappings возвращает сопоставления классов; если modelVersion задан — только этой версии
+func (s *Service) GetMLClassMappings(ctx context.Context, modelVersion *string) ([]*MLClassMappingDTO, error) {
+	const op = "usecase.GetMLClassMappings"
+
+	if modelVersion != nil {
+		version, err := domain.ValidateModelVersion(*modelVersion)
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+		modelVersion = &version
+	}
+
+	mappings, err := s.classMappingRepo.GetAll(ctx, modelVersion)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return toArrMLClassMappingDTO(mappings), nil
+}
+
+// UpdateMLClassMapping переназначает класс модели на другой тип инструмента
+func (s *Service) UpdateMLClassMapping(ctx context.Context, id, toolTypeId int64) (*MLClassMappingDTO, error) {
+	const op = "usecase.UpdateMLClassMapping"
+
+	toolType, err := s.toolTypeRepo.GetById(ctx, toolTypeId)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	mapping, err := s.classMappingRepo.GetById(ctx, id)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	mapping.ToolTypeId = toolTypeId
+
+	updated, err := s.classMappingRepo.Update(ctx, mapping)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+	updated.ToolType = toolType
+
+	return ToMLClassMappingDTO(updated), nil
+}
+
+// DeleteMLClassMapping удаляет сопоставление; детекции этого класса станут неизвестными инструментами
+func (s *Service) DeleteMLClassMapping(ctx context.Context, id int64) error {
+	const op = "usecase.DeleteMLClassMapping"
+
+	if err := s.classMappingRepo.Delete(ctx, id); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// enqueueShadowScan ставит распознавание в очередь теневой модели. Вызов не блокируется:
+// при заполненной очереди скан пропускается, чтобы теневая модель не замедляла выдачу и сдачу
+func (s *Service) enqueueShadowScan(task *shadowScanTask) {
+	if s.shadowTasks == nil {
+		return
+	}
+
+	select {
+	case s.shadowTasks <- task:
+	default:
+		log.Printf("shadow scan queue is full, skipping scan %d", task.Scan.Id)
+	}
+}
+
+// RunShadowScans обрабатывает очередь теневой модели в workers горутинах до отмены ctx.
+// Если теневая модель не настроена, сразу возвращает управление
+func (s *Service) RunShadowScans(ctx context.Context, workers int) {
+	if s.shadowTasks == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task := <-s.shadowTasks:
+					if err := s.processShadowScan(ctx, task); err != nil {
+						log.Printf("shadow scan %d: %v", task.Scan.Id, err)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// processShadowScan распознаёт изображение скана теневой моделью, определяет статус, который получила бы
+// транзакция, и сохраняет результат. Ошибка теневой модели сохраняется вместе со сканом
+func (s *Service) processShadowScan(ctx context.Context, task *shadowScanTask) error {
+	const op = "usecase.processShadowScan"
+
+	shadowScan := domain.NewShadowScan(task.Scan.Id, task.PrimaryStatus)
+
+	scanResult, err := s.shadowGateway.ScanTools(ctx, task.ScanReq)
+	if err == nil {
+		err = s.resolveToolTypes(ctx, scanResult)
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return e.Wrap(op, err)
+		}
+		shadowScan.Fail(err)
+	} else {
+		filterReq := NewFilterReq(s.defaultThresholds(), s.DuplicateIoU, scanResult.Tools, task.ReferenceSet)
+		filterRes, err := filterRecognizedTools(filterReq)
+		if err != nil {
+			return e.Wrap(op, err)
+		}
+
+		status := checkoutStatus(filterRes, task.ReferenceSet)
+		if task.Scan.ScanType == domain.Checkin {
+			status = checkinStatus(filterRes, task.CountOfChecks)
+		}
+
+		shadowScan.Complete(scanResult.ModelVersion, scanResult.DebugImageUrl, status, scanResult.Tools)
+	}
+
+	if _, err := s.shadowScanRepo.Create(ctx, shadowScan); err != nil {
+		return e.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// GetShadowComparison сравнивает результаты основной и теневой моделей на одних и тех же сканах
+func (s *Service) GetShadowComparison(ctx context.Context, filter repository.ShadowReportFilter) (*ShadowComparisonDTO, error) {
+	const op = "usecase.GetShadowComparison"
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, e.Wrap(op, e.ErrDateRangeInvalid)
+	}
+
+	comparison, err := s.shadowScanRepo.GetComparison(ctx, filter)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	return NewShadowComparisonDTO(comparison), nil
+}
+
+// GetThresholdSweep повторяет автоматическую проверку сохранённых сканов сдачи с каждой парой порогов из сетки
+// и сравнивает результат с решениями QA, чтобы выбрать пороги по данным
+func (s *Service) GetThresholdSweep(ctx context.Context, req *ThresholdSweepReq) (*ThresholdSweepRes, error) {
+	const op = "usecase.GetThresholdSweep"
+
+	if req.Filter.From != nil && req.Filter.To != nil && !req.Filter.From.Before(*req.Filter.To) {
+		return nil, e.Wrap(op, e.ErrDateRangeInvalid)
+	}
+
+	confidences, err := thresholdGrid(req.Confidence, 0, 1)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	cosineSims, err := thresholdGrid(req.CosineSim, -1, 1)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	if len(confidences)*len(cosineSims) > maxThresholdSweepPairs {
+		return nil, e.Wrap(op, e.ErrThresholdSweepInvalid)
+	}
+
+	if req.ToolTypeId != nil {
+		if _, err := s.toolTypeRepo.GetById(ctx, *req.ToolTypeId); err != nil {
+			return nil, e.Wrap(op, err)
+		}
+	}
+
+	// лишний скан сообщает, что в периоде сканов больше ограничения
+	history, err := s.cvScanRepo.GetCheckinHistory(ctx, req.Filter, maxThresholdSweepScans+1)
+	if err != nil {
+		return nil, e.Wrap(op, err)
+	}
+
+	res := &ThresholdSweepRes{}
+	if len(history) > maxThresholdSweepScans {
+		history = history[:maxThresholdSweepScans]
+		res.Truncated = true
+	}
+	res.ScansCount = len(history)
+	defaults := s.defaultThresholds()
+	toolSets := make(map[int64]*domain.ToolSet)
+	scans := make([]*calibrationScan, 0, len(history))
+	for _, item := range history {
+		toolSet, ok := toolSets[item.ToolSetId]
+		if !ok {
+			toolSet, err = s.toolSetRepo.GetByIdWithTools(ctx, item.ToolSetId)
+			if err != nil {
+				return nil, e.Wrap(op, err)
+			}
+			toolSets[item.ToolSetId] = toolSet
+		}
+
+		scan, err := newCalibrationScan(item, toolSet, defaults, s.DuplicateIoU)
+		if err != nil {
+			return nil, e.Wrap(op, err)
+		}
+		item.Details = nil
+		scans = append(scans, scan)
+		if scan.Resolved {
+			res.ResolvedScansCount++
+		}
+	}
+
+	for _, scan := range scans {
+		if scan.escalates(scan.CurrentManualCheck) {
+			res.CurrentEscalations++
+		}
+	}
+
+	res.Pairs = make([]*ThresholdPairStats, 0, len(confidences)*len(cosineSims))
+	for _, confidence := range confidences {
+		for _, cosineSim := range cosineSims {
+			pair := domain.Thresholds{Confidence: confidence, CosineSim: cosineSim}
+			res.Pairs = append(res.Pairs, replayThresholds(scans, pair, req.ToolTypeId))
+		}
+	}
+
+	if len(res.Pairs) > 0 {
+		first := res.Pairs[0]
+		res.LabeledDetections = first.TruePositives + first.FalsePositives + first.FalseNegatives + first.TrueNegatives
+	}
+
+	return res, nil
+}
+
+// Readiness сообщает, готов ли сервис выполнять проверки: ML-сервис доступен и circuit breaker не разомкнут
+func (s *Service) Readiness() *ReadinessRes {
+	health := s.mlGateway.Health()
+
+	return &ReadinessRes{
+		Ready: health.Healthy && health.CircuitState != MLCircuitOpen,
+		ML:    health,
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"airport-tools-backend/internal/config"
+	"context"
+	"log"
+	"time"
+)
+
+// IdempotencyKeyPurgeProcessor удаляет истёкшие ключи идемпотентности
+type IdempotencyKeyPurgeProcessor interface {
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// IdempotencyKeyPurger периодически удаляет истёкшие ключи идемпотентности. Удаление по сроку идемпотентно,
+// поэтому несколько экземпляров сервиса могут выполнять его одновременно
+type IdempotencyKeyPurger struct {
+	processor IdempotencyKeyPurgeProcessor
+	cfg       config.Idempotency
+}
+
+func NewIdempotencyKeyPurger(processor IdempotencyKeyPurgeProcessor, cfg config.Idempotency) *IdempotencyKeyPurger {
+	return &IdempotencyKeyPurger{
+		processor: processor,
+		cfg:       cfg,
+	}
+}
+
+// Run удаляет истёкшие ключи каждые PurgeInterval до отмены ctx
+func (p *IdempotencyKeyPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := p.processor.PurgeIdempotencyKeys(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("idempotency key purger: %v", err)
+		} else if count > 0 {
+			log.Printf("idempotency key purger: %d expired keys removed", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"airport-tools-backend/internal/config"
+	"context"
+	"log"
+	"time"
+)
+
+// QAEscalationProcessor эскалирует транзакции, превысившие SLA очереди QA
+type QAEscalationProcessor interface {
+	EscalateOverdueQA(ctx context.Context) (int64, error)
+}
+
+// QAEscalator периодически проверяет очередь QA на просроченные транзакции. Эскалация отмечается в БД
+// и выполняется один раз для каждой транзакции, поэтому несколько экземпляров сервиса не дублируют её
+type QAEscalator struct {
+	processor QAEscalationProcessor
+	cfg       config.QAQueue
+}
+
+func NewQAEscalator(processor QAEscalationProcessor, cfg config.QAQueue) *QAEscalator {
+	return &QAEscalator{
+		processor: processor,
+		cfg:       cfg,
+	}
+}
+
+// Run проверяет очередь каждые EscalationInterval до отмены ctx
+func (e *QAEscalator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.EscalationInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := e.processor.EscalateOverdueQA(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("qa escalator: %v", err)
+		} else if count > 0 {
+			log.Printf("qa escalator: %d transactions exceeded QA SLA of %s", count, e.cfg.SLA)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"airport-tools-backend/internal/config"
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ScanProcessor выполняет задания асинхронной проверки из очереди
+type ScanProcessor interface {
+	ProcessNextScanJob(ctx context.Context) (bool, error)
+	RequeueStaleScanJobs(ctx context.Context, timeout time.Duration) (int64, error)
+}
+
+// ScanWorkerPool пул воркеров, разбирающих очередь scan_jobs. Очередь хранится в Postgres,
+// поэтому задания переживают перезапуск, а несколько экземпляров сервиса могут работать параллельно
+type ScanWorkerPool struct {
+	processor ScanProcessor
+	cfg       config.ScanWorker
+}
+
+func NewScanWorkerPool(processor ScanProcessor, cfg config.ScanWorker) *ScanWorkerPool {
+	return &ScanWorkerPool{
+		processor: processor,
+		cfg:       cfg,
+	}
+}
+
+// Run запускает воркеры и блокируется до отмены ctx и завершения уже начатых заданий
+func (p *ScanWorkerPool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.requeueLoop(ctx)
+	}()
+
+	for i := 0; i < p.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.workLoop(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (p *ScanWorkerPool) workLoop(ctx context.Context) {
+	for {
+		processed, err := p.processNext(ctx)
+		if err != nil {
+			log.Printf("scan worker: %v", err)
+		}
+
+		if processed && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.PollInterval):
+		}
+	}
+}
+
+// processNext обрабатывает одно задание; паника не должна останавливать воркер и весь сервис
+func (p *ScanWorkerPool) processNext(ctx context.Context) (processed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scan worker: panic: %v\n%s", r, debug.Stack())
+			processed, err = true, fmt.Errorf("scan worker panicked: %v", r)
+		}
+	}()
+
+	// начатое задание доводится до конца даже при остановке сервиса, иначе оно повиснет до возврата в очередь
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.JobTimeout)
+	defer cancel()
+
+	return p.processor.ProcessNextScanJob(jobCtx)
+}
+
+func (p *ScanWorkerPool) requeueLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.JobTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		// задание с истёкшим JobTimeout может ещё фиксировать результат, поэтому в очередь возвращаются
+		// только задания, не завершённые за удвоенный JobTimeout
+		count, err := p.processor.RequeueStaleScanJobs(ctx, 2*p.cfg.JobTimeout)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("scan worker: requeue stale jobs: %v", err)
+		} else if count > 0 {
+			log.Printf("scan worker: requeued %d stale jobs", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
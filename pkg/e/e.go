@@ -10,8 +10,28 @@ var (
 	ErrToolTypeExists   = fmt.Errorf("tool type exists")
 	ErrToolTypeIsUsed   = fmt.Errorf("tool type is used")
 
+	ErrInvalidEmbeddingSize = errors.New("invalid reference embedding size")
+	ErrToolTypeNoSamples    = errors.New("no samples with detected tool of this type")
+
+	ErrToolTypeReferenceNotFound = errors.New("tool type reference not found")
+
 	ErrToolSetNotFound = fmt.Errorf("tool set not found")
 	ErrToolSetExists   = fmt.Errorf("tool set exists")
+	ErrToolSetRetired  = errors.New("tool set version is retired")
+	// ErrToolSetConcurrentEdit набор одновременно отредактирован другим запросом; редактирование нужно повторить
+	ErrToolSetConcurrentEdit = errors.New("tool set was edited concurrently, retry")
+
+	ErrToolInstanceNotFound        = errors.New("tool instance not found")
+	ErrToolInstanceExists          = errors.New("tool instance with this serial number exists")
+	ErrToolInstanceStatusInvalid   = errors.New("invalid tool instance status")
+	ErrToolInstanceScrapped        = errors.New("tool instance is scrapped")
+	ErrToolInstancesUnavailable    = errors.New("not enough serviceable tool instances in the set")
+	ErrToolInstancesExceedQuantity = errors.New("more tool instances are registered in the set than it requires")
+
+	ErrToolCalibrationOverdue     = errors.New("tool calibration is overdue")
+	ErrCalibrationIntervalInvalid = errors.New("calibration interval must be positive")
+	ErrCalibrationDueDateRequired = errors.New("next calibration due date is required for tool type without interval")
+	ErrCalibrationDateInvalid     = errors.New("invalid calibration dates")
 
 	ErrTransactionNotFound       = fmt.Errorf("transaction not found")
 	ErrTransactionUnfinished     = fmt.Errorf("you have an unfinished issue")
@@ -35,6 +55,8 @@ var (
 	ErrMLServiceDecode = errors.New("failed to decode ML service response")
 	ErrIncorrectImage  = errors.New("incorrect image sent")
 
+	ErrMLServiceUnavailable = errors.New("ML service is unavailable")
+
 	ErrInvalidRequestBody      = errors.New("invalid request body")
 	ErrRequestNotSupported     = errors.New("request not supported")
 	ErrRequestNoStatisticsType = errors.New("request has no statistics type")
@@ -44,6 +66,52 @@ var (
 
 	ErrRoleExists   = errors.New("role exists")
 	ErrRoleNotFound = errors.New("role not found")
+
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrInvalidCredentials = errors.New("invalid employee id or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrForbidden          = errors.New("access denied for this role")
+	ErrLoginLocked        = errors.New("login is temporarily locked after too many failed attempts")
+	ErrPinChangeRequired  = errors.New("pin must be changed on this login")
+	ErrPinNotChanged      = errors.New("new pin must differ from the current one")
+
+	ErrBadgeNotFound  = errors.New("badge not found")
+	ErrBadgeExists    = errors.New("badge exists")
+	ErrBadgeRevoked   = errors.New("badge is revoked")
+	ErrBadgeNotActive = errors.New("badge is not active yet")
+
+	ErrUserNotQualityAuditor  = errors.New("user is not a quality auditor")
+	ErrVerificationSelfReview = errors.New("quality auditor cannot verify own transaction")
+
+	ErrTransactionConcurrentCheck = errors.New("another check for this user is already in progress")
+
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+	ErrIdempotencyKeyInvalid    = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyConflict   = errors.New("idempotency key reused with a different request body")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+
+	ErrScanJobNotFound = errors.New("scan job not found")
+	// ErrScanJobNotRunning попытка обработки задания устарела: задание возвращено в очередь или уже завершено
+	ErrScanJobNotRunning = errors.New("scan job attempt is no longer running")
+
+	ErrMLClassMappingNotFound = errors.New("ml class mapping not found")
+	ErrMLClassMappingExists   = errors.New("ml class mapping for this model version and class index exists")
+	ErrMLClassIndexInvalid    = errors.New("ml class index must be non-negative")
+	ErrModelVersionInvalid    = errors.New("invalid model version")
+
+	ErrDateRangeInvalid = errors.New("invalid date range")
+
+	ErrThresholdInvalid      = errors.New("confidence threshold must be in [0, 1] and cosine similarity threshold in [-1, 1]")
+	ErrToolTypeNotInToolSet  = errors.New("tool type is not in the tool set")
+	ErrThresholdSweepInvalid = errors.New("invalid threshold sweep range")
+
+	ErrBoardSlotInvalid = errors.New("board slot polygon must have at least 3 points")
+
+	ErrQAQueueItemNotFound        = errors.New("transaction is not in the QA queue")
+	ErrQAQueueItemClaimed         = errors.New("transaction is claimed by another quality auditor")
+	ErrQAQueueItemAssigned        = errors.New("transaction is assigned to another quality auditor")
+	ErrQAQueueItemNotClaimed      = errors.New("transaction is not claimed by you")
+	ErrTransactionAlreadyResolved = errors.New("transaction is already resolved")
 )
 
 func Wrap(msg string, err error) error {